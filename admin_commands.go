@@ -0,0 +1,338 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// adminCommands are the commands that only the admins of the chatbot can run, in any chat.
+var adminCommands = commandSet{
+	{
+		name:        "allow",
+		args:        "[chat ID]",
+		description: "Allow the chatbot to respond in this chat or the given one.",
+		run:         (*Chat).runAllowCommand,
+	},
+	{
+		name:        "deny",
+		args:        "[chat ID]",
+		description: "Deny the chatbot to respond in this chat or the given one, deleting its history.",
+		run:         (*Chat).runDenyCommand,
+	},
+	{
+		name:        "prompt",
+		args:        "[template]",
+		description: "Set the system prompt template of this chat, or reset it to the default one if empty.",
+		run:         (*Chat).runPromptCommand,
+	},
+	{
+		name:        "tools",
+		args:        "[names|none]",
+		description: "Set the tools that the chatbot can use in this chat, or show them if empty.",
+		run:         (*Chat).runToolsCommand,
+	},
+	{
+		name:        "usage",
+		description: "Show usage statistics of this chat and of the chatbot.",
+		run:         (*Chat).runUsageCommand,
+	},
+	{
+		name:        "pause",
+		description: "Pause the chatbot in all chats. Messages are still stored.",
+		run:         (*Chat).runPauseCommand,
+	},
+	{
+		name:        "resume",
+		description: "Resume the chatbot in all chats.",
+		run:         (*Chat).runResumeCommand,
+	},
+	{
+		name:        "purge",
+		description: "Delete the history of this chat.",
+		run:         (*Chat).runPurgeCommand,
+	},
+}
+
+// isAdmin reports whether the sender of msg is an admin of the chatbot. Messages sent from the
+// account of the chatbot itself, e.g. from the phone it's linked to, are always from an admin.
+func (c *Client) isAdmin(msg message) bool {
+	if msg.Info.IsFromMe {
+		return true
+	}
+
+	_, ok := c.adminIDs[msg.Info.Sender.User]
+	return ok
+}
+
+func (c *Chat) runAllowCommand(ctx context.Context, msg message, args string) (string, error) {
+	chatID := c.id
+	if args != "" {
+		chatID = args
+	}
+
+	err := c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		return c.client.store.CreateChat(ctx, tx, data.Chat{ID: chatID})
+	})
+	if errors.Is(err, data.ErrAlreadyExists) {
+		return fmt.Sprintf("Chat %s was already allowed.", chatID), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to create chat in data store: %w", err)
+	}
+
+	return fmt.Sprintf("Allowed chat %s.", chatID), nil
+}
+
+func (c *Chat) runDenyCommand(ctx context.Context, msg message, args string) (string, error) {
+	chatID := c.id
+	if args != "" {
+		chatID = args
+	}
+
+	err := c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		return c.client.store.DeleteChat(ctx, tx, chatID)
+	})
+	if errors.Is(err, data.ErrNotFound) {
+		return fmt.Sprintf("Chat %s was not allowed.", chatID), nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to delete chat from data store: %w", err)
+	}
+
+	return fmt.Sprintf("Denied chat %s.", chatID), nil
+}
+
+func (c *Chat) runPromptCommand(ctx context.Context, msg message, args string) (string, error) {
+	if args != "" {
+		_, err := ParsePromptTemplate(c.id, args)
+		if err != nil {
+			return fmt.Sprintf("Invalid system prompt: %s", err), nil
+		}
+	}
+
+	err := c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		chat, err := c.client.store.Chat(ctx, tx, c.id)
+		if err != nil {
+			return err
+		}
+
+		chat.SystemPrompt = args
+
+		return c.client.store.UpdateChat(ctx, tx, chat)
+	})
+	if errors.Is(err, data.ErrNotFound) {
+		return "This chat is not allowed.", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to update chat in data store: %w", err)
+	}
+
+	if args == "" {
+		return "Reset the system prompt of this chat to the default one.", nil
+	}
+	return "Set the system prompt of this chat.", nil
+}
+
+// runToolsCommand sets the tools that the model can call in the chat, given as a list of names
+// separated by spaces or commas.
+func (c *Chat) runToolsCommand(ctx context.Context, msg message, args string) (string, error) {
+	available := c.client.toolNames()
+
+	if args == "" {
+		var chat data.Chat
+		err := c.client.execTx(ctx, sql.TxOptions{
+			Isolation: sql.LevelReadCommitted,
+			ReadOnly:  true,
+		}, func(tx data.Tx) error {
+			var err error
+			chat, err = c.client.store.Chat(ctx, tx, c.id)
+			return err
+		})
+		if errors.Is(err, data.ErrNotFound) {
+			return "This chat is not allowed.", nil
+		}
+		if err != nil {
+			return "", fmt.Errorf("failed to get chat from data store: %w", err)
+		}
+
+		enabled := "none"
+		if len(chat.Tools) > 0 {
+			enabled = strings.Join(chat.Tools, ", ")
+		}
+
+		return fmt.Sprintf("Enabled tools: %s.\nAvailable tools: %s.", enabled, strings.Join(available, ", ")), nil
+	}
+
+	var tools []string
+	if !strings.EqualFold(args, "none") {
+		names := strings.FieldsFunc(args, func(r rune) bool {
+			return r == ',' || unicode.IsSpace(r)
+		})
+		for _, name := range names {
+			if _, ok := c.client.tools[name]; !ok {
+				return fmt.Sprintf("Unknown tool %q. Available tools: %s.", name, strings.Join(available, ", ")), nil
+			}
+
+			var duplicate bool
+			for _, tool := range tools {
+				duplicate = duplicate || tool == name
+			}
+			if !duplicate {
+				tools = append(tools, name)
+			}
+		}
+	}
+
+	err := c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		chat, err := c.client.store.Chat(ctx, tx, c.id)
+		if err != nil {
+			return err
+		}
+
+		chat.Tools = tools
+
+		return c.client.store.UpdateChat(ctx, tx, chat)
+	})
+	if errors.Is(err, data.ErrNotFound) {
+		return "This chat is not allowed.", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to update chat in data store: %w", err)
+	}
+
+	if len(tools) == 0 {
+		return "Disabled the tools of this chat.", nil
+	}
+	return fmt.Sprintf("Enabled tools: %s.", strings.Join(tools, ", ")), nil
+}
+
+func (c *Chat) runUsageCommand(ctx context.Context, msg message, args string) (string, error) {
+	var (
+		chatMessages   []data.Message
+		recentMessages []data.Message
+		chats          []data.Chat
+	)
+
+	err := c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	}, func(tx data.Tx) error {
+		var err error
+
+		chatMessages, err = c.client.store.Messages(ctx, tx, c.id)
+		if err != nil {
+			return fmt.Errorf("failed to get messages from data store: %w", err)
+		}
+
+		recentMessages, err = c.client.store.AllMessagesSince(ctx, tx, time.Now().Add(-24*time.Hour))
+		if err != nil {
+			return fmt.Errorf("failed to get recent messages from data store: %w", err)
+		}
+
+		chats, err = c.client.store.Chats(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to get chats from data store: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	botID := c.client.botID()
+
+	var chatResponses int
+	for _, m := range chatMessages {
+		if m.SenderID == botID {
+			chatResponses++
+		}
+	}
+
+	recentChats := make(map[string]struct{})
+	var recentResponses int
+	for _, m := range recentMessages {
+		recentChats[m.ChatID] = struct{}{}
+		if m.SenderID == botID {
+			recentResponses++
+		}
+	}
+
+	status := "running"
+	if c.client.paused.Load() {
+		status = "paused"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "This chat: %d messages, %d responses.\n", len(chatMessages), chatResponses)
+	fmt.Fprintf(
+		&sb,
+		"Last 24 hours: %d messages, %d responses, in %d chats.\n",
+		len(recentMessages),
+		recentResponses,
+		len(recentChats),
+	)
+	fmt.Fprintf(&sb, "Allowed chats: %d.\n", len(chats))
+	fmt.Fprintf(&sb, "Chatbot is %s.", status)
+
+	return sb.String(), nil
+}
+
+func (c *Chat) runPauseCommand(ctx context.Context, msg message, args string) (string, error) {
+	if !c.client.paused.CompareAndSwap(false, true) {
+		return "Chatbot was already paused.", nil
+	}
+
+	return "Paused chatbot.", nil
+}
+
+func (c *Chat) runResumeCommand(ctx context.Context, msg message, args string) (string, error) {
+	if !c.client.paused.CompareAndSwap(true, false) {
+		return "Chatbot was not paused.", nil
+	}
+
+	return "Resumed chatbot.", nil
+}
+
+func (c *Chat) runPurgeCommand(ctx context.Context, msg message, args string) (string, error) {
+	err := c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		err := c.client.store.DeleteMessages(ctx, tx, c.id)
+		if err != nil {
+			return fmt.Errorf("failed to delete messages from data store: %w", err)
+		}
+
+		err = c.client.store.DeleteSummary(ctx, tx, c.id)
+		if err != nil {
+			return fmt.Errorf("failed to delete summary from data store: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	return "Deleted the history of this chat.", nil
+}
@@ -0,0 +1,180 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/happybydefault/chatbot/data"
+	"github.com/happybydefault/chatbot/memory"
+)
+
+// storedChat returns the chat with the given ID in the data store.
+func storedChat(t *testing.T, dataStore *memory.Store, chatID string) (data.Chat, error) {
+	t.Helper()
+
+	ctx := context.Background()
+	tx, err := dataStore.BeginTx(ctx, sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %s", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	return dataStore.Chat(ctx, tx, chatID)
+}
+
+// TestChatRunAdminCommands runs admin commands in order in the same chat, whose history has two
+// messages at the start.
+func TestChatRunAdminCommands(t *testing.T) {
+	whatsApp := &fakeWhatsApp{}
+	chat, dataStore := newTestChat(t, whatsApp, &fakeCompleter{})
+	chat.client.commandPrefix = "/"
+	chat.client.adminIDs = map[string]struct{}{testUserID: {}}
+	chat.client.tools = map[string]Tool{"echo": echoTool()}
+	createMessages(t, chat, testUserID, testBotID)
+
+	const otherChatID = "15559876543"
+
+	// wantChat checks the chat of the test in the data store after a command.
+	wantChat := func(want func(chat data.Chat) bool) func(t *testing.T) {
+		return func(t *testing.T) {
+			chat, err := storedChat(t, dataStore, testUserID)
+			if err != nil {
+				t.Fatalf("failed to get chat: %s", err)
+			}
+			if !want(chat) {
+				t.Errorf("got chat %+v after command", chat)
+			}
+		}
+	}
+
+	tests := []struct {
+		text       string
+		want       string
+		wantPrefix bool // Whether want is only the start of the reply.
+		check      func(t *testing.T)
+	}{
+		{
+			text: "/usage",
+			want: "This chat: 2 messages, 1 responses.\n" +
+				"Last 24 hours: 2 messages, 1 responses, in 1 chats.\n" +
+				"Allowed chats: 1.\n" +
+				"Chatbot is running.",
+		},
+		{
+			text:       "/prompt You are {{.Missing}}.",
+			want:       "Invalid system prompt: failed to execute template:",
+			wantPrefix: true,
+		},
+		{
+			text:  "/prompt You are a pirate.",
+			want:  "Set the system prompt of this chat.",
+			check: wantChat(func(chat data.Chat) bool { return chat.SystemPrompt == "You are a pirate." }),
+		},
+		{
+			text:  "/prompt",
+			want:  "Reset the system prompt of this chat to the default one.",
+			check: wantChat(func(chat data.Chat) bool { return chat.SystemPrompt == "" }),
+		},
+		{text: "/tools", want: "Enabled tools: none.\nAvailable tools: echo."},
+		{text: "/tools weather", want: `Unknown tool "weather". Available tools: echo.`},
+		{
+			text:  "/tools echo, echo",
+			want:  "Enabled tools: echo.",
+			check: wantChat(func(chat data.Chat) bool { return reflect.DeepEqual(chat.Tools, []string{"echo"}) }),
+		},
+		{text: "/tools", want: "Enabled tools: echo.\nAvailable tools: echo."},
+		{
+			text:  "/tools none",
+			want:  "Disabled the tools of this chat.",
+			check: wantChat(func(chat data.Chat) bool { return len(chat.Tools) == 0 }),
+		},
+		{text: "/pause", want: "Paused chatbot."},
+		{text: "/pause", want: "Chatbot was already paused."},
+		{text: "/resume", want: "Resumed chatbot."},
+		{text: "/resume", want: "Chatbot was not paused."},
+		{
+			text: "/purge",
+			want: "Deleted the history of this chat.",
+			check: func(t *testing.T) {
+				if messages := storedMessages(t, dataStore); len(messages) != 0 {
+					t.Errorf("got %d stored messages, want none", len(messages))
+				}
+			},
+		},
+		{
+			text: "/deny",
+			want: "Denied chat 15551234567.",
+			check: func(t *testing.T) {
+				_, err := storedChat(t, dataStore, testUserID)
+				if !errors.Is(err, data.ErrNotFound) {
+					t.Errorf("got error %v, want the chat not to be found", err)
+				}
+			},
+		},
+		{text: "/deny", want: "Chat 15551234567 was not allowed."},
+		{text: "/prompt You are a pirate.", want: "This chat is not allowed."},
+		{text: "/allow", want: "Allowed chat 15551234567."},
+		{text: "/allow", want: "Chat 15551234567 was already allowed."},
+		{
+			text: "/allow " + otherChatID,
+			want: "Allowed chat 15559876543.",
+			check: func(t *testing.T) {
+				_, err := storedChat(t, dataStore, otherChatID)
+				if err != nil {
+					t.Errorf("failed to get allowed chat: %s", err)
+				}
+			},
+		},
+		{text: "/deny " + otherChatID, want: "Denied chat 15559876543."},
+	}
+
+	for i, tt := range tests {
+		msg := newTestMessage("command", &waProto.Message{Conversation: proto.String(tt.text)})
+		err := chat.handleMessage(msg)
+		if err != nil {
+			t.Fatalf("failed to handle %q: %s", tt.text, err)
+		}
+
+		sent := whatsApp.sentTexts()
+		if len(sent) != i+1 {
+			t.Fatalf("sent %d messages in response to %q, want 1", len(sent)-i, tt.text)
+		}
+		if reply := sent[i]; reply != tt.want && !(tt.wantPrefix && strings.HasPrefix(reply, tt.want)) {
+			t.Fatalf("sent %q in response to %q, want %q", reply, tt.text, tt.want)
+		}
+
+		if tt.check != nil {
+			tt.check(t)
+		}
+	}
+}
+
+func TestChatRunAdminCommandByUser(t *testing.T) {
+	whatsApp := &fakeWhatsApp{}
+	chat, dataStore := newTestChat(t, whatsApp, &fakeCompleter{content: "I can't do that."})
+	chat.client.commandPrefix = "/"
+
+	msg := newTestMessage("1", &waProto.Message{Conversation: proto.String("/deny")})
+	err := chat.handleMessage(msg)
+	if err != nil {
+		t.Fatalf("failed to handle message: %s", err)
+	}
+
+	// The command of a user who isn't an admin is responded as any other message.
+	_, err = storedChat(t, dataStore, testUserID)
+	if err != nil {
+		t.Errorf("failed to get chat, which should still be allowed: %s", err)
+	}
+	if sent := whatsApp.sentTexts(); len(sent) != 1 || sent[0] != "I can't do that." {
+		t.Errorf("sent %q, want the response of the completer", sent)
+	}
+}
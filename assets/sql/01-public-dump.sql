@@ -1,99 +0,0 @@
---
--- PostgreSQL database dump
---
-
--- Dumped from database version 15.1 (Debian 15.1-1.pgdg110+1)
--- Dumped by pg_dump version 15.1
-
-SET statement_timeout = 0;
-SET lock_timeout = 0;
-SET idle_in_transaction_session_timeout = 0;
-SET client_encoding = 'UTF8';
-SET standard_conforming_strings = on;
-SELECT pg_catalog.set_config('search_path', '', false);
-SET check_function_bodies = false;
-SET xmloption = content;
-SET client_min_messages = warning;
-SET row_security = off;
-
-SET default_tablespace = '';
-
-SET default_table_access_method = heap;
-
---
--- Name: chats; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.chats (
-    chat_id text NOT NULL
-);
-
-
-ALTER TABLE public.chats OWNER TO postgres;
-
---
--- Name: messages; Type: TABLE; Schema: public; Owner: postgres
---
-
-CREATE TABLE public.messages (
-    chat_id text NOT NULL,
-    sender_id text NOT NULL,
-    message_id text NOT NULL,
-    conversation text DEFAULT ''::text NOT NULL,
-    "timestamp" timestamp with time zone DEFAULT (now() AT TIME ZONE 'UTC'::text) NOT NULL,
-    created_at timestamp with time zone DEFAULT (now() AT TIME ZONE 'UTC'::text) NOT NULL                         
-);
-
-
-ALTER TABLE public.messages OWNER TO postgres;
-
---
--- Data for Name: chats; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.chats (chat_id) FROM stdin;
-\.
-
-
---
--- Data for Name: messages; Type: TABLE DATA; Schema: public; Owner: postgres
---
-
-COPY public.messages (chat_id, sender_id, message_id, conversation, "timestamp", created_at) FROM stdin;
-\.
-
-
---
--- Name: chats chats_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.chats
-    ADD CONSTRAINT chats_pkey PRIMARY KEY (chat_id);
-
-
---
--- Name: messages messages_pkey; Type: CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.messages
-    ADD CONSTRAINT messages_pkey PRIMARY KEY (message_id);
-
-
---
--- Name: messages_chat_id_index; Type: INDEX; Schema: public; Owner: postgres
---
-
-CREATE INDEX messages_chat_id_index ON public.messages USING btree (chat_id);
-
-
---
--- Name: messages messages_chats_chat_id_fk; Type: FK CONSTRAINT; Schema: public; Owner: postgres
---
-
-ALTER TABLE ONLY public.messages
-    ADD CONSTRAINT messages_chats_chat_id_fk FOREIGN KEY (chat_id) REFERENCES public.chats(chat_id) ON DELETE CASCADE;
-
-
---
--- PostgreSQL database dump complete
---
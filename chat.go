@@ -10,18 +10,18 @@ import (
 	"sync/atomic"
 	"time"
 
-	gpt "github.com/sashabaranov/go-gpt3"
+	"go.mau.fi/whatsmeow"
 	waProto "go.mau.fi/whatsmeow/binary/proto"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 	"go.uber.org/zap"
-	"google.golang.org/protobuf/proto"
 
 	"github.com/happybydefault/chatbot/data"
 )
 
 type message struct {
 	*events.Message
+	content     messageContent
 	clientState State
 }
 
@@ -29,7 +29,8 @@ type Chat struct {
 	client *Client
 	logger *zap.Logger
 
-	id           string
+	id           string    // User part of jid, which identifies the chat in the data store.
+	jid          types.JID // Either a user or a group JID.
 	messagesChan chan message
 	wg           sync.WaitGroup
 
@@ -37,13 +38,14 @@ type Chat struct {
 	pendingMessages atomic.Int32
 }
 
-func (c *Client) newChat(id string) *Chat {
-	logger := c.logger.With(zap.String("chat_id", id))
+func (c *Client) newChat(jid types.JID) *Chat {
+	logger := c.logger.With(zap.String("chat_id", jid.User))
 
 	return &Chat{
 		client:       c,
 		logger:       logger,
-		id:           id,
+		id:           jid.User,
+		jid:          jid.ToNonAD(),
 		messagesChan: make(chan message),
 	}
 }
@@ -74,6 +76,13 @@ func (c *Chat) handleMessages() {
 func (c *Chat) handleMessage(msg message) error {
 	logger := c.logger.With(zap.String("message_id", msg.Info.ID))
 
+	if msg.clientState == StateSynced && c.client.isAdmin(msg) {
+		isCommand, err := c.runAdminCommand(msg)
+		if isCommand {
+			return err
+		}
+	}
+
 	isAllowed, err := c.isAllowed()
 	if err != nil {
 		return fmt.Errorf("failed to check if chat is allowed: %w", err)
@@ -88,13 +97,35 @@ func (c *Chat) handleMessage(msg message) error {
 		return fmt.Errorf("failed to mark message as read: %w", err)
 	}
 
-	if msg.Message.Message.GetConversation() == "" {
-		logger.Debug("ignored message with empty conversation")
+	err = c.transcribe(&msg)
+	if err != nil {
+		return fmt.Errorf("failed to transcribe audio: %w", err)
+	}
+
+	c.downloadImage(&msg)
+
+	err = c.downloadDocument(&msg)
+	if err != nil {
+		return fmt.Errorf("failed to download document: %w", err)
+	}
+
+	if msg.content.text == "" && msg.content.image == nil && msg.content.documentChunks == nil {
+		logger.Debug("ignored message without text, image or document")
 		return nil
 	}
 
-	c.pendingMessages.Add(1)
-	defer c.pendingMessages.Add(-1)
+	if msg.clientState == StateSynced {
+		isCommand, err := c.runUserCommand(msg)
+		if isCommand {
+			return err
+		}
+	}
+
+	isTriggered := c.client.isTriggered(msg)
+	if isTriggered {
+		c.pendingMessages.Add(1)
+		defer c.pendingMessages.Add(-1)
+	}
 
 	c.mu.Lock()
 	defer c.mu.Unlock()
@@ -102,7 +133,7 @@ func (c *Chat) handleMessage(msg message) error {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
-	err = c.storeMessageReceived(ctx, msg.Message)
+	err = c.storeMessageReceived(ctx, msg)
 	if err != nil {
 		return fmt.Errorf("failed to store received message: %w", err)
 	}
@@ -111,19 +142,60 @@ func (c *Chat) handleMessage(msg message) error {
 		logger.Debug("skipped responding to chat because client is not synced")
 		return nil
 	}
+	if !isTriggered {
+		logger.Debug("skipped responding to chat because message doesn't mention the chatbot")
+		return nil
+	}
+	if c.client.paused.Load() {
+		logger.Debug("skipped responding to chat because chatbot is paused")
+		return nil
+	}
 	if c.pendingMessages.Load() > 1 {
 		logger.Debug("skipped responding to chat because there are pending messages")
 		return nil
 	}
 
-	err = c.respond()
+	err = c.respond(msg.Message)
 	if err != nil {
 		return fmt.Errorf("failed to respond to chat: %w", err)
 	}
 
+	err = c.summarize()
+	if err != nil {
+		logger.Error("failed to summarize chat", zap.Error(err))
+	}
+
 	return nil
 }
 
+// runAdminCommand runs the admin command that msg invokes, if any. It returns false if msg doesn't
+// invoke an admin command.
+func (c *Chat) runAdminCommand(msg message) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	isCommand, err := c.runCommand(adminCommands, msg, msg.content.text)
+	if err != nil {
+		return isCommand, fmt.Errorf("failed to run admin command: %w", err)
+	}
+
+	return isCommand, nil
+}
+
+// runUserCommand runs the user command that msg invokes, if any. It returns false if msg doesn't
+// invoke a user command.
+func (c *Chat) runUserCommand(msg message) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	isCommand, err := c.runCommand(userCommands, msg, msg.content.text)
+	if err != nil {
+		return isCommand, fmt.Errorf("failed to run user command: %w", err)
+	}
+
+	return isCommand, nil
+}
+
 func (c *Chat) isAllowed() (bool, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -159,16 +231,26 @@ func (c *Chat) markAsRead(msg *events.Message) error {
 	)
 }
 
-func (c *Chat) storeMessageReceived(ctx context.Context, msg *events.Message) error {
+func (c *Chat) storeMessageReceived(ctx context.Context, msg message) error {
 	err := c.client.execTx(ctx, sql.TxOptions{
 		Isolation: sql.LevelReadCommitted,
 		ReadOnly:  false,
 	}, func(tx data.Tx) error {
-		err := c.client.store.CreateMessage(ctx, tx, data.Message{
+		chat, err := c.client.store.Chat(ctx, tx, c.id)
+		if err != nil {
+			return fmt.Errorf("failed to get chat from data store: %w", err)
+		}
+
+		err = c.client.store.CreateMessage(ctx, tx, data.Message{
 			ID:           msg.Info.ID,
 			ChatID:       msg.Info.Chat.User,
 			SenderID:     msg.Info.Sender.User,
-			Conversation: msg.Message.GetConversation(),
+			SenderName:   msg.Info.PushName,
+			Conversation: msg.content.text,
+			QuotedID:     msg.content.quotedID,
+			QuotedText:   msg.content.quotedText,
+			Transcribed:  msg.content.transcribed,
+			Segment:      chat.Segment,
 			Timestamp:    msg.Info.Timestamp,
 			CreatedAt:    time.Now(),
 		})
@@ -176,6 +258,44 @@ func (c *Chat) storeMessageReceived(ctx context.Context, msg *events.Message) er
 			return fmt.Errorf("failed to create message in data store: %w", err)
 		}
 
+		if msg.content.image != nil {
+			err = c.client.store.CreateMedia(ctx, tx, data.Media{
+				MessageID: msg.Info.ID,
+				ChatID:    c.id,
+				MIMEType:  msg.content.image.MIMEType,
+				Data:      msg.content.image.Data,
+				CreatedAt: time.Now(),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create media in data store: %w", err)
+			}
+		}
+
+		if msg.content.documentChunks != nil {
+			err = c.client.store.CreateDocument(ctx, tx, data.Document{
+				MessageID: msg.Info.ID,
+				ChatID:    c.id,
+				Segment:   chat.Segment,
+				FileName:  msg.content.document.GetFileName(),
+				MIMEType:  msg.content.document.GetMimetype(),
+				CreatedAt: time.Now(),
+			})
+			if err != nil {
+				return fmt.Errorf("failed to create document in data store: %w", err)
+			}
+
+			for i, content := range msg.content.documentChunks {
+				err = c.client.store.CreateDocumentChunk(ctx, tx, data.DocumentChunk{
+					MessageID: msg.Info.ID,
+					Index:     i,
+					Content:   content,
+				})
+				if err != nil {
+					return fmt.Errorf("failed to create document chunk in data store: %w", err)
+				}
+			}
+		}
+
 		return nil
 	})
 	if err != nil {
@@ -185,37 +305,33 @@ func (c *Chat) storeMessageReceived(ctx context.Context, msg *events.Message) er
 	return nil
 }
 
-func (c *Chat) respond() error {
+// respond sends a response to the chat, where trigger is the message being responded.
+func (c *Chat) respond(trigger *events.Message) error {
 	c.logger.Info("responding chat", zap.String("chat_id", c.id))
 
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	// Responses that call tools take several completions.
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
 	defer cancel()
 
-	var messages []data.Message
-	err := c.client.execTx(ctx, sql.TxOptions{
-		Isolation: sql.LevelReadCommitted,
-		ReadOnly:  true,
-	}, func(tx data.Tx) error {
-		var err error
-
-		messages, err = c.client.store.Messages(ctx, tx, c.id)
-		if err != nil {
-			return fmt.Errorf("failed to get messages from data store: %w", err)
-		}
-
-		return nil
-	})
+	h, err := c.loadHistory(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to execute data store transaction: %w", err)
+		return fmt.Errorf("failed to get chat history: %w", err)
+	}
+	messages := h.messages
+	settings, err := c.client.chatSettings(h.chat)
+	if err != nil {
+		return fmt.Errorf("failed to get chat settings: %w", err)
 	}
 
 	if len(messages) == 0 {
 		return errors.New("chat has no messages")
 	}
 
-	jid := types.NewJID(c.id, types.DefaultUserServer)
-
-	err = c.client.whatsmeowClient.SendChatPresence(jid, types.ChatPresenceComposing, "")
+	presenceMedia := types.ChatPresenceMediaText
+	if c.client.repliesWithVoice(h.chat.VoiceReplies) {
+		presenceMedia = types.ChatPresenceMediaAudio
+	}
+	err = c.client.whatsmeowClient.SendChatPresence(c.jid, types.ChatPresenceComposing, presenceMedia)
 	if err != nil {
 		return fmt.Errorf("failed to send chat composing presence: %w", err)
 	}
@@ -235,81 +351,305 @@ func (c *Chat) respond() error {
 		)
 	}
 
-	completionMessages := make([]gpt.ChatCompletionMessage, 0, len(messages)+1)
+	var quoted *events.Message
+	var quotedID, quotedText string
+	if c.client.quoteMode.quotes(trigger) {
+		quoted = trigger
+		quotedID = trigger.Info.ID
+		quotedText = parseMessageContent(trigger.Message).text
+	}
 
-	// TODO: Maybe move this initial system message out of the function. Maybe use (Go) text templates.
-	completionMessages = append(completionMessages, gpt.ChatCompletionMessage{
-		Role: "system",
-		Content: "The following is a conversation with an AI called Chatbot, the smartest of all beings." +
-			" The assistant is helpful, creative, clever, and very friendly.",
-	})
+	var responseText string
+	var stream *responseStream
+	if _, ok := h.media[trigger.Info.ID]; ok && !c.client.supportsVision(settings.model) {
+		c.logger.Info("responding image with fallback text because model doesn't support vision")
+		responseText = imageFallbackResponse
+	} else {
+		completionMessages, err := c.completionMessages(trigger, h, settings)
+		if err != nil {
+			return fmt.Errorf("failed to build completion messages: %w", err)
+		}
 
-	for _, msg := range messages {
-		var role string
-		if msg.SenderID == c.client.whatsmeowClient.Store.ID.User {
-			role = "assistant"
-		} else {
-			role = "user" // TODO: Support multiple users in order to fix group chats, if the completion API allows it.
+		completionRequest := CompletionRequest{
+			Model:       settings.model,
+			Messages:    completionMessages,
+			MaxTokens:   settings.maxTokens,
+			Temperature: settings.temperature,
+			Stop:        c.client.stop,
 		}
 
-		completionMessages = append(completionMessages, gpt.ChatCompletionMessage{
-			Role:    role,
-			Content: msg.Conversation,
-		})
-	}
+		toolInput := ToolInput{
+			ChatID:   c.id,
+			ChatJID:  c.jid.String(),
+			SenderID: trigger.Info.Sender.User,
+			Location: settings.location,
+		}
 
-	completionResponse, err := c.client.completion(ctx, completionMessages)
-	if err != nil {
-		return fmt.Errorf("failed to get completion response: %w", err)
-	}
-	if len(completionResponse.Choices) == 0 {
-		return fmt.Errorf("received empty slice of completion choices")
-	}
+		var onText func(text string) error
+		if c.client.streamsResponses(h.chat) {
+			stream = c.newResponseStream(ctx, quoted, timer.C)
+			onText = stream.update
+		}
 
-	conversationResponse := completionResponse.Choices[0].Message
-	if conversationResponse.Role != "assistant" {
-		c.logger.Warn(
-			"received completion response with unexpected role",
-			zap.String("role", conversationResponse.Role),
+		completionResponse, err := c.completeWithTools(
+			ctx,
+			completionRequest,
+			c.client.chatTools(h.chat),
+			toolInput,
+			trigger.Info.ID,
+			onText,
 		)
-		return nil
-	}
-	conversationResponse.Content = strings.TrimSpace(conversationResponse.Content)
+		if err != nil {
+			if stream != nil {
+				failErr := stream.fail()
+				if failErr != nil {
+					c.logger.Error("failed to mark streamed message as failed", zap.Error(failErr))
+				}
+			}
+			return fmt.Errorf("failed to get completion response: %w", err)
+		}
 
-	response := &waProto.Message{
-		Conversation: proto.String(conversationResponse.Content),
+		conversationResponse := completionResponse.Message
+		if conversationResponse.Role != RoleAssistant {
+			c.logger.Warn(
+				"received completion response with unexpected role",
+				zap.String("role", string(conversationResponse.Role)),
+			)
+			return nil
+		}
+		responseText = strings.TrimSpace(conversationResponse.Content)
 	}
 
-	// Make sure there is a delay between receiving a message and sending a response,
-	// to avoid being tagged as a bot and getting banned.
-	<-timer.C
+	// The response is sent as one or more parts, each stored as a message of its own.
+	var parts []string
+	var reports []whatsmeow.SendResponse
+	var sendErr error
+	// The Markdown is converted to the formatting of WhatsApp only for the text that is sent and
+	// stored, while the synthesizer gets the text of the model as it is.
+	formattedText := formatMarkdown(responseText)
+	if stream != nil && stream.sent() {
+		// The streamed message is stored once, with the whole text of the response.
+		report, err := stream.finish(formattedText)
+		if err != nil {
+			return fmt.Errorf("failed to finish streamed message: %w", err)
+		}
+		parts = []string{formattedText}
+		reports = []whatsmeow.SendResponse{report}
+	} else {
+		// The text of voice replies is stored all the same, so the history stays textual.
+		var voiceResponse *waProto.Message
+		if c.client.repliesWithVoice(h.chat.VoiceReplies) {
+			voiceResponse, err = c.voiceMessage(ctx, responseText, quoted)
+			if err != nil {
+				c.logger.Error("failed to synthesize voice reply, replying with text", zap.Error(err))
+			}
+		}
+
+		parts = []string{formattedText}
+		if voiceResponse == nil {
+			parts = splitResponse(formattedText, c.client.maxMessageLength)
+		}
 
-	report, err := c.client.whatsmeowClient.SendMessage(ctx, jid, "", response)
-	if err != nil {
-		return fmt.Errorf("failed to send message: %w", err)
+		reports, sendErr = c.sendParts(ctx, parts, quoted, voiceResponse, timer.C)
+		parts = parts[:len(reports)]
 	}
-	c.logger.Debug("sent message", zap.String("sent_message_id", report.ID))
 
 	err = c.client.execTx(ctx, sql.TxOptions{
 		Isolation: sql.LevelReadCommitted,
 		ReadOnly:  false,
 	}, func(tx data.Tx) error {
-		err := c.client.store.CreateMessage(ctx, tx, data.Message{
-			ID:           report.ID,
-			ChatID:       c.id,
-			SenderID:     c.client.whatsmeowClient.Store.ID.User,
-			Conversation: conversationResponse.Content,
-			Timestamp:    report.Timestamp,
-			CreatedAt:    time.Now(),
-		})
-		if err != nil {
-			return fmt.Errorf("failed to create message from chatbot in data store: %w", err)
+		for i, part := range parts {
+			message := data.Message{
+				ID:           reports[i].ID,
+				ChatID:       c.id,
+				SenderID:     c.client.botID(),
+				SenderName:   c.client.botName,
+				Conversation: part,
+				CompletionID: reports[0].ID,
+				Segment:      h.chat.Segment,
+				Timestamp:    reports[i].Timestamp,
+				CreatedAt:    time.Now(),
+			}
+			if i == 0 {
+				message.QuotedID = quotedID
+				message.QuotedText = quotedText
+			}
+
+			err := c.client.store.CreateMessage(ctx, tx, message)
+			if err != nil {
+				return fmt.Errorf("failed to create message from chatbot in data store: %w", err)
+			}
 		}
 		return nil
 	})
 	if err != nil {
 		return fmt.Errorf("failed to execute data store transaction: %w", err)
 	}
+	if sendErr != nil {
+		return fmt.Errorf("failed to send response: %w", sendErr)
+	}
 
 	return nil
 }
+
+// sendParts sends the parts of a response in order, the first one quoting the given message if it's
+// not nil, with delays and composing presences in between as if they were typed. If voiceResponse is
+// not nil, it's sent instead of the single part. It returns the reports of the parts that were sent
+// before any error.
+func (c *Chat) sendParts(
+	ctx context.Context,
+	parts []string,
+	quoted *events.Message,
+	voiceResponse *waProto.Message,
+	delay <-chan time.Time,
+) ([]whatsmeow.SendResponse, error) {
+	reports := make([]whatsmeow.SendResponse, 0, len(parts))
+	for i, part := range parts {
+		response := voiceResponse
+		if response == nil {
+			response = textMessage(part, quoted)
+		}
+		quoted = nil
+
+		if i == 0 {
+			// Make sure there is a delay between receiving a message and sending a response,
+			// to avoid being tagged as a bot and getting banned.
+			<-delay
+		} else {
+			err := c.client.whatsmeowClient.SendChatPresence(c.jid, types.ChatPresenceComposing, types.ChatPresenceMediaText)
+			if err != nil {
+				return reports, fmt.Errorf("failed to send chat composing presence: %w", err)
+			}
+
+			err = sleep(ctx, partDelay(part))
+			if err != nil {
+				return reports, err
+			}
+		}
+
+		report, err := c.client.whatsmeowClient.SendMessage(ctx, c.jid, "", response)
+		if err != nil {
+			return reports, fmt.Errorf("failed to send message: %w", err)
+		}
+		c.logger.Debug("sent message", zap.String("sent_message_id", report.ID))
+
+		reports = append(reports, report)
+	}
+
+	return reports, nil
+}
+
+// completionMessages returns the prompt of the response to trigger: the system message, the summary
+// and the most recent messages of the chat that fit in the context window.
+func (c *Chat) completionMessages(trigger *events.Message, h history, settings chatSettings) ([]CompletionMessage, error) {
+	messages := h.messages
+	vision := c.client.supportsVision(settings.model)
+
+	promptData := PromptData{
+		ChatID:         c.id,
+		SenderPushName: trigger.Info.PushName,
+		BotName:        c.client.botName,
+		IsGroup:        trigger.Info.IsGroup,
+		MessageCount:   h.messageCount,
+	}
+	if trigger.Info.IsGroup {
+		groupInfo, err := c.client.whatsmeowClient.GetGroupInfo(trigger.Info.Chat)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get group info: %w", err)
+		}
+		promptData.GroupSubject = groupInfo.Name
+	}
+
+	systemMessage, err := settings.systemMessage(promptData)
+	if err != nil {
+		return nil, fmt.Errorf("failed to render system message: %w", err)
+	}
+
+	history := make([]CompletionMessage, 0, len(messages))
+	for i, msg := range messages {
+		if msg.SenderID == c.client.botID() {
+			// The media of the chatbot are the images that it generated, with their prompt as text.
+			if _, ok := h.media[msg.ID]; ok {
+				msg.Conversation = generatedImagePrefix + msg.Conversation
+			}
+
+			// The parts of a response that was split are joined back into one message.
+			if i > 0 && msg.CompletionID != "" && messages[i-1].CompletionID == msg.CompletionID && len(history) > 0 {
+				history[len(history)-1].Content += "\n\n" + msg.Conversation
+				continue
+			}
+
+			history = append(history, CompletionMessage{
+				Role:    RoleAssistant,
+				Content: msg.Conversation,
+			})
+			continue
+		}
+
+		if msg.Transcribed {
+			msg.Conversation = "(Voice message) " + msg.Conversation
+		}
+
+		var images []CompletionImage
+		if media, ok := h.media[msg.ID]; ok {
+			if vision {
+				images = append(images, CompletionImage{
+					MIMEType: media.MIMEType,
+					Data:     media.Data,
+				})
+			} else {
+				msg.Conversation = strings.TrimSpace("(Image) " + msg.Conversation)
+			}
+		}
+		if document, ok := h.documents[msg.ID]; ok {
+			msg.Conversation = strings.TrimSpace(fmt.Sprintf("(Document: %s) %s", document.FileName, msg.Conversation))
+		}
+
+		content := msg.Conversation
+		if trigger.Info.IsGroup {
+			content = c.client.speakerContent(msg)
+		}
+		content = quoteContent(content, msg, messages[:i])
+
+		history = append(history, CompletionMessage{
+			Role:    RoleUser,
+			Content: content,
+			Images:  images,
+		})
+	}
+
+	pinned := []CompletionMessage{systemMessage}
+	if h.summary.Content != "" {
+		pinned = append(pinned, CompletionMessage{
+			Role:    RoleSystem,
+			Content: "Summary of the earlier conversation:\n" + h.summary.Content,
+		})
+	}
+
+	var query string
+	for _, msg := range messages {
+		if msg.ID == trigger.Info.ID {
+			query = msg.Conversation
+		}
+	}
+	if excerpts, ok := documentExcerpts(h, query, trigger.Info.ID); ok {
+		pinned = append(pinned, excerpts)
+	}
+
+	completionMessages, dropped := c.client.contextWindow.fit(
+		pinned,
+		history,
+		settings.maxTokens,
+	)
+	if dropped > 0 {
+		c.logger.Info(
+			"dropped messages that don't fit in the context window",
+			zap.Int("dropped_messages", dropped),
+			zap.Int("kept_messages", len(history)-dropped),
+		)
+		metricContextTruncations.Add(1)
+		metricContextDroppedMessages.Add(int64(dropped))
+	}
+
+	return completionMessages, nil
+}
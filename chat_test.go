@@ -0,0 +1,443 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/store"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/happybydefault/chatbot/data"
+	"github.com/happybydefault/chatbot/memory"
+)
+
+const (
+	testBotID  = "15550000000"
+	testUserID = "15551234567"
+)
+
+// fakeWhatsApp is a whatsappClient that records the messages sent to it instead of sending them.
+type fakeWhatsApp struct {
+	downloads map[string][]byte // Data of the media to download, by direct path.
+
+	mu   sync.Mutex
+	sent []*waProto.Message
+}
+
+func (f *fakeWhatsApp) Connect() error                                        { return nil }
+func (f *fakeWhatsApp) Disconnect()                                           {}
+func (f *fakeWhatsApp) IsLoggedIn() bool                                      { return true }
+func (f *fakeWhatsApp) AddEventHandler(handler whatsmeow.EventHandler) uint32 { return 0 }
+func (f *fakeWhatsApp) RemoveEventHandlers()                                  {}
+func (f *fakeWhatsApp) SendPresence(state types.Presence) error               { return nil }
+
+func (f *fakeWhatsApp) SendChatPresence(jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error {
+	return nil
+}
+
+func (f *fakeWhatsApp) MarkRead(ids []types.MessageID, timestamp time.Time, chat, sender types.JID) error {
+	return nil
+}
+
+func (f *fakeWhatsApp) GetGroupInfo(jid types.JID) (*types.GroupInfo, error) {
+	return &types.GroupInfo{GroupName: types.GroupName{Name: "Group"}}, nil
+}
+
+func (f *fakeWhatsApp) SendMessage(ctx context.Context, to types.JID, id types.MessageID, message *waProto.Message) (whatsmeow.SendResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sent = append(f.sent, message)
+
+	return whatsmeow.SendResponse{
+		ID:        fmt.Sprintf("sent-%d", len(f.sent)),
+		Timestamp: time.Now(),
+	}, nil
+}
+
+func (f *fakeWhatsApp) BuildEdit(chat types.JID, id types.MessageID, newContent *waProto.Message) *waProto.Message {
+	return newContent
+}
+
+func (f *fakeWhatsApp) Download(msg whatsmeow.DownloadableMessage) ([]byte, error) {
+	mediaData, ok := f.downloads[msg.GetDirectPath()]
+	if !ok {
+		return nil, fmt.Errorf("unknown media %q", msg.GetDirectPath())
+	}
+
+	return mediaData, nil
+}
+
+func (f *fakeWhatsApp) Upload(ctx context.Context, plaintext []byte, appInfo whatsmeow.MediaType) (whatsmeow.UploadResponse, error) {
+	return whatsmeow.UploadResponse{DirectPath: "/uploaded"}, nil
+}
+
+// sentTexts returns the text of the messages sent so far.
+func (f *fakeWhatsApp) sentTexts() []string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	texts := make([]string, 0, len(f.sent))
+	for _, message := range f.sent {
+		texts = append(texts, parseMessageContent(message).text)
+	}
+
+	return texts
+}
+
+// fakeCompleter is a Completer that responds every request with the same content.
+type fakeCompleter struct {
+	content string
+
+	mu       sync.Mutex
+	requests []CompletionRequest
+}
+
+func (f *fakeCompleter) Complete(ctx context.Context, request CompletionRequest) (CompletionResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, request)
+
+	return CompletionResponse{
+		Message: CompletionMessage{
+			Role:    RoleAssistant,
+			Content: f.content,
+		},
+	}, nil
+}
+
+// newTestChat returns a Chat with a private user whose client has a memory store, where the chat is
+// allowed, and fakes of WhatsApp and the completer.
+func newTestChat(t *testing.T, whatsApp *fakeWhatsApp, completer Completer) (*Chat, *memory.Store) {
+	t.Helper()
+
+	systemPrompt, err := ParsePromptTemplate("default", DefaultSystemPrompt)
+	if err != nil {
+		t.Fatalf("failed to parse system prompt: %s", err)
+	}
+
+	dataStore := memory.NewStore(data.Chat{ID: testUserID})
+	client := &Client{
+		logger:          zap.NewNop(),
+		store:           dataStore,
+		whatsmeowClient: whatsApp,
+		device:          &store.Device{ID: &types.JID{User: testBotID, Server: types.DefaultUserServer}},
+		completer:       completer,
+		systemPrompt:    systemPrompt,
+		model:           "model",
+		maxTokens:       100,
+		location:        time.UTC,
+		botName:         "Chatbot",
+		contextWindow:   contextWindow{counter: estimateTokenCounter{}},
+		tools:           make(map[string]Tool),
+		stopChan:        make(chan struct{}),
+		chats:           make(map[string]*Chat),
+	}
+
+	return client.newChat(types.NewJID(testUserID, types.DefaultUserServer)), dataStore
+}
+
+// newTestMessage returns a message of the user of the chat of newTestChat.
+func newTestMessage(id string, msg *waProto.Message) message {
+	jid := types.NewJID(testUserID, types.DefaultUserServer)
+
+	return message{
+		Message: &events.Message{
+			Info: types.MessageInfo{
+				MessageSource: types.MessageSource{
+					Chat:   jid,
+					Sender: jid,
+				},
+				ID:        id,
+				PushName:  "User",
+				Timestamp: time.Now(),
+			},
+			Message: msg,
+		},
+		content:     parseMessageContent(msg),
+		clientState: StateSynced,
+	}
+}
+
+// storedMessages returns the messages of the chat of newTestChat in the data store.
+func storedMessages(t *testing.T, dataStore data.Store) []data.Message {
+	t.Helper()
+
+	ctx := context.Background()
+	tx, err := dataStore.BeginTx(ctx, sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %s", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	messages, err := dataStore.Messages(ctx, tx, testUserID)
+	if err != nil {
+		t.Fatalf("failed to get messages: %s", err)
+	}
+
+	return messages
+}
+
+// createMessages creates a message in the chat of newTestChat for every sender, with IDs "1", "2" and
+// so on, a minute apart.
+func createMessages(t *testing.T, chat *Chat, senderIDs ...string) {
+	t.Helper()
+
+	ctx := context.Background()
+	start := time.Now().Add(-time.Hour)
+	err := chat.client.execTx(ctx, sql.TxOptions{}, func(tx data.Tx) error {
+		for i, senderID := range senderIDs {
+			err := chat.client.store.CreateMessage(ctx, tx, data.Message{
+				ID:           strconv.Itoa(i + 1),
+				ChatID:       chat.id,
+				SenderID:     senderID,
+				Conversation: "Message " + strconv.Itoa(i+1),
+				Timestamp:    start.Add(time.Duration(i) * time.Minute),
+				CreatedAt:    time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to create messages: %s", err)
+	}
+}
+
+func TestChatRespond(t *testing.T) {
+	ctx := context.Background()
+	whatsApp := &fakeWhatsApp{}
+	completer := &fakeCompleter{content: "  Hello, User!\n"}
+	chat, dataStore := newTestChat(t, whatsApp, completer)
+
+	msg := newTestMessage("1", &waProto.Message{Conversation: proto.String("Hi there")})
+	err := chat.storeMessageReceived(ctx, msg)
+	if err != nil {
+		t.Fatalf("failed to store message: %s", err)
+	}
+
+	err = chat.respond(msg.Message)
+	if err != nil {
+		t.Fatalf("failed to respond: %s", err)
+	}
+
+	if len(completer.requests) != 1 {
+		t.Fatalf("got %d completion requests, want 1", len(completer.requests))
+	}
+	request := completer.requests[0]
+	last := request.Messages[len(request.Messages)-1]
+	if request.Model != "model" || request.MaxTokens != 100 ||
+		request.Messages[0].Role != RoleSystem || last.Role != RoleUser || last.Content != "Hi there" {
+		t.Errorf("got completion request %+v, want the system prompt and the message", request)
+	}
+
+	sent := whatsApp.sentTexts()
+	if len(sent) != 1 || sent[0] != "Hello, User!" {
+		t.Errorf("sent %q, want the trimmed completion", sent)
+	}
+
+	messages := storedMessages(t, dataStore)
+	if len(messages) != 2 {
+		t.Fatalf("got %d stored messages, want 2", len(messages))
+	}
+	if messages[0].ID != "1" || messages[0].SenderID != testUserID || messages[0].Conversation != "Hi there" {
+		t.Errorf("got stored message %+v, want the one of the user", messages[0])
+	}
+	response := messages[1]
+	if response.ID != "sent-1" || response.SenderID != testBotID || response.SenderName != "Chatbot" ||
+		response.Conversation != "Hello, User!" || response.CompletionID != "sent-1" {
+		t.Errorf("got stored response %+v, want the sent one", response)
+	}
+}
+
+func TestChatHandleImage(t *testing.T) {
+	tests := []struct {
+		name          string
+		vision        bool
+		imageData     []byte // Nil if the image fails to download.
+		maxResolution int
+		wantImages    int
+		wantSent      string
+	}{
+		{
+			name:       "vision model",
+			vision:     true,
+			imageData:  []byte("image data"),
+			wantImages: 1,
+			wantSent:   "A cat.",
+		},
+		{
+			name:      "model without vision",
+			imageData: []byte("image data"),
+			wantSent:  imageFallbackResponse,
+		},
+		{
+			// The caption is responded without the image.
+			name:     "failed download",
+			vision:   true,
+			wantSent: "A cat.",
+		},
+		{
+			name:          "image that can't be decoded",
+			vision:        true,
+			imageData:     []byte("truncated image"),
+			maxResolution: 512,
+			wantSent:      "A cat.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whatsApp := &fakeWhatsApp{downloads: map[string][]byte{}}
+			if tt.imageData != nil {
+				whatsApp.downloads["/image"] = tt.imageData
+			}
+			completer := &fakeCompleter{content: "A cat."}
+			chat, dataStore := newTestChat(t, whatsApp, completer)
+			chat.client.maxImageResolution = tt.maxResolution
+			if tt.vision {
+				chat.client.visionModels = map[string]struct{}{"model": {}}
+			}
+
+			msg := newTestMessage("1", &waProto.Message{
+				ImageMessage: &waProto.ImageMessage{
+					Caption:    proto.String("What is this?"),
+					Mimetype:   proto.String("image/jpeg"),
+					DirectPath: proto.String("/image"),
+				},
+			})
+			err := chat.handleMessage(msg)
+			if err != nil {
+				t.Fatalf("failed to handle message: %s", err)
+			}
+
+			var images []CompletionImage
+			if len(completer.requests) > 0 {
+				request := completer.requests[0]
+				last := request.Messages[len(request.Messages)-1]
+				if last.Content != "What is this?" {
+					t.Errorf("got last completion message %+v, want the caption", last)
+				}
+				images = last.Images
+			}
+			if len(images) != tt.wantImages {
+				t.Fatalf("got %d images in completion request, want %d", len(images), tt.wantImages)
+			}
+			if tt.wantImages > 0 && (images[0].MIMEType != "image/jpeg" || string(images[0].Data) != string(tt.imageData)) {
+				t.Errorf("got image %+v, want the downloaded one", images[0])
+			}
+
+			sent := whatsApp.sentTexts()
+			if len(sent) != 1 || sent[0] != tt.wantSent {
+				t.Errorf("sent %q, want %q", sent, tt.wantSent)
+			}
+
+			messages := storedMessages(t, dataStore)
+			if len(messages) != 2 || messages[0].Conversation != "What is this?" {
+				t.Errorf("got stored messages %+v, want the caption and the response", messages)
+			}
+		})
+	}
+}
+
+// fakeSynthesizer is a Synthesizer that records the requests to it and returns one second of silent
+// Ogg audio, or fails with err if it's not nil.
+type fakeSynthesizer struct {
+	err error
+
+	requests []SpeechRequest
+}
+
+func (f *fakeSynthesizer) Synthesize(ctx context.Context, request SpeechRequest) (SpeechResponse, error) {
+	f.requests = append(f.requests, request)
+	if f.err != nil {
+		return SpeechResponse{}, f.err
+	}
+
+	// The header of an Ogg page whose granule position is 48000 samples.
+	audio := []byte("OggS\x00\x04\x80\xbb\x00\x00\x00\x00\x00\x00")
+
+	return SpeechResponse{Audio: audio}, nil
+}
+
+func TestChatRespondFormatting(t *testing.T) {
+	const (
+		markdown  = "# Answer\n\nThis is **important**."
+		formatted = "*Answer*\n\nThis is *important*."
+	)
+
+	tests := []struct {
+		name  string
+		voice bool
+	}{
+		{name: "text"},
+		{name: "voice", voice: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			whatsApp := &fakeWhatsApp{}
+			synthesizer := &fakeSynthesizer{}
+			chat, dataStore := newTestChat(t, whatsApp, &fakeCompleter{content: markdown})
+			chat.client.synthesizer = synthesizer
+
+			err := chat.client.execTx(ctx, sql.TxOptions{}, func(tx data.Tx) error {
+				return dataStore.UpdateChat(ctx, tx, data.Chat{ID: testUserID, VoiceReplies: tt.voice})
+			})
+			if err != nil {
+				t.Fatalf("failed to update chat: %s", err)
+			}
+
+			msg := newTestMessage("1", &waProto.Message{Conversation: proto.String("Hi")})
+			err = chat.storeMessageReceived(ctx, msg)
+			if err != nil {
+				t.Fatalf("failed to store message: %s", err)
+			}
+
+			err = chat.respond(msg.Message)
+			if err != nil {
+				t.Fatalf("failed to respond: %s", err)
+			}
+
+			if len(whatsApp.sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(whatsApp.sent))
+			}
+			if tt.voice {
+				if len(synthesizer.requests) != 1 || synthesizer.requests[0].Text != markdown {
+					t.Errorf("got speech requests %+v, want the unformatted response", synthesizer.requests)
+				}
+				if whatsApp.sent[0].GetAudioMessage() == nil {
+					t.Errorf("sent %v, want a voice message", whatsApp.sent[0])
+				}
+			} else {
+				if len(synthesizer.requests) != 0 {
+					t.Errorf("got %d speech requests, want none", len(synthesizer.requests))
+				}
+				if sent := whatsApp.sentTexts(); sent[0] != formatted {
+					t.Errorf("sent %q, want the formatted response", sent[0])
+				}
+			}
+
+			messages := storedMessages(t, dataStore)
+			if len(messages) != 2 || messages[1].Conversation != formatted {
+				t.Errorf("got stored messages %+v, want the formatted response", messages)
+			}
+		})
+	}
+}
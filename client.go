@@ -1,11 +1,16 @@
 package chatbot
 
 import (
+	"context"
 	"fmt"
 	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
-	gpt "github.com/sashabaranov/go-gpt3"
 	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/store"
 	"go.mau.fi/whatsmeow/store/sqlstore"
 	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
@@ -14,12 +19,71 @@ import (
 	"github.com/happybydefault/chatbot/data"
 )
 
+// whatsappClient is the part of whatsmeow.Client that the chatbot uses, which tests replace with a
+// fake.
+type whatsappClient interface {
+	Connect() error
+	Disconnect()
+	IsLoggedIn() bool
+	AddEventHandler(handler whatsmeow.EventHandler) uint32
+	RemoveEventHandlers()
+
+	SendPresence(state types.Presence) error
+	SendChatPresence(jid types.JID, state types.ChatPresence, media types.ChatPresenceMedia) error
+	MarkRead(ids []types.MessageID, timestamp time.Time, chat, sender types.JID) error
+	GetGroupInfo(jid types.JID) (*types.GroupInfo, error)
+
+	SendMessage(ctx context.Context, to types.JID, id types.MessageID, message *waProto.Message) (whatsmeow.SendResponse, error)
+	BuildEdit(chat types.JID, id types.MessageID, newContent *waProto.Message) *waProto.Message
+	Download(msg whatsmeow.DownloadableMessage) ([]byte, error)
+	Upload(ctx context.Context, plaintext []byte, appInfo whatsmeow.MediaType) (whatsmeow.UploadResponse, error)
+}
+
 type Client struct {
 	logger *zap.Logger
 	store  data.Store
 
-	whatsmeowClient *whatsmeow.Client
-	gpt3Client      *gpt.Client
+	whatsmeowClient whatsappClient
+	device          *store.Device // Of the WhatsApp account of the chatbot, the Store of whatsmeowClient.
+	completer       Completer
+
+	transcriber        Transcriber
+	transcriptionModel string
+
+	imageGenerator ImageGenerator
+	imageModel     string
+
+	synthesizer Synthesizer
+	speechModel string
+	voice       string
+
+	tools map[string]Tool
+
+	visionModels       map[string]struct{}
+	maxImageSize       int
+	maxImageResolution int
+	maxDocumentSize    int
+
+	systemPrompt *template.Template
+	model        string
+	maxTokens    int
+	temperature  *float32
+	stop         []string
+	location     *time.Location
+	botName      string
+	quoteMode    QuoteMode
+
+	maxMessageLength int
+
+	streamResponses bool
+	streamInterval  time.Duration
+
+	contextWindow      contextWindow
+	summarizeThreshold int
+
+	adminIDs      map[string]struct{}
+	commandPrefix string
+	paused        atomic.Bool
 
 	state State
 
@@ -36,9 +100,14 @@ func NewClient(cfg Config) (*Client, error) {
 		zap.IncreaseLevel(zap.InfoLevel),
 	)
 
+	whatsmeowDialect := cfg.WhatsmeowDialect
+	if whatsmeowDialect == "" {
+		whatsmeowDialect = "postgres"
+	}
+
 	whatsmeowDB := sqlstore.NewWithDB(
 		cfg.WhatsmeowDB,
-		"postgres",
+		whatsmeowDialect,
 		newWALogger(whatsmeowLogger.Named("db")),
 	)
 	err := whatsmeowDB.Upgrade()
@@ -55,16 +124,108 @@ func NewClient(cfg Config) (*Client, error) {
 		newWALogger(whatsmeowLogger.Named("client")),
 	)
 
-	gpt3Client := gpt.NewClient(cfg.OpenAIAPIKey)
+	tokenCounter := cfg.TokenCounter
+	if tokenCounter == nil {
+		tokenCounter = estimateTokenCounter{}
+	}
+
+	systemPromptText := cfg.SystemPrompt
+	if systemPromptText == "" {
+		systemPromptText = DefaultSystemPrompt
+	}
+	systemPrompt, err := ParsePromptTemplate("default", systemPromptText)
+	if err != nil {
+		return nil, fmt.Errorf("invalid system prompt: %w", err)
+	}
+
+	location := cfg.Location
+	if location == nil {
+		location = time.Local
+	}
+
+	botName := cfg.BotName
+	if botName == "" {
+		botName = "Chatbot"
+	}
+
+	streamInterval := cfg.StreamInterval
+	if streamInterval == 0 {
+		streamInterval = time.Second
+	}
+
+	visionModels := make(map[string]struct{}, len(cfg.VisionModels))
+	for _, model := range cfg.VisionModels {
+		visionModels[model] = struct{}{}
+	}
+
+	adminIDs := make(map[string]struct{}, len(cfg.AdminIDs))
+	for _, id := range cfg.AdminIDs {
+		adminIDs[id] = struct{}{}
+	}
+
+	commandPrefix := cfg.CommandPrefix
+	if commandPrefix == "" {
+		commandPrefix = "/"
+	}
+
+	client := &Client{
+		logger:             cfg.Logger,
+		store:              cfg.Store,
+		whatsmeowClient:    whatsmeowClient,
+		device:             device,
+		completer:          cfg.Completer,
+		transcriber:        cfg.Transcriber,
+		transcriptionModel: cfg.TranscriptionModel,
+		imageGenerator:     cfg.ImageGenerator,
+		imageModel:         cfg.ImageModel,
+		synthesizer:        cfg.Synthesizer,
+		speechModel:        cfg.SpeechModel,
+		voice:              cfg.Voice,
+		visionModels:       visionModels,
+		maxImageSize:       cfg.MaxImageSize,
+		maxImageResolution: cfg.MaxImageResolution,
+		maxDocumentSize:    cfg.MaxDocumentSize,
+		systemPrompt:       systemPrompt,
+		model:              cfg.Model,
+		maxTokens:          cfg.MaxTokens,
+		temperature:        cfg.Temperature,
+		stop:               cfg.Stop,
+		location:           location,
+		botName:            botName,
+		quoteMode:          cfg.QuoteMode,
+		maxMessageLength:   cfg.MaxMessageLength,
+		streamResponses:    cfg.StreamResponses,
+		streamInterval:     streamInterval,
+		contextWindow: contextWindow{
+			size:    cfg.ContextWindowSize,
+			counter: tokenCounter,
+		},
+		summarizeThreshold: cfg.SummarizeThreshold,
+		adminIDs:           adminIDs,
+		commandPrefix:      commandPrefix,
+		stopChan:           make(chan struct{}),
+		chats:              make(map[string]*Chat),
+	}
 
-	return &Client{
-		logger:          cfg.Logger,
-		store:           cfg.Store,
-		whatsmeowClient: whatsmeowClient,
-		gpt3Client:      gpt3Client,
-		stopChan:        make(chan struct{}),
-		chats:           make(map[string]*Chat),
-	}, nil
+	client.tools, err = newToolRegistry(append(client.builtinTools(), cfg.Tools...))
+	if err != nil {
+		return nil, fmt.Errorf("invalid tools: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	err = client.validateChats(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to validate chats: %w", err)
+	}
+
+	return client, nil
+}
+
+// botID returns the WhatsApp ID (phone number) of the account of the chatbot.
+func (c *Client) botID() string {
+	return c.device.ID.User
 }
 
 func (c *Client) Start() error {
@@ -75,6 +236,9 @@ func (c *Client) Start() error {
 		return fmt.Errorf("failed to connect the whatsmeow client to WhatsApp: %w", err)
 	}
 
+	c.wg.Add(1)
+	go c.sendReminders()
+
 	<-c.stopChan
 
 	return nil
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// chats executes the "chats" command, which manages the chats the chatbot is allowed to respond in:
+//
+//	chatbot chats allow <chat ID>...
+//	chatbot chats deny <chat ID>...
+//	chatbot chats set <chat ID> <setting>=<value>...
+//	chatbot chats list
+//
+// The settings of "set" are model, temperature, max-tokens, language and timezone. An empty value
+// resets the setting to the global default.
+func chats(ctx context.Context, logger *zap.Logger, cfg config) error {
+	if len(cfg.args) == 0 {
+		return errors.New(`missing chats action: "allow", "deny", "set" or "list"`)
+	}
+	action, chatIDs := cfg.args[0], cfg.args[1:]
+
+	switch action {
+	case "allow", "deny":
+		if len(chatIDs) == 0 {
+			return fmt.Errorf("missing chat IDs to %s", action)
+		}
+	case "set":
+		if len(chatIDs) < 2 {
+			return errors.New("missing chat ID and settings to set")
+		}
+		// The settings are validated before opening the database.
+		err := setChatSettings(&data.Chat{}, chatIDs[1:])
+		if err != nil {
+			return err
+		}
+	case "list":
+	default:
+		return fmt.Errorf("unknown chats action %q", action)
+	}
+
+	db, err := openDatabase(ctx, logger, cfg.databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.close()
+
+	store := db.store
+
+	tx, err := store.BeginTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  action == "list",
+	})
+	if err != nil {
+		return fmt.Errorf("failed to begin data store transaction: %w", err)
+	}
+	defer func() {
+		err := tx.Rollback(ctx)
+		if err != nil && !errors.Is(err, data.ErrTxDone) {
+			logger.Error("failed to rollback data store transaction", zap.Error(err))
+		}
+	}()
+
+	switch action {
+	case "allow":
+		for _, chatID := range chatIDs {
+			err := store.CreateChat(ctx, tx, data.Chat{ID: chatID})
+			if errors.Is(err, data.ErrAlreadyExists) {
+				fmt.Printf("chat %s was already allowed\n", chatID)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to create chat %q in data store: %w", chatID, err)
+			}
+			fmt.Printf("allowed chat %s\n", chatID)
+		}
+	case "deny":
+		for _, chatID := range chatIDs {
+			err := store.DeleteChat(ctx, tx, chatID)
+			if errors.Is(err, data.ErrNotFound) {
+				fmt.Printf("chat %s was not allowed\n", chatID)
+				continue
+			}
+			if err != nil {
+				return fmt.Errorf("failed to delete chat %q from data store: %w", chatID, err)
+			}
+			fmt.Printf("denied chat %s\n", chatID)
+		}
+	case "set":
+		chatID := chatIDs[0]
+		chat, err := store.Chat(ctx, tx, chatID)
+		if errors.Is(err, data.ErrNotFound) {
+			return fmt.Errorf("chat %s is not allowed", chatID)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get chat %q from data store: %w", chatID, err)
+		}
+
+		err = setChatSettings(&chat, chatIDs[1:])
+		if err != nil {
+			return err
+		}
+
+		err = store.UpdateChat(ctx, tx, chat)
+		if err != nil {
+			return fmt.Errorf("failed to update chat %q in data store: %w", chatID, err)
+		}
+		printChats(os.Stdout, []data.Chat{chat})
+	case "list":
+		chats, err := store.Chats(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to get chats from data store: %w", err)
+		}
+		printChats(os.Stdout, chats)
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to commit data store transaction: %w", err)
+	}
+
+	return nil
+}
+
+// setChatSettings sets the settings of chat from arguments like "temperature=0.5". An empty value
+// resets the setting to the global default.
+func setChatSettings(chat *data.Chat, settings []string) error {
+	for _, setting := range settings {
+		key, value, ok := strings.Cut(setting, "=")
+		if !ok {
+			return fmt.Errorf("setting %q doesn't have the format <setting>=<value>", setting)
+		}
+		value = strings.TrimSpace(value)
+
+		switch key {
+		case "model":
+			chat.Model = value
+		case "temperature":
+			if value == "" {
+				chat.Temperature = nil
+				continue
+			}
+			temperature, err := strconv.ParseFloat(value, 32)
+			if err != nil || temperature < 0 || temperature > 2 {
+				return fmt.Errorf("temperature %q is not a number between 0 and 2", value)
+			}
+			t := float32(temperature)
+			chat.Temperature = &t
+		case "max-tokens":
+			if value == "" {
+				chat.MaxTokens = 0
+				continue
+			}
+			maxTokens, err := strconv.Atoi(value)
+			if err != nil || maxTokens <= 0 {
+				return fmt.Errorf("max tokens %q is not a positive integer", value)
+			}
+			chat.MaxTokens = maxTokens
+		case "language":
+			chat.Language = value
+		case "timezone":
+			if value != "" {
+				_, err := time.LoadLocation(value)
+				if err != nil {
+					return fmt.Errorf("invalid timezone: %w", err)
+				}
+			}
+			chat.Timezone = value
+		default:
+			return fmt.Errorf(
+				"unknown setting %q: must be model, temperature, max-tokens, language or timezone",
+				key,
+			)
+		}
+	}
+
+	return nil
+}
+
+// printChats writes chats to out as a table with a row per chat.
+func printChats(out io.Writer, chats []data.Chat) {
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	defer w.Flush()
+
+	fmt.Fprintln(w, "CHAT ID\tMODEL\tTEMPERATURE\tMAX TOKENS\tLANGUAGE\tTIMEZONE\tVOICE\tTOOLS\tSYSTEM PROMPT")
+	for _, chat := range chats {
+		temperature := ""
+		if chat.Temperature != nil {
+			temperature = strconv.FormatFloat(float64(*chat.Temperature), 'f', -1, 32)
+		}
+		maxTokens := ""
+		if chat.MaxTokens > 0 {
+			maxTokens = strconv.Itoa(chat.MaxTokens)
+		}
+		voice := "no"
+		if chat.VoiceReplies {
+			voice = "yes"
+		}
+		systemPrompt := "default"
+		if chat.SystemPrompt != "" {
+			systemPrompt = "custom"
+		}
+
+		fmt.Fprintf(
+			w,
+			"%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			chat.ID,
+			orDash(chat.Model),
+			orDash(temperature),
+			orDash(maxTokens),
+			orDash(chat.Language),
+			orDash(chat.Timezone),
+			voice,
+			orDash(strings.Join(chat.Tools, ",")),
+			systemPrompt,
+		)
+	}
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+
+	return s
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func TestSetChatSettings(t *testing.T) {
+	temperature := float32(0.5)
+
+	tests := []struct {
+		name     string
+		chat     data.Chat
+		settings []string
+		want     data.Chat
+		wantErr  bool
+	}{
+		{
+			name: "set",
+			chat: data.Chat{ID: "chat"},
+			settings: []string{
+				"model=gpt-4o",
+				"temperature=0.5",
+				"max-tokens=500",
+				"language=Spanish",
+				"timezone=Europe/Madrid",
+			},
+			want: data.Chat{
+				ID:          "chat",
+				Model:       "gpt-4o",
+				Temperature: &temperature,
+				MaxTokens:   500,
+				Language:    "Spanish",
+				Timezone:    "Europe/Madrid",
+			},
+		},
+		{
+			name: "reset",
+			chat: data.Chat{
+				ID:          "chat",
+				Model:       "gpt-4o",
+				Temperature: &temperature,
+				MaxTokens:   500,
+				Language:    "Spanish",
+				Timezone:    "Europe/Madrid",
+			},
+			settings: []string{"model=", "temperature=", "max-tokens=", "language=", "timezone="},
+			want:     data.Chat{ID: "chat"},
+		},
+		{
+			name:     "other settings are kept",
+			chat:     data.Chat{ID: "chat", Model: "gpt-4o", VoiceReplies: true, Tools: []string{"current_time"}},
+			settings: []string{"language=Catalan"},
+			want: data.Chat{
+				ID:           "chat",
+				Model:        "gpt-4o",
+				Language:     "Catalan",
+				VoiceReplies: true,
+				Tools:        []string{"current_time"},
+			},
+		},
+		{name: "unknown setting", settings: []string{"voice=yes"}, wantErr: true},
+		{name: "missing value", settings: []string{"model"}, wantErr: true},
+		{name: "invalid temperature", settings: []string{"temperature=hot"}, wantErr: true},
+		{name: "temperature out of range", settings: []string{"temperature=3"}, wantErr: true},
+		{name: "invalid max tokens", settings: []string{"max-tokens=-1"}, wantErr: true},
+		{name: "invalid timezone", settings: []string{"timezone=Mars/Base"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat := tt.chat
+			err := setChatSettings(&chat, tt.settings)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !reflect.DeepEqual(chat, tt.want) {
+				t.Errorf("got chat %+v, want %+v", chat, tt.want)
+			}
+		})
+	}
+}
+
+func TestChats(t *testing.T) {
+	ctx := context.Background()
+	databaseURL := "sqlite://" + filepath.Join(t.TempDir(), "chatbot.db")
+
+	db, err := openDatabase(ctx, zap.NewNop(), databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %s", err)
+	}
+	err = db.migrator.MigrateUp(ctx)
+	db.close()
+	if err != nil {
+		t.Fatalf("failed to migrate database: %s", err)
+	}
+
+	steps := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{args: []string{"allow", "15551234567", "15559876543"}},
+		{args: []string{"allow", "15551234567"}},
+		{args: []string{"set", "15551234567", "model=gpt-4o", "language=Spanish"}},
+		{args: []string{"set", "15550000000", "model=gpt-4o"}, wantErr: true},
+		{args: []string{"deny", "15559876543", "15550000000"}},
+		{args: []string{"list"}},
+	}
+	for _, step := range steps {
+		err := chats(ctx, zap.NewNop(), config{args: step.args, databaseURL: databaseURL})
+		if (err != nil) != step.wantErr {
+			t.Fatalf("got error %v for %q, want error %t", err, step.args, step.wantErr)
+		}
+	}
+
+	db, err = openDatabase(ctx, zap.NewNop(), databaseURL)
+	if err != nil {
+		t.Fatalf("failed to open database: %s", err)
+	}
+	defer db.close()
+
+	tx, err := db.store.BeginTx(ctx, sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %s", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	got, err := db.store.Chats(ctx, tx)
+	if err != nil {
+		t.Fatalf("failed to get chats: %s", err)
+	}
+	if len(got) != 1 || got[0].ID != "15551234567" || got[0].Model != "gpt-4o" || got[0].Language != "Spanish" {
+		t.Errorf("got chats %+v, want the allowed one with its settings", got)
+	}
+}
+
+func TestChatsInvalidArgs(t *testing.T) {
+	// The arguments are validated before opening the database, whose URL is invalid.
+	tests := [][]string{
+		nil,
+		{"block", "15551234567"},
+		{"allow"},
+		{"deny"},
+		{"set", "15551234567"},
+		{"set", "15551234567", "voice=yes"},
+	}
+
+	for _, args := range tests {
+		err := chats(context.Background(), zap.NewNop(), config{args: args, databaseURL: "invalid://"})
+		if err == nil || strings.Contains(err.Error(), "database") {
+			t.Errorf("got error %v for %q, want one about the arguments", err, args)
+		}
+	}
+}
+
+func TestPrintChats(t *testing.T) {
+	temperature := float32(0.3)
+
+	var out bytes.Buffer
+	printChats(&out, []data.Chat{
+		{ID: "15551234567"},
+		{
+			ID:           "15559876543",
+			Model:        "gpt-4o",
+			Temperature:  &temperature,
+			MaxTokens:    500,
+			Language:     "Spanish",
+			Timezone:     "Europe/Madrid",
+			VoiceReplies: true,
+			Tools:        []string{"current_time", "reminders"},
+			SystemPrompt: "You are a pirate.",
+		},
+	})
+
+	want := "" +
+		"CHAT ID      MODEL   TEMPERATURE  MAX TOKENS  LANGUAGE  TIMEZONE       VOICE  TOOLS                   SYSTEM PROMPT\n" +
+		"15551234567  -       -            -           -         -              no     -                       default\n" +
+		"15559876543  gpt-4o  0.3          500         Spanish   Europe/Madrid  yes    current_time,reminders  custom\n"
+	if out.String() != want {
+		t.Errorf("got table:\n%s\nwant:\n%s", out.String(), want)
+	}
+}
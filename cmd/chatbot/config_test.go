@@ -0,0 +1,21 @@
+package main
+
+import "testing"
+
+func TestNewConfigTemperature(t *testing.T) {
+	cfg, err := newConfig([]string{"chats", "list"})
+	if err != nil {
+		t.Fatalf("failed to parse config: %s", err)
+	}
+	if cfg.temperature != nil {
+		t.Errorf("got temperature %v, want none without --temperature", *cfg.temperature)
+	}
+
+	cfg, err = newConfig([]string{"--temperature=0", "chats", "list"})
+	if err != nil {
+		t.Fatalf("failed to parse config: %s", err)
+	}
+	if cfg.temperature == nil || *cfg.temperature != 0 {
+		t.Errorf("got temperature %v, want zero with --temperature=0", cfg.temperature)
+	}
+}
@@ -0,0 +1,148 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/jackc/pgx/v5"
+	pgxstd "github.com/jackc/pgx/v5/stdlib"
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+	"github.com/happybydefault/chatbot/memory"
+	"github.com/happybydefault/chatbot/postgres"
+	"github.com/happybydefault/chatbot/sqlite"
+)
+
+// migrator is implemented by the data stores with a versioned schema.
+type migrator interface {
+	MigrateUp(ctx context.Context) error
+	MigrateDown(ctx context.Context, steps int) error
+	SchemaVersion(ctx context.Context) (int, error)
+	LatestSchemaVersion() (int, error)
+}
+
+// database is the data store and the whatsmeow database selected by the scheme of the database URL:
+//
+//   - "postgres://" or "postgresql://" for Postgres.
+//   - "sqlite://<path>" for SQLite.
+//   - "memory://?chat=<chat ID>&chat=..." for an ephemeral in-memory store with the given chats, and an
+//     in-memory SQLite database for whatsmeow.
+type database struct {
+	store            data.Store
+	migrator         migrator // Nil if the store has no schema.
+	whatsmeowDB      *sql.DB
+	whatsmeowDialect string
+
+	closers []func()
+}
+
+func openDatabase(ctx context.Context, logger *zap.Logger, databaseURL string) (*database, error) {
+	scheme, _, _ := strings.Cut(databaseURL, "://")
+
+	switch scheme {
+	case "postgres", "postgresql":
+		return openPostgres(ctx, logger, databaseURL)
+	case "sqlite":
+		path, err := sqlite.PathFromURL(databaseURL)
+		if err != nil {
+			return nil, err
+		}
+		return openSQLite(ctx, logger, path)
+	case "memory":
+		return openMemory(ctx, logger, databaseURL)
+	default:
+		return nil, fmt.Errorf("unsupported database URL scheme %q", scheme)
+	}
+}
+
+func openPostgres(ctx context.Context, logger *zap.Logger, connString string) (*database, error) {
+	d := &database{whatsmeowDialect: "postgres"}
+
+	connConfig, err := pgx.ParseConfig(connString)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse Postgres connection string: %w", err)
+	}
+	db := pgxstd.OpenDB(*connConfig)
+	d.whatsmeowDB = db
+	d.closers = append(d.closers, func() {
+		err := db.Close()
+		if err != nil {
+			logger.Error("failed to close Postgres database connection", zap.Error(err))
+		}
+	})
+
+	err = db.PingContext(ctx)
+	if err != nil {
+		d.close()
+		return nil, fmt.Errorf("failed to ping database: %w", err)
+	}
+
+	store, err := postgres.NewStore(ctx, connString, logger.Named("postgres-store"))
+	if err != nil {
+		d.close()
+		return nil, fmt.Errorf("failed to construct Postgres store: %w", err)
+	}
+	d.store = store
+	d.migrator = store
+	d.closers = append(d.closers, store.Close)
+
+	return d, nil
+}
+
+func openSQLite(ctx context.Context, logger *zap.Logger, path string) (*database, error) {
+	d := &database{whatsmeowDialect: "sqlite3"}
+
+	db, err := sqlite.OpenDB(path)
+	if err != nil {
+		return nil, err
+	}
+	d.whatsmeowDB = db
+	d.closers = append(d.closers, func() {
+		err := db.Close()
+		if err != nil {
+			logger.Error("failed to close SQLite database", zap.Error(err))
+		}
+	})
+
+	store, err := sqlite.NewStore(ctx, db, logger.Named("sqlite-store"))
+	if err != nil {
+		d.close()
+		return nil, fmt.Errorf("failed to construct SQLite store: %w", err)
+	}
+	d.store = store
+	d.migrator = store
+
+	return d, nil
+}
+
+func openMemory(ctx context.Context, logger *zap.Logger, databaseURL string) (*database, error) {
+	u, err := url.Parse(databaseURL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse memory URL: %w", err)
+	}
+
+	var chats []data.Chat
+	for _, chatID := range u.Query()["chat"] {
+		chats = append(chats, data.Chat{ID: chatID})
+	}
+
+	d, err := openSQLite(ctx, logger, ":memory:")
+	if err != nil {
+		return nil, err
+	}
+	d.store = memory.NewStore(chats...)
+	d.migrator = nil
+
+	return d, nil
+}
+
+// close closes everything opened by openDatabase, in reverse order.
+func (d *database) close() {
+	for i := len(d.closers) - 1; i >= 0; i-- {
+		d.closers[i]()
+	}
+}
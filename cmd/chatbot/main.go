@@ -48,7 +48,7 @@ func main() {
 		cancel()
 	}()
 
-	err = run(ctx, logger, cfg)
+	err = execute(ctx, logger, cfg)
 	if err != nil && !errors.Is(err, context.Canceled) {
 		logger.Error("failed to run", zap.Error(err))
 		statusCode = 1
@@ -56,6 +56,17 @@ func main() {
 	}
 }
 
+func execute(ctx context.Context, logger *zap.Logger, cfg config) error {
+	switch cfg.command {
+	case "migrate":
+		return migrate(ctx, logger, cfg)
+	case "chats":
+		return chats(ctx, logger, cfg)
+	default:
+		return run(ctx, logger, cfg)
+	}
+}
+
 func newLogger(development bool) (*zap.Logger, error) {
 	var cfg zap.Config
 
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// migrate executes the "migrate" command:
+//
+//	chatbot migrate [up | down [steps] | version]
+func migrate(ctx context.Context, logger *zap.Logger, cfg config) error {
+	action := "up"
+	if len(cfg.args) > 0 {
+		action = cfg.args[0]
+	}
+
+	db, err := openDatabase(ctx, logger, cfg.databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.close()
+
+	store := db.migrator
+	if store == nil {
+		return fmt.Errorf("database has no schema to migrate")
+	}
+
+	switch action {
+	case "up":
+		err = store.MigrateUp(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to apply migrations: %w", err)
+		}
+	case "down":
+		steps := 1
+		if len(cfg.args) > 1 {
+			steps, err = strconv.Atoi(cfg.args[1])
+			if err != nil || steps < 1 {
+				return fmt.Errorf("invalid number of steps %q", cfg.args[1])
+			}
+		}
+
+		err = store.MigrateDown(ctx, steps)
+		if err != nil {
+			return fmt.Errorf("failed to revert migrations: %w", err)
+		}
+	case "version":
+		version, err := store.SchemaVersion(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to get schema version: %w", err)
+		}
+		latest, err := store.LatestSchemaVersion()
+		if err != nil {
+			return fmt.Errorf("failed to get latest schema version: %w", err)
+		}
+
+		fmt.Printf("database schema version: %d (latest: %d)\n", version, latest)
+	default:
+		return fmt.Errorf("unknown migrate action %q", action)
+	}
+
+	return nil
+}
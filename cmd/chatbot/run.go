@@ -2,45 +2,119 @@ package main
 
 import (
 	"context"
+	"errors"
+	"expvar"
 	"fmt"
+	"net/http"
+	"net/url"
+	"time"
 
-	"github.com/jackc/pgx/v5"
-	pgxstd "github.com/jackc/pgx/v5/stdlib"
 	"go.uber.org/zap"
 
 	"github.com/happybydefault/chatbot"
-	"github.com/happybydefault/chatbot/postgres"
+	"github.com/happybydefault/chatbot/espeak"
+	"github.com/happybydefault/chatbot/openai"
+	"github.com/happybydefault/chatbot/placeholder"
+	"github.com/happybydefault/chatbot/whispercpp"
 )
 
 func run(ctx context.Context, logger *zap.Logger, cfg config) error {
-	connConfig, err := pgx.ParseConfig(cfg.postgresConnString)
+	if cfg.metricsAddress != "" {
+		metricsServer := newMetricsServer(cfg.metricsAddress)
+		go func() {
+			err := metricsServer.ListenAndServe()
+			if err != nil && !errors.Is(err, http.ErrServerClosed) {
+				logger.Error("failed to serve metrics", zap.Error(err))
+			}
+		}()
+		defer func() {
+			err := metricsServer.Close()
+			if err != nil {
+				logger.Error("failed to close metrics server", zap.Error(err))
+			}
+		}()
+	}
+
+	location, err := time.LoadLocation(cfg.timezone)
 	if err != nil {
-		return fmt.Errorf("failed to parse Postgres connection string: %w", err)
+		return fmt.Errorf("failed to load timezone: %w", err)
 	}
-	db := pgxstd.OpenDB(*connConfig)
-	defer func() {
-		err := db.Close()
+
+	quoteMode, err := chatbot.ParseQuoteMode(cfg.quoteMode)
+	if err != nil {
+		return fmt.Errorf("invalid quote mode: %w", err)
+	}
+
+	db, err := openDatabase(ctx, logger, cfg.databaseURL)
+	if err != nil {
+		return fmt.Errorf("failed to open database: %w", err)
+	}
+	defer db.close()
+
+	if cfg.autoMigrate && db.migrator != nil {
+		err = db.migrator.MigrateUp(ctx)
 		if err != nil {
-			logger.Error("failed to close Postgres database connection", zap.Error(err))
+			return fmt.Errorf("failed to migrate database: %w", err)
 		}
-	}()
+	}
+
+	transcriber, err := newTranscriber(cfg)
+	if err != nil {
+		return fmt.Errorf("invalid transcriber: %w", err)
+	}
 
-	err = db.PingContext(ctx)
+	imageGenerator, err := newImageGenerator(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to ping database: %w", err)
+		return fmt.Errorf("invalid image generator: %w", err)
 	}
 
-	store, err := postgres.NewStore(ctx, cfg.postgresConnString, logger.Named("postgres-store"))
+	synthesizer, err := newSynthesizer(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to construct Postgres store: %w", err)
+		return fmt.Errorf("invalid synthesizer: %w", err)
 	}
-	defer store.Close()
 
 	chatbotConfig := chatbot.Config{
-		Logger:       logger,
-		Store:        store,
-		WhatsmeowDB:  db,
-		OpenAIAPIKey: cfg.openAIAPIKey,
+		Logger:           logger,
+		Store:            db.store,
+		WhatsmeowDB:      db.whatsmeowDB,
+		WhatsmeowDialect: db.whatsmeowDialect,
+		Completer: openai.NewCompleter(openai.Config{
+			APIKey:  cfg.openAIAPIKey,
+			BaseURL: cfg.openAIBaseURL,
+		}),
+		SystemPrompt: cfg.systemPrompt,
+		Model:        cfg.model,
+		MaxTokens:    cfg.maxTokens,
+		Temperature:  cfg.temperature,
+		Stop:         cfg.stop,
+		Location:     location,
+		BotName:      cfg.botName,
+
+		AdminIDs:      cfg.adminIDs,
+		CommandPrefix: cfg.commandPrefix,
+		QuoteMode:     quoteMode,
+
+		MaxMessageLength: cfg.maxMessageLength,
+		StreamResponses:  cfg.stream,
+		StreamInterval:   cfg.streamInterval,
+
+		Transcriber:        transcriber,
+		TranscriptionModel: cfg.transcriptionModel,
+
+		ImageGenerator: imageGenerator,
+		ImageModel:     cfg.imageModel,
+
+		Synthesizer: synthesizer,
+		SpeechModel: cfg.speechModel,
+		Voice:       cfg.voice,
+
+		VisionModels:       cfg.visionModels,
+		MaxImageSize:       cfg.maxImageSize,
+		MaxImageResolution: cfg.maxImageResolution,
+		MaxDocumentSize:    cfg.maxDocumentSize,
+
+		ContextWindowSize:  cfg.contextWindowSize,
+		SummarizeThreshold: cfg.summarizeThreshold,
 	}
 
 	client, err := chatbot.NewClient(chatbotConfig)
@@ -70,3 +144,71 @@ func run(ctx context.Context, logger *zap.Logger, cfg config) error {
 		return err
 	}
 }
+
+// newTranscriber returns the transcriber selected by the --transcriber flag, or nil if it's empty.
+func newTranscriber(cfg config) (chatbot.Transcriber, error) {
+	switch cfg.transcriber {
+	case "":
+		return nil, nil
+	case "openai":
+		return openai.NewTranscriber(openai.Config{
+			APIKey:  cfg.openAIAPIKey,
+			BaseURL: cfg.openAIBaseURL,
+		}), nil
+	}
+
+	u, err := url.Parse(cfg.transcriber)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf(`%q is neither "openai" nor an HTTP URL`, cfg.transcriber)
+	}
+
+	return whispercpp.NewTranscriber(whispercpp.Config{
+		URL: cfg.transcriber,
+	}), nil
+}
+
+// newImageGenerator returns the image generator selected by the --image-generator flag, or nil if
+// it's empty.
+func newImageGenerator(cfg config) (chatbot.ImageGenerator, error) {
+	switch cfg.imageGenerator {
+	case "":
+		return nil, nil
+	case "openai":
+		return openai.NewImageGenerator(openai.Config{
+			APIKey:  cfg.openAIAPIKey,
+			BaseURL: cfg.openAIBaseURL,
+		}), nil
+	case "placeholder":
+		return placeholder.NewImageGenerator(), nil
+	default:
+		return nil, fmt.Errorf(`%q is neither "openai" nor "placeholder"`, cfg.imageGenerator)
+	}
+}
+
+// newSynthesizer returns the synthesizer selected by the --synthesizer flag, or nil if it's empty.
+func newSynthesizer(cfg config) (chatbot.Synthesizer, error) {
+	switch cfg.synthesizer {
+	case "":
+		return nil, nil
+	case "openai":
+		return openai.NewSynthesizer(openai.Config{
+			APIKey:  cfg.openAIAPIKey,
+			BaseURL: cfg.openAIBaseURL,
+		}), nil
+	case "espeak":
+		return espeak.NewSynthesizer(), nil
+	default:
+		return nil, fmt.Errorf(`%q is neither "openai" nor "espeak"`, cfg.synthesizer)
+	}
+}
+
+func newMetricsServer(address string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/debug/vars", expvar.Handler())
+
+	return &http.Server{
+		Addr:              address,
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
@@ -0,0 +1,134 @@
+package chatbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+)
+
+// defaultCommandTimeout is the time that commands have to run, unless they set their own timeout.
+const defaultCommandTimeout = 10 * time.Second
+
+// errInvalidArgs is wrapped by the errors of commands that are caused by their arguments. Only the
+// messages of these errors are sent to the chat, since the ones of other errors may have internal
+// details, e.g. of the data store.
+var errInvalidArgs = errors.New("invalid arguments")
+
+// command is a command that is sent to the chatbot as a WhatsApp message like "/name args".
+type command struct {
+	name        string
+	args        string // Describes the arguments in the help, e.g. "<chat ID>".
+	description string
+	timeout     time.Duration // Defaults to defaultCommandTimeout.
+
+	// run executes the command and returns the reply to it, or an empty string if the command sent
+	// its own reply.
+	run func(c *Chat, ctx context.Context, msg message, args string) (string, error)
+}
+
+type commandSet []command
+
+func (s commandSet) find(name string) (command, bool) {
+	for _, cmd := range s {
+		if cmd.name == name {
+			return cmd, true
+		}
+	}
+
+	return command{}, false
+}
+
+// help returns a description of every command of the set, one per line.
+func (s commandSet) help(prefix string) string {
+	var sb strings.Builder
+	for i, cmd := range s {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+
+		sb.WriteString(prefix + cmd.name)
+		if cmd.args != "" {
+			sb.WriteString(" " + cmd.args)
+		}
+		sb.WriteString(" - " + cmd.description)
+	}
+
+	return sb.String()
+}
+
+// parseCommand splits a text like "/name some args" into the name of the command and its arguments.
+// It returns false if the text doesn't start with prefix.
+func parseCommand(text, prefix string) (name, args string, ok bool) {
+	text = strings.TrimSpace(text)
+
+	rest, ok := strings.CutPrefix(text, prefix)
+	if !ok || rest == "" {
+		return "", "", false
+	}
+
+	name, args, _ = strings.Cut(rest, " ")
+
+	return strings.ToLower(name), strings.TrimSpace(args), true
+}
+
+// runCommand executes the command of the set that text invokes, if any, and sends its reply to the
+// chat. It returns false if text doesn't invoke a command of the set. The errors of the command are
+// logged and replied with a generic message, unless they are caused by its arguments.
+func (c *Chat) runCommand(commands commandSet, msg message, text string) (bool, error) {
+	name, args, ok := parseCommand(text, c.client.commandPrefix)
+	if !ok {
+		return false, nil
+	}
+
+	cmd, ok := commands.find(name)
+	if !ok {
+		return false, nil
+	}
+
+	timeout := cmd.timeout
+	if timeout == 0 {
+		timeout = defaultCommandTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	reply, err := cmd.run(c, ctx, msg, args)
+	switch {
+	case errors.Is(err, errInvalidArgs):
+		reply = fmt.Sprintf("Failed to run %s%s: %s.", c.client.commandPrefix, cmd.name, err)
+	case err != nil:
+		c.logger.Error(
+			"failed to run command",
+			zap.String("message_id", msg.Info.ID),
+			zap.String("command", cmd.name),
+			zap.Error(err),
+		)
+		reply = fmt.Sprintf("Failed to run %s%s. Please try again later.", c.client.commandPrefix, cmd.name)
+	}
+	if reply == "" {
+		return true, nil
+	}
+
+	err = c.sendText(ctx, msg.Info.Chat, reply)
+	if err != nil {
+		return true, fmt.Errorf("failed to send command reply: %w", err)
+	}
+
+	return true, nil
+}
+
+func (c *Chat) sendText(ctx context.Context, jid types.JID, text string) error {
+	_, err := c.client.whatsmeowClient.SendMessage(ctx, jid, "", &waProto.Message{
+		Conversation: proto.String(text),
+	})
+
+	return err
+}
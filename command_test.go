@@ -0,0 +1,100 @@
+package chatbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseCommand(t *testing.T) {
+	tests := []struct {
+		text     string
+		wantName string
+		wantArgs string
+		wantOK   bool
+	}{
+		{text: "/help", wantName: "help", wantOK: true},
+		{text: "  /Allow   15551234567 ", wantName: "allow", wantArgs: "15551234567", wantOK: true},
+		{text: "/prompt You are a pirate.", wantName: "prompt", wantArgs: "You are a pirate.", wantOK: true},
+		{text: "/", wantOK: false},
+		{text: "help", wantOK: false},
+		{text: "What does /help do?", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			name, args, ok := parseCommand(tt.text, "/")
+			if name != tt.wantName || args != tt.wantArgs || ok != tt.wantOK {
+				t.Errorf("got %q, %q, %v, want %q, %q, %v", name, args, ok, tt.wantName, tt.wantArgs, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestChatRunCommand(t *testing.T) {
+	commands := commandSet{
+		{
+			name: "echo",
+			run: func(c *Chat, ctx context.Context, msg message, args string) (string, error) {
+				return "Echo: " + args, nil
+			},
+		},
+		{
+			name: "silent",
+			run: func(c *Chat, ctx context.Context, msg message, args string) (string, error) {
+				return "", nil
+			},
+		},
+		{
+			name: "fail",
+			run: func(c *Chat, ctx context.Context, msg message, args string) (string, error) {
+				return "", fmt.Errorf("failed to update chat in data store: %w", errors.New("pq: connection refused"))
+			},
+		},
+		{
+			name: "usage",
+			run: func(c *Chat, ctx context.Context, msg message, args string) (string, error) {
+				return "", fmt.Errorf("%w: missing prompt", errInvalidArgs)
+			},
+		},
+	}
+
+	tests := []struct {
+		text          string
+		wantIsCommand bool
+		wantSent      []string
+	}{
+		{text: "/echo hello", wantIsCommand: true, wantSent: []string{"Echo: hello"}},
+		{text: "/silent", wantIsCommand: true, wantSent: nil},
+		{text: "/fail", wantIsCommand: true, wantSent: []string{"Failed to run /fail. Please try again later."}},
+		{text: "/usage", wantIsCommand: true, wantSent: []string{"Failed to run /usage: invalid arguments: missing prompt."}},
+		{text: "/unknown", wantIsCommand: false, wantSent: nil},
+		{text: "echo", wantIsCommand: false, wantSent: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.text, func(t *testing.T) {
+			whatsApp := &fakeWhatsApp{}
+			chat, _ := newTestChat(t, whatsApp, &fakeCompleter{})
+			chat.client.commandPrefix = "/"
+
+			msg := newTestMessage("1", &waProto.Message{Conversation: proto.String(tt.text)})
+			isCommand, err := chat.runCommand(commands, msg, tt.text)
+			if err != nil {
+				t.Fatalf("failed to run command: %s", err)
+			}
+			if isCommand != tt.wantIsCommand {
+				t.Errorf("got is command %v, want %v", isCommand, tt.wantIsCommand)
+			}
+
+			sent := whatsApp.sentTexts()
+			if len(sent) != len(tt.wantSent) || (len(sent) > 0 && sent[0] != tt.wantSent[0]) {
+				t.Errorf("sent %q, want %q", sent, tt.wantSent)
+			}
+		})
+	}
+}
@@ -2,38 +2,135 @@ package chatbot
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
-	gpt "github.com/sashabaranov/go-gpt3"
 	"go.uber.org/zap"
 )
 
-func (c *Client) completion(ctx context.Context, messages []gpt.ChatCompletionMessage) (gpt.ChatCompletionResponse, error) {
-	var completionResponse gpt.ChatCompletionResponse
+var (
+	// ErrCompletionRejected is returned by a Completer when the backend rejected the request,
+	// so retrying the same request would not help.
+	ErrCompletionRejected = errors.New("completion request rejected")
 
-	fn := func() error {
-		completionRequest := newCompletionRequest(messages)
+	// ErrEmptyCompletion is returned by a Completer when the backend responded without any choices.
+	ErrEmptyCompletion = errors.New("received empty slice of completion choices")
+)
+
+type Role string
+
+const (
+	RoleSystem    Role = "system"
+	RoleUser      Role = "user"
+	RoleAssistant Role = "assistant"
+	RoleTool      Role = "tool"
+)
+
+type CompletionMessage struct {
+	Role    Role
+	Content string
+	Images  []CompletionImage // Only sent to models that support vision.
+
+	ToolCalls  []ToolCall // Calls of the model to tools, in assistant messages.
+	ToolCallID string     // ID of the call whose result is Content, in tool messages.
+}
+
+// ToolCall is a call of the model to a tool, with its arguments as a JSON object.
+type ToolCall struct {
+	ID        string
+	Name      string
+	Arguments string
+}
+
+// ToolDefinition describes a tool that the model can call, with its parameters as a JSON schema.
+type ToolDefinition struct {
+	Name        string
+	Description string
+	Parameters  json.RawMessage
+}
+
+type CompletionImage struct {
+	MIMEType string
+	Data     []byte
+}
+
+type CompletionRequest struct {
+	Model       string
+	Messages    []CompletionMessage
+	MaxTokens   int
+	Temperature *float32 // Nil for the default of the backend.
+	Stop        []string
+	Tools       []ToolDefinition
+}
+
+type CompletionResponse struct {
+	Message CompletionMessage
+}
+
+// Completer is implemented by the backends that generate the chatbot's responses.
+type Completer interface {
+	Complete(ctx context.Context, request CompletionRequest) (CompletionResponse, error)
+}
 
+// StreamingCompleter is implemented by the Completers that can stream the responses. CompleteStream
+// calls onDelta with every piece of the content as it's generated, stopping with its error if it
+// returns one, and returns the whole response at the end, including the tool calls.
+type StreamingCompleter interface {
+	Completer
+	CompleteStream(ctx context.Context, request CompletionRequest, onDelta func(delta string) error) (CompletionResponse, error)
+}
+
+func (c *Client) completion(ctx context.Context, request CompletionRequest) (CompletionResponse, error) {
+	return c.retryCompletion(func() (CompletionResponse, error) {
+		return c.completer.Complete(ctx, request)
+	})
+}
+
+// completionStream streams the response to request with the StreamingCompleter of the client, calling
+// onText with all the content received so far every time it grows. If the request is retried, the
+// content starts over.
+func (c *Client) completionStream(ctx context.Context, request CompletionRequest, onText func(text string) error) (CompletionResponse, error) {
+	streamingCompleter, ok := c.completer.(StreamingCompleter)
+	if !ok {
+		return CompletionResponse{}, errors.New("completer doesn't support streaming")
+	}
+
+	return c.retryCompletion(func() (CompletionResponse, error) {
+		var text strings.Builder
+		var onTextErr error
+		completionResponse, err := streamingCompleter.CompleteStream(ctx, request, func(delta string) error {
+			text.WriteString(delta)
+			onTextErr = onText(text.String())
+			return onTextErr
+		})
+		if onTextErr != nil {
+			return CompletionResponse{}, backoff.Permanent(onTextErr)
+		}
+
+		return completionResponse, err
+	})
+}
+
+// retryCompletion calls fn until it returns a completion response, retrying the errors that may be
+// temporary.
+func (c *Client) retryCompletion(fn func() (CompletionResponse, error)) (CompletionResponse, error) {
+	var completionResponse CompletionResponse
+
+	operation := func() error {
 		var err error
-		completionResponse, err = c.gpt3Client.CreateChatCompletion(ctx, completionRequest)
+		completionResponse, err = fn()
 		if err != nil {
-			var apiErr *gpt.APIError
-			if errors.As(err, &apiErr) {
-				if apiErr.StatusCode < 500 || apiErr.StatusCode >= 600 {
-					return backoff.Permanent(err)
-				}
+			if errors.Is(err, ErrCompletionRejected) || errors.Is(err, ErrEmptyCompletion) {
+				return backoff.Permanent(err)
 			}
 			c.logger.Debug("failed attempt to get completion response", zap.Error(err))
 			return err
 		}
 
-		if len(completionResponse.Choices) == 0 {
-			return backoff.Permanent(errors.New("received empty slice of completion choices"))
-		}
-
 		c.logger.Debug(
 			"received completion response",
 			zap.String("completion_response", fmt.Sprintf("%#v", completionResponse)),
@@ -43,27 +140,9 @@ func (c *Client) completion(ctx context.Context, messages []gpt.ChatCompletionMe
 	}
 
 	err := backoff.Retry(
-		fn,
+		operation,
 		backoff.WithMaxRetries(backoff.NewConstantBackOff(100*time.Millisecond), 3),
 	)
 
 	return completionResponse, err
 }
-
-func newCompletionRequest(messages []gpt.ChatCompletionMessage) gpt.ChatCompletionRequest {
-	var (
-		maxTokens           = 512
-		temperature float32 = 0.0
-		stop                = []string{"'''"}
-	)
-
-	completionRequest := gpt.ChatCompletionRequest{
-		Model:       gpt.GPT3Dot5Turbo,
-		Messages:    messages,
-		MaxTokens:   maxTokens,
-		Temperature: temperature,
-		Stop:        stop,
-	}
-
-	return completionRequest
-}
@@ -0,0 +1,87 @@
+package chatbot
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"go.uber.org/zap"
+)
+
+// failingCompleter is a Completer that fails with err the first failures times that it's called.
+type failingCompleter struct {
+	err      error
+	failures int
+	calls    int
+}
+
+func (f *failingCompleter) Complete(ctx context.Context, request CompletionRequest) (CompletionResponse, error) {
+	f.calls++
+	if f.calls <= f.failures {
+		return CompletionResponse{}, f.err
+	}
+
+	return CompletionResponse{
+		Message: CompletionMessage{
+			Role:    RoleAssistant,
+			Content: "Hello!",
+		},
+	}, nil
+}
+
+func TestClientCompletionRetries(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		failures  int
+		wantCalls int
+		wantErr   bool
+	}{
+		{
+			name:      "temporary error",
+			err:       errors.New("503 Service Unavailable"),
+			failures:  2,
+			wantCalls: 3,
+		},
+		{
+			name:      "persistent error",
+			err:       errors.New("503 Service Unavailable"),
+			failures:  10,
+			wantCalls: 4,
+			wantErr:   true,
+		},
+		{
+			name:      "rejected request",
+			err:       fmt.Errorf("%w: 400 Bad Request", ErrCompletionRejected),
+			failures:  10,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+		{
+			name:      "empty completion",
+			err:       ErrEmptyCompletion,
+			failures:  10,
+			wantCalls: 1,
+			wantErr:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			completer := &failingCompleter{err: tt.err, failures: tt.failures}
+			client := &Client{logger: zap.NewNop(), completer: completer}
+
+			response, err := client.completion(context.Background(), CompletionRequest{})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && response.Message.Content != "Hello!" {
+				t.Errorf("got response %+v, want the one after the failures", response)
+			}
+			if completer.calls != tt.wantCalls {
+				t.Errorf("completer was called %d times, want %d", completer.calls, tt.wantCalls)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package chatbot
 
 import (
 	"database/sql"
+	"time"
 
 	"go.uber.org/zap"
 
@@ -9,8 +10,94 @@ import (
 )
 
 type Config struct {
-	Logger       *zap.Logger
-	Store        data.Store
-	WhatsmeowDB  *sql.DB // Must be a Postgres database.
-	OpenAIAPIKey string
+	Logger      *zap.Logger
+	Store       data.Store
+	WhatsmeowDB *sql.DB
+	Completer   Completer
+
+	// WhatsmeowDialect is the whatsmeow sqlstore dialect of WhatsmeowDB, i.e. "postgres" or "sqlite3".
+	// Defaults to "postgres".
+	WhatsmeowDialect string
+
+	// SystemPrompt, Model, MaxTokens, Temperature and Location are the defaults of the chats that
+	// don't set their own. SystemPrompt is a template executed with PromptData, and defaults to
+	// DefaultSystemPrompt. Temperature defaults to the one of the backend, and Location to
+	// time.Local. Stop is sent with every completion request.
+	SystemPrompt string
+	Model        string
+	MaxTokens    int
+	Temperature  *float32
+	Stop         []string
+	Location     *time.Location
+
+	// AdminIDs are the WhatsApp IDs (phone numbers) of the users that can run admin commands, besides
+	// the account of the chatbot itself.
+	AdminIDs []string
+
+	// CommandPrefix is the prefix of the messages that invoke commands. Defaults to "/".
+	CommandPrefix string
+
+	// QuoteMode selects the responses that quote the message they respond. Defaults to QuoteGroups.
+	QuoteMode QuoteMode
+
+	// MaxMessageLength is the maximum number of characters of the messages of the chatbot. Longer
+	// responses are split into several messages, preferably between paragraphs. Zero means unlimited.
+	// Streamed responses and voice replies are not split.
+	MaxMessageLength int
+
+	// StreamResponses sends the responses as soon as their first text is generated, editing them as
+	// the rest of it arrives, at most once per StreamInterval (one second by default). It requires a
+	// StreamingCompleter, and doesn't apply to voice replies.
+	StreamResponses bool
+	StreamInterval  time.Duration
+
+	// Transcriber transcribes the voice messages, which are ignored if it's nil. TranscriptionModel is
+	// sent with every transcription request.
+	Transcriber        Transcriber
+	TranscriptionModel string
+
+	// ImageGenerator generates the images of the imagine command, which is disabled if it's nil.
+	// ImageModel is sent with every image generation request.
+	ImageGenerator ImageGenerator
+	ImageModel     string
+
+	// Synthesizer turns the responses into voice messages in the chats with voice replies, which are
+	// replied with text if it's nil. SpeechModel and Voice are sent with every speech request.
+	Synthesizer Synthesizer
+	SpeechModel string
+	Voice       string
+
+	// Tools are the tools that the model can call in the chats that enable them, besides the built-in
+	// ones. Their names must be unique.
+	Tools []Tool
+
+	// VisionModels are the models that accept images. The chatbot responds to images with a fallback
+	// text when the model of the chat is not one of them.
+	VisionModels []string
+
+	// MaxImageSize is the maximum size in bytes of the images that are downloaded, and
+	// MaxImageResolution the maximum number of pixels of their longest side, beyond which they are
+	// downscaled. Zero means unlimited.
+	MaxImageSize       int
+	MaxImageResolution int
+
+	// MaxDocumentSize is the maximum size in bytes of the documents that are downloaded, i.e. PDFs and
+	// text files. Zero means unlimited.
+	MaxDocumentSize int
+
+	// BotName is the name of the chatbot in system prompts. Defaults to "Chatbot".
+	BotName string
+
+	// ContextWindowSize is the number of tokens that the model accepts for the prompt and the
+	// response combined. Older messages of a chat are dropped from the prompt to fit in it.
+	// Zero means unlimited.
+	ContextWindowSize int
+
+	// TokenCounter counts the tokens of the messages in the prompt. If nil, the number of tokens
+	// is estimated from the length of the messages.
+	TokenCounter TokenCounter
+
+	// SummarizeThreshold is the number of messages of a chat that are not covered by its summary
+	// that triggers summarizing the oldest of them. Zero disables summaries.
+	SummarizeThreshold int
 }
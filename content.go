@@ -0,0 +1,77 @@
+package chatbot
+
+import (
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+)
+
+// messageContent is what the chatbot uses of a WhatsApp message, independently of its type.
+type messageContent struct {
+	text        string // Text of the message, the caption of its media, or the transcription of its audio.
+	transcribed bool   // Whether text was transcribed from audio.
+
+	audio *waProto.AudioMessage // Nil if the message is not a voice message or an audio file.
+	image *CompletionImage      // Nil if the message is not an image or it hasn't been downloaded.
+
+	document       *waProto.DocumentMessage // Nil if the message is not a document.
+	documentChunks []string                 // Text of document, if it has been downloaded.
+
+	quotedID          string // ID of the message that this one replies to, if any.
+	quotedParticipant string // JID of the sender of the quoted message, if any.
+	quotedText        string // Text of the quoted message, if any.
+
+	mentionedJIDs []string
+}
+
+// parseMessageContent extracts the content of the textual WhatsApp message types. The text of the
+// other types, e.g. stickers or locations, is empty, and so is the text of audio messages until they
+// are transcribed.
+func parseMessageContent(msg *waProto.Message) messageContent {
+	if wrapped := msg.GetDocumentWithCaptionMessage().GetMessage(); wrapped != nil {
+		msg = wrapped
+	}
+
+	var (
+		content     messageContent
+		contextInfo *waProto.ContextInfo
+	)
+
+	switch {
+	case msg.GetConversation() != "":
+		content.text = msg.GetConversation()
+	case msg.GetExtendedTextMessage() != nil:
+		content.text = msg.GetExtendedTextMessage().GetText()
+		contextInfo = msg.GetExtendedTextMessage().GetContextInfo()
+	case msg.GetImageMessage() != nil:
+		content.text = msg.GetImageMessage().GetCaption()
+		contextInfo = msg.GetImageMessage().GetContextInfo()
+	case msg.GetVideoMessage() != nil:
+		content.text = msg.GetVideoMessage().GetCaption()
+		contextInfo = msg.GetVideoMessage().GetContextInfo()
+	case msg.GetAudioMessage() != nil:
+		content.audio = msg.GetAudioMessage()
+		contextInfo = msg.GetAudioMessage().GetContextInfo()
+	case msg.GetDocumentMessage() != nil:
+		content.text = msg.GetDocumentMessage().GetCaption()
+		content.document = msg.GetDocumentMessage()
+		contextInfo = msg.GetDocumentMessage().GetContextInfo()
+	case msg.GetButtonsResponseMessage() != nil:
+		content.text = msg.GetButtonsResponseMessage().GetSelectedDisplayText()
+		contextInfo = msg.GetButtonsResponseMessage().GetContextInfo()
+	case msg.GetTemplateButtonReplyMessage() != nil:
+		content.text = msg.GetTemplateButtonReplyMessage().GetSelectedDisplayText()
+		contextInfo = msg.GetTemplateButtonReplyMessage().GetContextInfo()
+	case msg.GetListResponseMessage() != nil:
+		content.text = msg.GetListResponseMessage().GetTitle()
+		contextInfo = msg.GetListResponseMessage().GetContextInfo()
+	}
+
+	content.mentionedJIDs = contextInfo.GetMentionedJid()
+
+	if contextInfo.GetStanzaId() != "" {
+		content.quotedID = contextInfo.GetStanzaId()
+		content.quotedParticipant = contextInfo.GetParticipant()
+		content.quotedText = parseMessageContent(contextInfo.GetQuotedMessage()).text
+	}
+
+	return content
+}
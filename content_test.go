@@ -0,0 +1,175 @@
+package chatbot
+
+import (
+	"reflect"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParseMessageContent(t *testing.T) {
+	quoted := &waProto.ContextInfo{
+		StanzaId:      proto.String("quoted"),
+		Participant:   proto.String(testBotID + "@s.whatsapp.net"),
+		QuotedMessage: &waProto.Message{Conversation: proto.String("Earlier message")},
+		MentionedJid:  []string{testBotID + "@s.whatsapp.net"},
+	}
+	document := &waProto.DocumentMessage{
+		Caption:     proto.String("Read this"),
+		FileName:    proto.String("notes.txt"),
+		ContextInfo: quoted,
+	}
+
+	tests := []struct {
+		name    string
+		message *waProto.Message
+		want    messageContent
+	}{
+		{
+			name:    "conversation",
+			message: &waProto.Message{Conversation: proto.String("Hi")},
+			want:    messageContent{text: "Hi"},
+		},
+		{
+			name: "extended text",
+			message: &waProto.Message{
+				ExtendedTextMessage: &waProto.ExtendedTextMessage{Text: proto.String("Hi")},
+			},
+			want: messageContent{text: "Hi"},
+		},
+		{
+			name: "extended text replying",
+			message: &waProto.Message{
+				ExtendedTextMessage: &waProto.ExtendedTextMessage{Text: proto.String("Why?"), ContextInfo: quoted},
+			},
+			want: messageContent{
+				text:              "Why?",
+				quotedID:          "quoted",
+				quotedParticipant: testBotID + "@s.whatsapp.net",
+				quotedText:        "Earlier message",
+				mentionedJIDs:     []string{testBotID + "@s.whatsapp.net"},
+			},
+		},
+		{
+			name: "quoted caption",
+			message: &waProto.Message{
+				ExtendedTextMessage: &waProto.ExtendedTextMessage{
+					Text: proto.String("Nice"),
+					ContextInfo: &waProto.ContextInfo{
+						StanzaId: proto.String("photo"),
+						QuotedMessage: &waProto.Message{
+							ImageMessage: &waProto.ImageMessage{Caption: proto.String("My cat")},
+						},
+					},
+				},
+			},
+			want: messageContent{text: "Nice", quotedID: "photo", quotedText: "My cat"},
+		},
+		{
+			// Without the ID of the quoted message, the context info is not a reply.
+			name: "context info without quote",
+			message: &waProto.Message{
+				ExtendedTextMessage: &waProto.ExtendedTextMessage{
+					Text: proto.String("Hi"),
+					ContextInfo: &waProto.ContextInfo{
+						QuotedMessage: &waProto.Message{Conversation: proto.String("Earlier message")},
+					},
+				},
+			},
+			want: messageContent{text: "Hi"},
+		},
+		{
+			name: "image caption",
+			message: &waProto.Message{
+				ImageMessage: &waProto.ImageMessage{Caption: proto.String("What is this?"), ContextInfo: quoted},
+			},
+			want: messageContent{
+				text:              "What is this?",
+				quotedID:          "quoted",
+				quotedParticipant: testBotID + "@s.whatsapp.net",
+				quotedText:        "Earlier message",
+				mentionedJIDs:     []string{testBotID + "@s.whatsapp.net"},
+			},
+		},
+		{
+			name:    "video caption",
+			message: &waProto.Message{VideoMessage: &waProto.VideoMessage{Caption: proto.String("Look")}},
+			want:    messageContent{text: "Look"},
+		},
+		{
+			name:    "document caption",
+			message: &waProto.Message{DocumentMessage: document},
+			want: messageContent{
+				text:              "Read this",
+				document:          document,
+				quotedID:          "quoted",
+				quotedParticipant: testBotID + "@s.whatsapp.net",
+				quotedText:        "Earlier message",
+				mentionedJIDs:     []string{testBotID + "@s.whatsapp.net"},
+			},
+		},
+		{
+			name: "document with caption",
+			message: &waProto.Message{
+				DocumentWithCaptionMessage: &waProto.FutureProofMessage{
+					Message: &waProto.Message{DocumentMessage: document},
+				},
+			},
+			want: messageContent{
+				text:              "Read this",
+				document:          document,
+				quotedID:          "quoted",
+				quotedParticipant: testBotID + "@s.whatsapp.net",
+				quotedText:        "Earlier message",
+				mentionedJIDs:     []string{testBotID + "@s.whatsapp.net"},
+			},
+		},
+		{
+			name: "buttons response",
+			message: &waProto.Message{
+				ButtonsResponseMessage: &waProto.ButtonsResponseMessage{
+					Response: &waProto.ButtonsResponseMessage_SelectedDisplayText{SelectedDisplayText: "Yes"},
+				},
+			},
+			want: messageContent{text: "Yes"},
+		},
+		{
+			name: "list response",
+			message: &waProto.Message{
+				ListResponseMessage: &waProto.ListResponseMessage{Title: proto.String("Option A")},
+			},
+			want: messageContent{text: "Option A"},
+		},
+		{
+			name: "sticker",
+			message: &waProto.Message{
+				StickerMessage: &waProto.StickerMessage{Mimetype: proto.String("image/webp")},
+			},
+			want: messageContent{},
+		},
+		{
+			name:    "nil message",
+			message: nil,
+			want:    messageContent{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := parseMessageContent(tt.message)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseMessageContentAudio(t *testing.T) {
+	audio := &waProto.AudioMessage{Ptt: proto.Bool(true), Mimetype: proto.String("audio/ogg; codecs=opus")}
+
+	got := parseMessageContent(&waProto.Message{AudioMessage: audio})
+	if got.audio != audio || got.text != "" {
+		t.Errorf("got %+v, want the audio without text until it's transcribed", got)
+	}
+}
@@ -0,0 +1,38 @@
+package chatbot
+
+// contextWindow selects which messages of a chat are sent to the model, so that the prompt plus
+// the tokens reserved for the response fit in the context window of the model.
+type contextWindow struct {
+	size    int // Zero means unlimited.
+	counter TokenCounter
+}
+
+// fit returns the pinned messages followed by the most recent messages of history that fit in the
+// context window after reserving maxTokens for the response, as well as the number of messages of
+// history that were dropped. Pinned messages (e.g. the system prompt) are always kept, and so is the
+// most recent message of history, even if they don't fit.
+func (w contextWindow) fit(pinned, history []CompletionMessage, maxTokens int) ([]CompletionMessage, int) {
+	messages := make([]CompletionMessage, 0, len(pinned)+len(history))
+	messages = append(messages, pinned...)
+
+	if w.size <= 0 {
+		return append(messages, history...), 0
+	}
+
+	budget := w.size - maxTokens - tokensPerReply
+	for _, msg := range pinned {
+		budget -= w.counter.CountTokens(msg)
+	}
+
+	first := len(history)
+	for first > 0 {
+		tokens := w.counter.CountTokens(history[first-1])
+		if tokens > budget && first < len(history) {
+			break
+		}
+		budget -= tokens
+		first--
+	}
+
+	return append(messages, history[first:]...), first
+}
@@ -0,0 +1,153 @@
+package chatbot
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// lengthTokenCounter is a TokenCounter that counts one token per byte of content, without overhead.
+type lengthTokenCounter struct{}
+
+func (lengthTokenCounter) CountTokens(message CompletionMessage) int {
+	return len(message.Content)
+}
+
+func TestContextWindowFit(t *testing.T) {
+	pinned := []CompletionMessage{{Role: RoleSystem, Content: strings.Repeat("s", 10)}}
+	history := []CompletionMessage{
+		{Role: RoleUser, Content: strings.Repeat("a", 10)},
+		{Role: RoleAssistant, Content: strings.Repeat("b", 20)},
+		{Role: RoleUser, Content: strings.Repeat("c", 30)},
+	}
+
+	tests := []struct {
+		name        string
+		size        int
+		maxTokens   int
+		wantHistory []CompletionMessage
+		wantDropped int
+	}{
+		{
+			name:        "unlimited",
+			size:        0,
+			maxTokens:   1000,
+			wantHistory: history,
+		},
+		{
+			name:        "everything fits",
+			size:        10 + 60 + 20 + tokensPerReply,
+			maxTokens:   20,
+			wantHistory: history,
+		},
+		{
+			name:        "oldest dropped",
+			size:        10 + 59 + 20 + tokensPerReply,
+			maxTokens:   20,
+			wantHistory: history[1:],
+			wantDropped: 1,
+		},
+		{
+			// The tokens reserved for the response count against the window too.
+			name:        "response reserved",
+			size:        10 + 60 + 20 + tokensPerReply,
+			maxTokens:   50,
+			wantHistory: history[2:],
+			wantDropped: 2,
+		},
+		{
+			name:        "most recent kept anyway",
+			size:        10,
+			maxTokens:   20,
+			wantHistory: history[2:],
+			wantDropped: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := contextWindow{size: tt.size, counter: lengthTokenCounter{}}
+
+			got, dropped := w.fit(pinned, history, tt.maxTokens)
+
+			want := append(append([]CompletionMessage{}, pinned...), tt.wantHistory...)
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("got messages %+v, want %+v", got, want)
+			}
+			if dropped != tt.wantDropped {
+				t.Errorf("got %d dropped messages, want %d", dropped, tt.wantDropped)
+			}
+		})
+	}
+}
+
+func TestEstimateTokenCounter(t *testing.T) {
+	tests := []struct {
+		name    string
+		message CompletionMessage
+		want    int
+	}{
+		{name: "empty", message: CompletionMessage{}, want: tokensPerMessage},
+		{name: "rounded up", message: CompletionMessage{Content: "Hello"}, want: tokensPerMessage + 2},
+		{name: "multibyte runes", message: CompletionMessage{Content: "ñandú ñandú"}, want: tokensPerMessage + 3},
+		{
+			name:    "images",
+			message: CompletionMessage{Content: "What?", Images: make([]CompletionImage, 2)},
+			want:    tokensPerMessage + 2 + 2*tokensPerImage,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := estimateTokenCounter{}.CountTokens(tt.message)
+			if got != tt.want {
+				t.Errorf("got %d tokens, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatRespondContextWindow(t *testing.T) {
+	ctx := context.Background()
+	completer := &fakeCompleter{content: "Hello!"}
+	chat, _ := newTestChat(t, &fakeWhatsApp{}, completer)
+
+	systemPrompt, err := ParsePromptTemplate("test", "System.")
+	if err != nil {
+		t.Fatalf("failed to parse system prompt: %s", err)
+	}
+	chat.client.systemPrompt = systemPrompt
+
+	// The window fits the system prompt, the last message of history, the trigger and the response.
+	chat.client.contextWindow = contextWindow{
+		size:    len("System.") + len("Message 2") + len("Hi") + 100 + tokensPerReply,
+		counter: lengthTokenCounter{},
+	}
+
+	createMessages(t, chat, testUserID, testBotID)
+	msg := newTestMessage("trigger", &waProto.Message{Conversation: proto.String("Hi")})
+	err = chat.storeMessageReceived(ctx, msg)
+	if err != nil {
+		t.Fatalf("failed to store message: %s", err)
+	}
+
+	err = chat.respond(msg.Message)
+	if err != nil {
+		t.Fatalf("failed to respond: %s", err)
+	}
+
+	if len(completer.requests) != 1 {
+		t.Fatalf("got %d completion requests, want 1", len(completer.requests))
+	}
+	var got []string
+	for _, m := range completer.requests[0].Messages {
+		got = append(got, m.Content)
+	}
+	if want := []string{"System.", "Message 2", "Hi"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got completion messages %q, want %q", got, want)
+	}
+}
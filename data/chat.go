@@ -1,5 +1,25 @@
 package data
 
+// Chat is a chat the chatbot is allowed to respond in. The zero values of its settings mean that the
+// global defaults of the chatbot are used.
 type Chat struct {
 	ID string
+
+	SystemPrompt string
+	Model        string
+	Temperature  *float32
+	MaxTokens    int
+	Language     string
+	Timezone     string // IANA Time Zone database name, e.g. "America/New_York".
+
+	// VoiceReplies makes the chatbot reply with voice messages instead of text, when it has a
+	// synthesizer.
+	VoiceReplies bool
+
+	// Tools are the names of the tools that the model can call in the chat.
+	Tools []string
+
+	// Segment is the conversation segment that new messages of the chat belong to. Starting a new
+	// segment excludes the earlier messages from the prompts without deleting them.
+	Segment int
 }
@@ -0,0 +1,801 @@
+// Package datatest implements a conformance test suite for implementations of data.Store.
+package datatest
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// NewStoreFunc returns an empty data.Store containing only the given chats. It's called once per
+// test, and the returned store must be isolated from the ones returned by previous calls.
+type NewStoreFunc func(t *testing.T, chats ...data.Chat) data.Store
+
+var (
+	readOnly  = sql.TxOptions{Isolation: sql.LevelReadCommitted, ReadOnly: true}
+	readWrite = sql.TxOptions{Isolation: sql.LevelReadCommitted, ReadOnly: false}
+)
+
+// TestStore runs the conformance test suite against the stores returned by newStore.
+func TestStore(t *testing.T, newStore NewStoreFunc) {
+	t.Run("Tx", func(t *testing.T) { testTx(t, newStore) })
+	t.Run("Chat", func(t *testing.T) { testChat(t, newStore) })
+	t.Run("CreateDeleteChat", func(t *testing.T) { testCreateDeleteChat(t, newStore) })
+	t.Run("Message", func(t *testing.T) { testMessage(t, newStore) })
+	t.Run("Summary", func(t *testing.T) { testSummary(t, newStore) })
+	t.Run("Media", func(t *testing.T) { testMedia(t, newStore) })
+	t.Run("Document", func(t *testing.T) { testDocument(t, newStore) })
+	t.Run("ToolCall", func(t *testing.T) { testToolCall(t, newStore) })
+	t.Run("Reminder", func(t *testing.T) { testReminder(t, newStore) })
+}
+
+func testTx(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	chat := data.Chat{ID: "chat"}
+
+	t.Run("committed changes are visible", func(t *testing.T) {
+		store := newStore(t, chat)
+
+		execTx(t, store, readWrite, func(tx data.Tx) {
+			mustCreateMessage(t, store, tx, newMessage(chat.ID, "1", time.Now()))
+		})
+
+		execTx(t, store, readOnly, func(tx data.Tx) {
+			messages, err := store.Messages(ctx, tx, chat.ID)
+			if err != nil {
+				t.Fatalf("failed to get messages: %s", err)
+			}
+			if len(messages) != 1 {
+				t.Fatalf("got %d messages, want 1", len(messages))
+			}
+		})
+	})
+
+	t.Run("rolled back changes are discarded", func(t *testing.T) {
+		store := newStore(t, chat)
+
+		tx := beginTx(t, store, readWrite)
+		mustCreateMessage(t, store, tx, newMessage(chat.ID, "1", time.Now()))
+		err := tx.Rollback(ctx)
+		if err != nil {
+			t.Fatalf("failed to roll back transaction: %s", err)
+		}
+
+		execTx(t, store, readOnly, func(tx data.Tx) {
+			messages, err := store.Messages(ctx, tx, chat.ID)
+			if err != nil {
+				t.Fatalf("failed to get messages: %s", err)
+			}
+			if len(messages) != 0 {
+				t.Fatalf("got %d messages, want 0", len(messages))
+			}
+		})
+	})
+
+	t.Run("finishing a finished transaction fails with ErrTxDone", func(t *testing.T) {
+		store := newStore(t, chat)
+
+		tx := beginTx(t, store, readWrite)
+		err := tx.Commit(ctx)
+		if err != nil {
+			t.Fatalf("failed to commit transaction: %s", err)
+		}
+
+		err = tx.Commit(ctx)
+		if !errors.Is(err, data.ErrTxDone) {
+			t.Errorf("got error %v after committing twice, want %v", err, data.ErrTxDone)
+		}
+		err = tx.Rollback(ctx)
+		if !errors.Is(err, data.ErrTxDone) {
+			t.Errorf("got error %v after rolling back a committed transaction, want %v", err, data.ErrTxDone)
+		}
+	})
+}
+
+func testChat(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+
+	temperature := float32(0.5)
+	chat := data.Chat{
+		ID:           "chat",
+		SystemPrompt: "You are {{.BotName}}.",
+		Model:        "model",
+		Temperature:  &temperature,
+		MaxTokens:    100,
+		Language:     "Spanish",
+		Timezone:     "America/Bogota",
+		Segment:      3,
+		VoiceReplies: true,
+		Tools:        []string{"current_time", "reminders"},
+	}
+	store := newStore(t, chat, data.Chat{ID: "another-chat"})
+
+	execTx(t, store, readOnly, func(tx data.Tx) {
+		got, err := store.Chat(ctx, tx, chat.ID)
+		if err != nil {
+			t.Fatalf("failed to get chat: %s", err)
+		}
+		if got.ID != chat.ID ||
+			got.SystemPrompt != chat.SystemPrompt ||
+			got.Model != chat.Model ||
+			got.Temperature == nil || *got.Temperature != *chat.Temperature ||
+			got.MaxTokens != chat.MaxTokens ||
+			got.Language != chat.Language ||
+			got.Timezone != chat.Timezone ||
+			got.Segment != chat.Segment ||
+			got.VoiceReplies != chat.VoiceReplies ||
+			strings.Join(got.Tools, ",") != strings.Join(chat.Tools, ",") {
+			t.Errorf("got chat %+v, want %+v", got, chat)
+		}
+
+		_, err = store.Chat(ctx, tx, "unknown")
+		if !errors.Is(err, data.ErrNotFound) {
+			t.Errorf("got error %v for unknown chat, want %v", err, data.ErrNotFound)
+		}
+
+		chats, err := store.Chats(ctx, tx)
+		if err != nil {
+			t.Fatalf("failed to get chats: %s", err)
+		}
+		if len(chats) != 2 {
+			t.Errorf("got %d chats, want 2", len(chats))
+		}
+	})
+}
+
+func testCreateDeleteChat(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	store := newStore(t)
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		err := store.CreateChat(ctx, tx, data.Chat{ID: "chat", Language: "Spanish"})
+		if err != nil {
+			t.Fatalf("failed to create chat: %s", err)
+		}
+		mustCreateMessage(t, store, tx, newMessage("chat", "1", time.Now()))
+	})
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		err := store.CreateChat(ctx, tx, data.Chat{ID: "chat"})
+		if !errors.Is(err, data.ErrAlreadyExists) {
+			t.Errorf("got error %v creating existing chat, want %v", err, data.ErrAlreadyExists)
+		}
+	})
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		err := store.UpdateChat(ctx, tx, data.Chat{ID: "chat", SystemPrompt: "prompt", Language: "French"})
+		if err != nil {
+			t.Fatalf("failed to update chat: %s", err)
+		}
+
+		err = store.UpdateChat(ctx, tx, data.Chat{ID: "unknown"})
+		if !errors.Is(err, data.ErrNotFound) {
+			t.Errorf("got error %v updating missing chat, want %v", err, data.ErrNotFound)
+		}
+	})
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		chat, err := store.Chat(ctx, tx, "chat")
+		if err != nil {
+			t.Fatalf("failed to get chat: %s", err)
+		}
+		if chat.SystemPrompt != "prompt" || chat.Language != "French" {
+			t.Errorf("got chat %+v, want the updated one", chat)
+		}
+
+		err = store.DeleteChat(ctx, tx, "chat")
+		if err != nil {
+			t.Fatalf("failed to delete chat: %s", err)
+		}
+
+		err = store.DeleteChat(ctx, tx, "chat")
+		if !errors.Is(err, data.ErrNotFound) {
+			t.Errorf("got error %v deleting missing chat, want %v", err, data.ErrNotFound)
+		}
+	})
+
+	execTx(t, store, readOnly, func(tx data.Tx) {
+		_, err := store.Chat(ctx, tx, "chat")
+		if !errors.Is(err, data.ErrNotFound) {
+			t.Errorf("got error %v for deleted chat, want %v", err, data.ErrNotFound)
+		}
+
+		messages, err := store.Messages(ctx, tx, "chat")
+		if err != nil {
+			t.Fatalf("failed to get messages: %s", err)
+		}
+		if len(messages) != 0 {
+			t.Errorf("got %d messages of deleted chat, want 0", len(messages))
+		}
+	})
+}
+
+func testMessage(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	chat := data.Chat{ID: "chat"}
+	store := newStore(t, chat, data.Chat{ID: "another-chat"})
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		reply := newMessage(chat.ID, "2", now.Add(2*time.Second))
+		reply.QuotedID = "1"
+		reply.QuotedText = "message 1"
+		reply.Transcribed = true
+		reply.CompletionID = "1"
+		mustCreateMessage(t, store, tx, reply)
+		mustCreateMessage(t, store, tx, newMessage(chat.ID, "1", now.Add(time.Second)))
+		mustCreateMessage(t, store, tx, newMessage("another-chat", "3", now))
+
+		segmentMessage := newMessage(chat.ID, "5", now.Add(3*time.Second))
+		segmentMessage.Segment = 1
+		mustCreateMessage(t, store, tx, segmentMessage)
+	})
+
+	tx := beginTx(t, store, readWrite)
+	err := store.CreateMessage(ctx, tx, newMessage("unknown", "4", now))
+	if err == nil {
+		t.Errorf("created message in unknown chat")
+	}
+	_ = tx.Rollback(ctx)
+
+	execTx(t, store, readOnly, func(tx data.Tx) {
+		messages, err := store.Messages(ctx, tx, chat.ID)
+		if err != nil {
+			t.Fatalf("failed to get messages: %s", err)
+		}
+		if len(messages) != 3 || messages[0].ID != "1" || messages[1].ID != "2" || messages[2].ID != "5" {
+			t.Fatalf("got messages %+v, want messages 1, 2 and 5 ordered by timestamp", messages)
+		}
+		if messages[1].QuotedID != "1" || messages[1].QuotedText != "message 1" {
+			t.Errorf("got quoted message %q (%q), want 1", messages[1].QuotedID, messages[1].QuotedText)
+		}
+		if messages[0].Transcribed || !messages[1].Transcribed {
+			t.Errorf("got transcribed flags %t and %t, want false and true", messages[0].Transcribed, messages[1].Transcribed)
+		}
+		if messages[0].CompletionID != "" || messages[1].CompletionID != "1" {
+			t.Errorf("got completion IDs %q and %q, want none and 1", messages[0].CompletionID, messages[1].CompletionID)
+		}
+		if messages[0].Conversation != "message 1" ||
+			messages[0].SenderName != "Sender" ||
+			!messages[0].Timestamp.Equal(now.Add(time.Second)) {
+			t.Errorf("got message %+v, want the created one", messages[0])
+		}
+
+		messages, err = store.SegmentMessages(ctx, tx, chat.ID, 0)
+		if err != nil {
+			t.Fatalf("failed to get segment messages: %s", err)
+		}
+		if len(messages) != 2 || messages[0].ID != "1" || messages[1].ID != "2" {
+			t.Errorf("got messages %+v, want messages 1 and 2 of segment 0", messages)
+		}
+
+		messages, err = store.SegmentMessages(ctx, tx, chat.ID, 1)
+		if err != nil {
+			t.Fatalf("failed to get segment messages: %s", err)
+		}
+		if len(messages) != 1 || messages[0].ID != "5" || messages[0].Segment != 1 {
+			t.Errorf("got messages %+v, want message 5 of segment 1", messages)
+		}
+
+		messages, err = store.AllMessagesSince(ctx, tx, now.Add(-time.Minute))
+		if err != nil {
+			t.Fatalf("failed to get all messages: %s", err)
+		}
+		if len(messages) != 4 {
+			t.Errorf("got %d messages, want 4", len(messages))
+		}
+	})
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		err := store.DeleteMessage(ctx, tx, chat.ID, "5")
+		if err != nil {
+			t.Fatalf("failed to delete message: %s", err)
+		}
+
+		err = store.DeleteMessage(ctx, tx, chat.ID, "5")
+		if !errors.Is(err, data.ErrNotFound) {
+			t.Errorf("got error %v for deleted message, want %v", err, data.ErrNotFound)
+		}
+
+		err = store.DeleteMessage(ctx, tx, chat.ID, "3")
+		if !errors.Is(err, data.ErrNotFound) {
+			t.Errorf("got error %v for message of another chat, want %v", err, data.ErrNotFound)
+		}
+	})
+
+	execTx(t, store, readOnly, func(tx data.Tx) {
+		messages, err := store.Messages(ctx, tx, chat.ID)
+		if err != nil {
+			t.Fatalf("failed to get messages: %s", err)
+		}
+		if len(messages) != 2 {
+			t.Errorf("got %d messages after deleting one, want 2", len(messages))
+		}
+	})
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		err := store.DeleteMessages(ctx, tx, chat.ID)
+		if err != nil {
+			t.Fatalf("failed to delete messages: %s", err)
+		}
+	})
+
+	execTx(t, store, readOnly, func(tx data.Tx) {
+		messages, err := store.AllMessagesSince(ctx, tx, now.Add(-time.Minute))
+		if err != nil {
+			t.Fatalf("failed to get all messages: %s", err)
+		}
+		if len(messages) != 1 || messages[0].ChatID != "another-chat" {
+			t.Errorf("got messages %+v, want only the message of another chat", messages)
+		}
+	})
+}
+
+func testSummary(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	chat := data.Chat{ID: "chat"}
+	store := newStore(t, chat)
+
+	now := time.Now().UTC().Truncate(time.Second)
+
+	execTx(t, store, readOnly, func(tx data.Tx) {
+		_, err := store.Summary(ctx, tx, chat.ID)
+		if !errors.Is(err, data.ErrNotFound) {
+			t.Errorf("got error %v for missing summary, want %v", err, data.ErrNotFound)
+		}
+	})
+
+	for i, content := range []string{"first", "second"} {
+		execTx(t, store, readWrite, func(tx data.Tx) {
+			err := store.UpsertSummary(ctx, tx, data.Summary{
+				ChatID:       chat.ID,
+				Content:      content,
+				CoveredUntil: now.Add(time.Duration(i) * time.Second),
+				CreatedAt:    now,
+				UpdatedAt:    now,
+			})
+			if err != nil {
+				t.Fatalf("failed to upsert summary: %s", err)
+			}
+		})
+	}
+
+	execTx(t, store, readOnly, func(tx data.Tx) {
+		summary, err := store.Summary(ctx, tx, chat.ID)
+		if err != nil {
+			t.Fatalf("failed to get summary: %s", err)
+		}
+		if summary.Content != "second" || !summary.CoveredUntil.Equal(now.Add(time.Second)) {
+			t.Errorf("got summary %+v, want the last upserted one", summary)
+		}
+	})
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		err := store.DeleteSummary(ctx, tx, chat.ID)
+		if err != nil {
+			t.Fatalf("failed to delete summary: %s", err)
+		}
+
+		_, err = store.Summary(ctx, tx, chat.ID)
+		if !errors.Is(err, data.ErrNotFound) {
+			t.Errorf("got error %v for deleted summary, want %v", err, data.ErrNotFound)
+		}
+	})
+}
+
+func testMedia(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	chat := data.Chat{ID: "chat"}
+	store := newStore(t, chat, data.Chat{ID: "another-chat"})
+
+	now := time.Now().UTC().Truncate(time.Second)
+	media := data.Media{
+		MessageID: "1",
+		ChatID:    chat.ID,
+		MIMEType:  "image/jpeg",
+		Data:      []byte{0xff, 0xd8, 0xff},
+		CreatedAt: now,
+	}
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		mustCreateMessage(t, store, tx, newMessage(chat.ID, "1", now))
+		mustCreateMessage(t, store, tx, newMessage(chat.ID, "2", now.Add(time.Second)))
+
+		err := store.CreateMedia(ctx, tx, media)
+		if err != nil {
+			t.Fatalf("failed to create media: %s", err)
+		}
+	})
+
+	tx := beginTx(t, store, readWrite)
+	err := store.CreateMedia(ctx, tx, data.Media{MessageID: "unknown", ChatID: chat.ID, MIMEType: "image/jpeg", CreatedAt: now})
+	if err == nil {
+		t.Errorf("created media of unknown message")
+	}
+	_ = tx.Rollback(ctx)
+
+	execTx(t, store, readOnly, func(tx data.Tx) {
+		got, err := store.Media(ctx, tx, chat.ID, []string{"1", "2"})
+		if err != nil {
+			t.Fatalf("failed to get media: %s", err)
+		}
+		if len(got) != 1 ||
+			got[0].MessageID != media.MessageID ||
+			got[0].MIMEType != media.MIMEType ||
+			string(got[0].Data) != string(media.Data) ||
+			!got[0].CreatedAt.Equal(media.CreatedAt) {
+			t.Errorf("got media %+v, want only %+v", got, media)
+		}
+
+		got, err = store.Media(ctx, tx, "another-chat", []string{"1"})
+		if err != nil {
+			t.Fatalf("failed to get media: %s", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("got media %+v of another chat, want none", got)
+		}
+
+		got, err = store.Media(ctx, tx, chat.ID, nil)
+		if err != nil {
+			t.Fatalf("failed to get media: %s", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("got media %+v without message IDs, want none", got)
+		}
+	})
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		err := store.DeleteMessage(ctx, tx, chat.ID, "1")
+		if err != nil {
+			t.Fatalf("failed to delete message: %s", err)
+		}
+
+		got, err := store.Media(ctx, tx, chat.ID, []string{"1"})
+		if err != nil {
+			t.Fatalf("failed to get media: %s", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("got media %+v of deleted message, want none", got)
+		}
+	})
+}
+
+func testDocument(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	chat := data.Chat{ID: "chat"}
+	store := newStore(t, chat)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	documents := []data.Document{
+		{MessageID: "1", ChatID: chat.ID, FileName: "a.pdf", MIMEType: "application/pdf", CreatedAt: now},
+		{MessageID: "2", ChatID: chat.ID, FileName: "b.txt", MIMEType: "text/plain", CreatedAt: now.Add(time.Second)},
+		{MessageID: "3", ChatID: chat.ID, Segment: 1, FileName: "c.md", MIMEType: "text/markdown", CreatedAt: now},
+	}
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		for _, document := range documents {
+			message := newMessage(chat.ID, document.MessageID, document.CreatedAt)
+			message.Segment = document.Segment
+			mustCreateMessage(t, store, tx, message)
+
+			err := store.CreateDocument(ctx, tx, document)
+			if err != nil {
+				t.Fatalf("failed to create document: %s", err)
+			}
+		}
+
+		// Created out of order to check that chunks are ordered by index.
+		for _, chunk := range []data.DocumentChunk{
+			{MessageID: "2", Index: 0, Content: "b0"},
+			{MessageID: "1", Index: 1, Content: "a1"},
+			{MessageID: "1", Index: 0, Content: "a0"},
+			{MessageID: "3", Index: 0, Content: "c0"},
+		} {
+			err := store.CreateDocumentChunk(ctx, tx, chunk)
+			if err != nil {
+				t.Fatalf("failed to create document chunk: %s", err)
+			}
+		}
+	})
+
+	tx := beginTx(t, store, readWrite)
+	err := store.CreateDocumentChunk(ctx, tx, data.DocumentChunk{MessageID: "unknown", Content: "x"})
+	if err == nil {
+		t.Errorf("created chunk of unknown document")
+	}
+	_ = tx.Rollback(ctx)
+
+	execTx(t, store, readOnly, func(tx data.Tx) {
+		got, err := store.Documents(ctx, tx, chat.ID, 0)
+		if err != nil {
+			t.Fatalf("failed to get documents: %s", err)
+		}
+		if len(got) != 2 || got[0].MessageID != "1" || got[1].MessageID != "2" {
+			t.Fatalf("got documents %+v, want documents 1 and 2 of segment 0", got)
+		}
+		if got[0].FileName != "a.pdf" || got[0].MIMEType != "application/pdf" || !got[0].CreatedAt.Equal(now) {
+			t.Errorf("got document %+v, want %+v", got[0], documents[0])
+		}
+
+		chunks, err := store.DocumentChunks(ctx, tx, chat.ID, 0)
+		if err != nil {
+			t.Fatalf("failed to get document chunks: %s", err)
+		}
+		var contents []string
+		for _, chunk := range chunks {
+			contents = append(contents, chunk.Content)
+		}
+		if strings.Join(contents, ",") != "a0,a1,b0" {
+			t.Errorf("got chunks %v, want a0, a1 and b0", contents)
+		}
+	})
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		err := store.DeleteMessages(ctx, tx, chat.ID)
+		if err != nil {
+			t.Fatalf("failed to delete messages: %s", err)
+		}
+
+		got, err := store.Documents(ctx, tx, chat.ID, 1)
+		if err != nil {
+			t.Fatalf("failed to get documents: %s", err)
+		}
+		chunks, err := store.DocumentChunks(ctx, tx, chat.ID, 1)
+		if err != nil {
+			t.Fatalf("failed to get document chunks: %s", err)
+		}
+		if len(got) != 0 || len(chunks) != 0 {
+			t.Errorf("got documents %+v and chunks %+v of deleted messages, want none", got, chunks)
+		}
+	})
+}
+
+func newMessage(chatID, id string, timestamp time.Time) data.Message {
+	return data.Message{
+		ID:           id,
+		ChatID:       chatID,
+		SenderID:     "sender",
+		SenderName:   "Sender",
+		Conversation: "message " + id,
+		Timestamp:    timestamp,
+		CreatedAt:    timestamp,
+	}
+}
+
+func beginTx(t *testing.T, store data.Store, options sql.TxOptions) data.Tx {
+	t.Helper()
+
+	tx, err := store.BeginTx(context.Background(), options)
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %s", err)
+	}
+
+	return tx
+}
+
+// execTx calls fn in a transaction that is committed afterwards, unless fn fails the test.
+func execTx(t *testing.T, store data.Store, options sql.TxOptions, fn func(tx data.Tx)) {
+	t.Helper()
+
+	tx := beginTx(t, store, options)
+	defer func() {
+		_ = tx.Rollback(context.Background())
+	}()
+
+	fn(tx)
+
+	err := tx.Commit(context.Background())
+	if err != nil {
+		t.Fatalf("failed to commit transaction: %s", err)
+	}
+}
+
+func mustCreateMessage(t *testing.T, store data.Store, tx data.Tx, message data.Message) {
+	t.Helper()
+
+	err := store.CreateMessage(context.Background(), tx, message)
+	if err != nil {
+		t.Fatalf("failed to create message: %s", err)
+	}
+}
+
+func testToolCall(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	chat := data.Chat{ID: "chat"}
+	store := newStore(t, chat, data.Chat{ID: "another-chat"})
+
+	now := time.Now().UTC().Truncate(time.Second)
+	toolCall := data.ToolCall{
+		MessageID: "1",
+		Index:     0,
+		ChatID:    chat.ID,
+		CallID:    "call",
+		Name:      "current_time",
+		Arguments: "{}",
+		Result:    "2006-01-02T15:04:05Z",
+		CreatedAt: now,
+	}
+	failedToolCall := data.ToolCall{
+		MessageID: "1",
+		Index:     1,
+		ChatID:    chat.ID,
+		Name:      "unknown",
+		Result:    "unknown tool",
+		Failed:    true,
+		CreatedAt: now,
+	}
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		mustCreateMessage(t, store, tx, newMessage(chat.ID, "1", now))
+		mustCreateMessage(t, store, tx, newMessage(chat.ID, "2", now.Add(time.Second)))
+
+		for _, c := range []data.ToolCall{failedToolCall, toolCall} {
+			err := store.CreateToolCall(ctx, tx, c)
+			if err != nil {
+				t.Fatalf("failed to create tool call: %s", err)
+			}
+		}
+	})
+
+	tx := beginTx(t, store, readWrite)
+	err := store.CreateToolCall(ctx, tx, data.ToolCall{MessageID: "unknown", ChatID: chat.ID, Name: "tool", CreatedAt: now})
+	if err == nil {
+		t.Errorf("created tool call of unknown message")
+	}
+	_ = tx.Rollback(ctx)
+
+	execTx(t, store, readOnly, func(tx data.Tx) {
+		got, err := store.ToolCalls(ctx, tx, chat.ID, []string{"1", "2"})
+		if err != nil {
+			t.Fatalf("failed to get tool calls: %s", err)
+		}
+		if len(got) != 2 ||
+			got[0].Index != 0 || got[0].CallID != toolCall.CallID || got[0].Name != toolCall.Name ||
+			got[0].Arguments != toolCall.Arguments || got[0].Result != toolCall.Result || got[0].Failed ||
+			!got[0].CreatedAt.Equal(toolCall.CreatedAt) ||
+			got[1].Index != 1 || !got[1].Failed {
+			t.Errorf("got tool calls %+v, want %+v and %+v", got, toolCall, failedToolCall)
+		}
+
+		got, err = store.ToolCalls(ctx, tx, "another-chat", []string{"1"})
+		if err != nil {
+			t.Fatalf("failed to get tool calls: %s", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("got tool calls %+v of another chat, want none", got)
+		}
+	})
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		err := store.DeleteMessage(ctx, tx, chat.ID, "1")
+		if err != nil {
+			t.Fatalf("failed to delete message: %s", err)
+		}
+
+		got, err := store.ToolCalls(ctx, tx, chat.ID, []string{"1"})
+		if err != nil {
+			t.Fatalf("failed to get tool calls: %s", err)
+		}
+		if len(got) != 0 {
+			t.Errorf("got tool calls %+v of deleted message, want none", got)
+		}
+	})
+}
+
+func testReminder(t *testing.T, newStore NewStoreFunc) {
+	ctx := context.Background()
+	chat := data.Chat{ID: "chat"}
+	anotherChat := data.Chat{ID: "another-chat"}
+	store := newStore(t, chat, anotherChat)
+
+	now := time.Now().UTC().Truncate(time.Second)
+	newReminder := func(chatID, text string, dueAt time.Time) data.Reminder {
+		return data.Reminder{
+			ChatID:    chatID,
+			ChatJID:   chatID + "@s.whatsapp.net",
+			SenderID:  "sender",
+			Text:      text,
+			DueAt:     dueAt,
+			CreatedAt: now,
+		}
+	}
+	reminders := []data.Reminder{
+		newReminder(chat.ID, "second", now.Add(-time.Minute)),
+		newReminder(chat.ID, "first", now.Add(-time.Hour)),
+		newReminder(chat.ID, "future", now.Add(time.Hour)),
+		newReminder(anotherChat.ID, "another", now.Add(-time.Second)),
+	}
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		for i, reminder := range reminders {
+			id, err := store.CreateReminder(ctx, tx, reminder)
+			if err != nil {
+				t.Fatalf("failed to create reminder: %s", err)
+			}
+			reminders[i].ID = id
+		}
+	})
+
+	execTx(t, store, readOnly, func(tx data.Tx) {
+		got, err := store.Reminders(ctx, tx, chat.ID)
+		if err != nil {
+			t.Fatalf("failed to get reminders: %s", err)
+		}
+		if len(got) != 3 ||
+			got[0].ID != reminders[1].ID || got[1].ID != reminders[0].ID || got[2].ID != reminders[2].ID ||
+			got[0].Text != "first" || got[0].ChatJID != reminders[1].ChatJID || got[0].SenderID != "sender" ||
+			!got[0].DueAt.Equal(reminders[1].DueAt) || !got[0].CreatedAt.Equal(now) {
+			t.Errorf("got reminders %+v, want the ones of the chat ordered by due time", got)
+		}
+	})
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		err := store.DeleteReminder(ctx, tx, anotherChat.ID, reminders[2].ID)
+		if !errors.Is(err, data.ErrNotFound) {
+			t.Errorf("got error %v deleting reminder of another chat, want %v", err, data.ErrNotFound)
+		}
+
+		err = store.DeleteReminder(ctx, tx, chat.ID, reminders[2].ID)
+		if err != nil {
+			t.Fatalf("failed to delete reminder: %s", err)
+		}
+	})
+
+	claim := func(now time.Time, limit int) []data.Reminder {
+		var claimed []data.Reminder
+		execTx(t, store, readWrite, func(tx data.Tx) {
+			var err error
+			claimed, err = store.ClaimReminders(ctx, tx, now, now.Add(time.Minute), limit)
+			if err != nil {
+				t.Fatalf("failed to claim reminders: %s", err)
+			}
+		})
+		return claimed
+	}
+
+	if got := claim(now, 2); len(got) != 2 {
+		t.Errorf("claimed %d reminders with a limit of 2, want 2", len(got))
+	}
+	if got := claim(now, 10); len(got) != 1 || got[0].ID != reminders[3].ID {
+		t.Errorf("claimed reminders %+v, want the only unclaimed due one", got)
+	}
+	if got := claim(now, 10); len(got) != 0 {
+		t.Errorf("claimed reminders %+v twice", got)
+	}
+
+	execTx(t, store, readWrite, func(tx data.Tx) {
+		err := store.MarkReminderSent(ctx, tx, reminders[1].ID, now)
+		if err != nil {
+			t.Fatalf("failed to mark reminder as sent: %s", err)
+		}
+
+		err = store.DeleteReminder(ctx, tx, chat.ID, reminders[1].ID)
+		if !errors.Is(err, data.ErrNotFound) {
+			t.Errorf("got error %v deleting sent reminder, want %v", err, data.ErrNotFound)
+		}
+
+		got, err := store.Reminders(ctx, tx, chat.ID)
+		if err != nil {
+			t.Fatalf("failed to get reminders: %s", err)
+		}
+		if len(got) != 1 || got[0].ID != reminders[0].ID {
+			t.Errorf("got reminders %+v, want the one that wasn't sent", got)
+		}
+
+		err = store.DeleteChat(ctx, tx, anotherChat.ID)
+		if err != nil {
+			t.Fatalf("failed to delete chat: %s", err)
+		}
+	})
+
+	// Once the claims expire, the reminders that weren't sent can be claimed again.
+	if got := claim(now.Add(2*time.Minute), 10); len(got) != 1 || got[0].ID != reminders[0].ID {
+		t.Errorf("claimed reminders %+v after the claims expired, want the unsent one of the remaining chat", got)
+	}
+}
@@ -0,0 +1,21 @@
+package data
+
+import "time"
+
+// Document is a file sent to a chat whose text is used to answer questions about it. Its ID is the
+// one of the message it was sent in.
+type Document struct {
+	MessageID string
+	ChatID    string
+	Segment   int // Conversation segment of the message.
+	FileName  string
+	MIMEType  string
+	CreatedAt time.Time
+}
+
+// DocumentChunk is a fragment of the text of a document, small enough to be part of a prompt.
+type DocumentChunk struct {
+	MessageID string // ID of the message of the document.
+	Index     int    // Position of the chunk in the document, starting at zero.
+	Content   string
+}
@@ -0,0 +1,12 @@
+package data
+
+import "time"
+
+// Media is the file attached to a message, e.g. the image of an image message.
+type Media struct {
+	MessageID string
+	ChatID    string
+	MIMEType  string
+	Data      []byte
+	CreatedAt time.Time
+}
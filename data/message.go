@@ -6,7 +6,13 @@ type Message struct {
 	ID           string
 	ChatID       string
 	SenderID     string
+	SenderName   string // Push name of the sender, if known.
 	Conversation string
+	QuotedID     string // ID of the message that this one replies to, if any.
+	QuotedText   string // Text of the quoted message when it was replied to, if any.
+	Transcribed  bool   // Whether Conversation was transcribed from a voice message.
+	CompletionID string // ID of the first message of the response of the chatbot that this one is part of.
+	Segment      int    // Conversation segment of the chat that the message belongs to.
 	Timestamp    time.Time
 	CreatedAt    time.Time
 }
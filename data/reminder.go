@@ -0,0 +1,14 @@
+package data
+
+import "time"
+
+// Reminder is a message that the chatbot sends to a chat at a given time, at the request of a user.
+type Reminder struct {
+	ID        int64 // Assigned by the data store.
+	ChatID    string
+	ChatJID   string // Full WhatsApp JID of the chat, which the reminder is sent to.
+	SenderID  string // User who asked for the reminder.
+	Text      string
+	DueAt     time.Time
+	CreatedAt time.Time
+}
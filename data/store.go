@@ -7,14 +7,58 @@ import (
 	"time"
 )
 
-var ErrNotFound = errors.New("resource not found")
+var (
+	ErrNotFound      = errors.New("resource not found")
+	ErrAlreadyExists = errors.New("resource already exists")
+)
 
 type Store interface {
 	BeginTx(ctx context.Context, options sql.TxOptions) (Tx, error)
 
 	Chat(ctx context.Context, tx Tx, chatID string) (Chat, error)
+	Chats(ctx context.Context, tx Tx) ([]Chat, error)
+	CreateChat(ctx context.Context, tx Tx, chat Chat) error
+	UpdateChat(ctx context.Context, tx Tx, chat Chat) error
+	DeleteChat(ctx context.Context, tx Tx, chatID string) error
 
 	AllMessagesSince(ctx context.Context, tx Tx, t time.Time) ([]Message, error)
 	Messages(ctx context.Context, tx Tx, chatID string) ([]Message, error)
+	SegmentMessages(ctx context.Context, tx Tx, chatID string, segment int) ([]Message, error)
 	CreateMessage(ctx context.Context, tx Tx, message Message) error
+	DeleteMessage(ctx context.Context, tx Tx, chatID, messageID string) error
+	DeleteMessages(ctx context.Context, tx Tx, chatID string) error
+
+	// Media returns the media of the given messages of a chat, skipping the messages without media.
+	Media(ctx context.Context, tx Tx, chatID string, messageIDs []string) ([]Media, error)
+	CreateMedia(ctx context.Context, tx Tx, media Media) error
+
+	Documents(ctx context.Context, tx Tx, chatID string, segment int) ([]Document, error)
+	CreateDocument(ctx context.Context, tx Tx, document Document) error
+
+	// DocumentChunks returns the chunks of the documents of a conversation segment, ordered by
+	// document and index.
+	DocumentChunks(ctx context.Context, tx Tx, chatID string, segment int) ([]DocumentChunk, error)
+	CreateDocumentChunk(ctx context.Context, tx Tx, chunk DocumentChunk) error
+
+	// ToolCalls returns the tool calls made while responding the given messages of a chat, in the
+	// order they were made.
+	ToolCalls(ctx context.Context, tx Tx, chatID string, messageIDs []string) ([]ToolCall, error)
+	CreateToolCall(ctx context.Context, tx Tx, toolCall ToolCall) error
+
+	// Reminders returns the reminders of a chat that haven't been sent, ordered by due time.
+	Reminders(ctx context.Context, tx Tx, chatID string) ([]Reminder, error)
+	// CreateReminder creates a reminder and returns its ID, ignoring the one of reminder.
+	CreateReminder(ctx context.Context, tx Tx, reminder Reminder) (int64, error)
+	// DeleteReminder deletes a reminder of a chat that hasn't been sent.
+	DeleteReminder(ctx context.Context, tx Tx, chatID string, reminderID int64) error
+	// ClaimReminders claims up to limit reminders that are due at now, haven't been sent and aren't
+	// claimed, until the given time. A reminder can only be claimed once until then, even by
+	// concurrent transactions, which allows several instances of the chatbot to send them.
+	ClaimReminders(ctx context.Context, tx Tx, now, until time.Time, limit int) ([]Reminder, error)
+	// MarkReminderSent marks a reminder as sent, so it's never claimed again.
+	MarkReminderSent(ctx context.Context, tx Tx, reminderID int64, sentAt time.Time) error
+
+	Summary(ctx context.Context, tx Tx, chatID string) (Summary, error)
+	UpsertSummary(ctx context.Context, tx Tx, summary Summary) error
+	DeleteSummary(ctx context.Context, tx Tx, chatID string) error
 }
@@ -0,0 +1,13 @@
+package data
+
+import "time"
+
+// Summary is a summary of the oldest messages of a chat. It covers all the messages of the chat with
+// a timestamp up to and including CoveredUntil.
+type Summary struct {
+	ChatID       string
+	Content      string
+	CoveredUntil time.Time
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
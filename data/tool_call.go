@@ -0,0 +1,17 @@
+package data
+
+import "time"
+
+// ToolCall is a call of the model to a tool while responding a message, kept with its result for
+// auditing.
+type ToolCall struct {
+	MessageID string // Message being responded.
+	Index     int    // Order of the call among the ones made while responding the message.
+	ChatID    string
+	CallID    string // Assigned by the model.
+	Name      string
+	Arguments string // JSON object.
+	Result    string
+	Failed    bool // Whether Result is the error of the tool.
+	CreatedAt time.Time
+}
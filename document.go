@@ -0,0 +1,300 @@
+package chatbot
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/ledongthuc/pdf"
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// errUnsupportedDocument is returned when the text of a document can't be extracted because of its
+// type, e.g. a spreadsheet.
+var errUnsupportedDocument = errors.New("unsupported document type")
+
+const (
+	// documentChunkSize is the maximum number of characters of the chunks that documents are split
+	// into, which is about 250 tokens.
+	documentChunkSize = 1000
+
+	// maxDocumentExcerpts is the maximum number of chunks of documents that are part of a prompt.
+	maxDocumentExcerpts = 4
+
+	// BM25 parameters, with their usual values.
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// downloadDocument downloads the document of msg and splits its text into the chunks of its content.
+// Documents that are larger than the maximum size, whose type is not supported or whose text can't
+// be extracted, e.g. because they are corrupted, are skipped, keeping only their caption.
+func (c *Chat) downloadDocument(msg *message) error {
+	document := msg.content.document
+	if document == nil {
+		return nil
+	}
+
+	logger := c.logger.With(
+		zap.String("message_id", msg.Info.ID),
+		zap.String("file_name", document.GetFileName()),
+		zap.String("mime_type", document.GetMimetype()),
+	)
+
+	if c.client.maxDocumentSize > 0 && document.GetFileLength() > uint64(c.client.maxDocumentSize) {
+		logger.Info("skipped document larger than the maximum size", zap.Uint64("size", document.GetFileLength()))
+		return nil
+	}
+
+	documentData, err := c.client.whatsmeowClient.Download(document)
+	if err != nil {
+		return fmt.Errorf("failed to download document: %w", err)
+	}
+
+	text, err := extractDocumentText(documentData, document.GetMimetype(), document.GetFileName())
+	if errors.Is(err, errUnsupportedDocument) {
+		logger.Info("skipped document of unsupported type")
+		return nil
+	}
+	if err != nil {
+		logger.Warn("skipped document whose text can't be extracted", zap.Error(err))
+		return nil
+	}
+
+	msg.content.documentChunks = chunkText(text, documentChunkSize)
+
+	return nil
+}
+
+// extractDocumentText returns the text of a PDF or plain text document, whose type is detected from
+// its MIME type or, if that's not specific enough, the extension of its file name.
+func extractDocumentText(documentData []byte, mimeType, fileName string) (string, error) {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+
+	switch strings.TrimSpace(mimeType) {
+	case "application/pdf":
+		return extractPDFText(documentData)
+	case "text/plain", "text/markdown", "text/x-markdown", "text/csv":
+		return extractPlainText(documentData)
+	}
+
+	switch strings.ToLower(path.Ext(fileName)) {
+	case ".pdf":
+		return extractPDFText(documentData)
+	case ".txt", ".md", ".markdown", ".csv":
+		return extractPlainText(documentData)
+	}
+
+	return "", errUnsupportedDocument
+}
+
+func extractPDFText(documentData []byte) (string, error) {
+	reader, err := pdf.NewReader(bytes.NewReader(documentData), int64(len(documentData)))
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF: %w", err)
+	}
+
+	textReader, err := reader.GetPlainText()
+	if err != nil {
+		return "", fmt.Errorf("failed to get PDF text: %w", err)
+	}
+
+	text, err := io.ReadAll(textReader)
+	if err != nil {
+		return "", fmt.Errorf("failed to read PDF text: %w", err)
+	}
+
+	return string(text), nil
+}
+
+func extractPlainText(documentData []byte) (string, error) {
+	documentData = bytes.TrimPrefix(documentData, []byte("\xef\xbb\xbf")) // UTF-8 byte order mark.
+	if !utf8.Valid(documentData) {
+		return "", fmt.Errorf("%w: text is not valid UTF-8", errUnsupportedDocument)
+	}
+
+	return string(documentData), nil
+}
+
+// chunkText splits text into chunks of at most size characters, preferably between paragraphs, then
+// between lines and then between words.
+func chunkText(text string, size int) []string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+
+	return joinPieces(splitText(text, size, []string{"\n\n", "\n", " "}), size)
+}
+
+// splitText splits text by the first separator into pieces of at most size characters, splitting the
+// larger ones by the next separators. The separators are kept at the end of the pieces.
+func splitText(text string, size int, separators []string) []string {
+	if utf8.RuneCountInString(text) <= size {
+		return []string{text}
+	}
+
+	if len(separators) == 0 {
+		runes := []rune(text)
+
+		var pieces []string
+		for len(runes) > size {
+			pieces = append(pieces, string(runes[:size]))
+			runes = runes[size:]
+		}
+
+		return append(pieces, string(runes))
+	}
+
+	var pieces []string
+	for _, piece := range strings.SplitAfter(text, separators[0]) {
+		pieces = append(pieces, splitText(piece, size, separators[1:])...)
+	}
+
+	return pieces
+}
+
+// joinPieces joins consecutive pieces into chunks of at most size characters, trimming their spaces.
+func joinPieces(pieces []string, size int) []string {
+	var chunks []string
+	var current strings.Builder
+	for _, piece := range pieces {
+		if current.Len() > 0 && utf8.RuneCountInString(current.String())+utf8.RuneCountInString(piece) > size {
+			chunks = appendChunk(chunks, current.String())
+			current.Reset()
+		}
+		current.WriteString(piece)
+	}
+
+	return appendChunk(chunks, current.String())
+}
+
+func appendChunk(chunks []string, chunk string) []string {
+	chunk = strings.TrimSpace(chunk)
+	if chunk == "" {
+		return chunks
+	}
+
+	return append(chunks, chunk)
+}
+
+// rankChunks returns the n chunks that are most relevant to query according to BM25, in the order of
+// the documents. Chunks that don't contain any term of query are never returned.
+func rankChunks(query string, chunks []data.DocumentChunk, n int) []data.DocumentChunk {
+	queryTerms := tokenize(query)
+	if len(queryTerms) == 0 || len(chunks) == 0 {
+		return nil
+	}
+
+	chunkTerms := make([]map[string]int, len(chunks))
+	documentFrequencies := make(map[string]int)
+	var totalLength int
+	for i, chunk := range chunks {
+		terms := tokenize(chunk.Content)
+		totalLength += len(terms)
+
+		chunkTerms[i] = make(map[string]int)
+		for _, term := range terms {
+			chunkTerms[i][term]++
+		}
+		for term := range chunkTerms[i] {
+			documentFrequencies[term]++
+		}
+	}
+	averageLength := float64(totalLength) / float64(len(chunks))
+
+	type scoredChunk struct {
+		index int
+		score float64
+	}
+	var scored []scoredChunk
+	for i := range chunks {
+		var length int
+		for _, count := range chunkTerms[i] {
+			length += count
+		}
+
+		var score float64
+		for _, term := range queryTerms {
+			frequency := float64(chunkTerms[i][term])
+			if frequency == 0 {
+				continue
+			}
+
+			df := float64(documentFrequencies[term])
+			idf := math.Log(1 + (float64(len(chunks))-df+0.5)/(df+0.5))
+			score += idf * frequency * (bm25K1 + 1) /
+				(frequency + bm25K1*(1-bm25B+bm25B*float64(length)/averageLength))
+		}
+		if score > 0 {
+			scored = append(scored, scoredChunk{index: i, score: score})
+		}
+	}
+
+	sort.SliceStable(scored, func(i, j int) bool {
+		return scored[i].score > scored[j].score
+	})
+	if len(scored) > n {
+		scored = scored[:n]
+	}
+	sort.Slice(scored, func(i, j int) bool {
+		return scored[i].index < scored[j].index
+	})
+
+	ranked := make([]data.DocumentChunk, 0, len(scored))
+	for _, s := range scored {
+		ranked = append(ranked, chunks[s.index])
+	}
+
+	return ranked
+}
+
+// tokenize splits text into lowercase words, skipping the ones of a single character.
+func tokenize(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	terms := words[:0]
+	for _, word := range words {
+		if utf8.RuneCountInString(word) > 1 {
+			terms = append(terms, word)
+		}
+	}
+
+	return terms
+}
+
+// documentExcerpts returns a system message with the chunks of the documents of the chat that are the
+// most relevant to query. If none is, but trigger is the message of a document, the message has the
+// beginning of that document instead. It returns false if there are no excerpts.
+func documentExcerpts(h history, query, triggerID string) (CompletionMessage, bool) {
+	excerpts := rankChunks(query, h.documentChunks, maxDocumentExcerpts)
+	if len(excerpts) == 0 {
+		for _, chunk := range h.documentChunks {
+			if chunk.MessageID == triggerID && len(excerpts) < maxDocumentExcerpts {
+				excerpts = append(excerpts, chunk)
+			}
+		}
+	}
+	if len(excerpts) == 0 {
+		return CompletionMessage{}, false
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Excerpts of the documents sent in this chat, which may help to answer:")
+	for _, excerpt := range excerpts {
+		fmt.Fprintf(&sb, "\n\n[%s, part %d]\n%s", h.documents[excerpt.MessageID].FileName, excerpt.Index+1, excerpt.Content)
+	}
+
+	return CompletionMessage{
+		Role:    RoleSystem,
+		Content: sb.String(),
+	}, true
+}
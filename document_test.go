@@ -0,0 +1,268 @@
+package chatbot
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func readTestdata(t *testing.T, name string) []byte {
+	t.Helper()
+
+	b, err := os.ReadFile(filepath.Join("testdata", name))
+	if err != nil {
+		t.Fatalf("failed to read test data: %s", err)
+	}
+
+	return b
+}
+
+func TestExtractDocumentText(t *testing.T) {
+	tests := []struct {
+		name     string
+		data     []byte
+		mimeType string
+		fileName string
+		want     string
+		wantErr  error
+	}{
+		{
+			name:     "PDF",
+			data:     readTestdata(t, "invoice.pdf"),
+			mimeType: "application/pdf",
+			want:     "The invoice total is 42 euros.Payment is due in March.",
+		},
+		{
+			name:     "PDF by extension",
+			data:     readTestdata(t, "invoice.pdf"),
+			mimeType: "application/octet-stream",
+			fileName: "Invoice.PDF",
+			want:     "The invoice total is 42 euros.Payment is due in March.",
+		},
+		{
+			name:     "plain text",
+			data:     readTestdata(t, "notes.txt"),
+			mimeType: "text/plain; charset=utf-8",
+			want:     string(readTestdata(t, "notes.txt")),
+		},
+		{
+			name:     "CSV with byte order mark",
+			data:     readTestdata(t, "people.csv"),
+			mimeType: "text/csv",
+			want:     "name,city\r\nAlice,Madrid\r\nBob,Lisbon\r\n",
+		},
+		{
+			name:     "unsupported type",
+			data:     []byte("PK\x03\x04"),
+			mimeType: "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet",
+			fileName: "sheet.xlsx",
+			wantErr:  errUnsupportedDocument,
+		},
+		{
+			name:     "invalid UTF-8",
+			data:     []byte("\xff\xfe\x00"),
+			mimeType: "text/plain",
+			wantErr:  errUnsupportedDocument,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			text, err := extractDocumentText(tt.data, tt.mimeType, tt.fileName)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if strings.TrimSpace(text) != strings.TrimSpace(tt.want) {
+				t.Errorf("got text %q, want %q", text, tt.want)
+			}
+		})
+	}
+}
+
+func TestExtractDocumentTextCorruptedPDF(t *testing.T) {
+	pdf := readTestdata(t, "invoice.pdf")
+
+	_, err := extractDocumentText(pdf[:len(pdf)/2], "application/pdf", "")
+	if err == nil || errors.Is(err, errUnsupportedDocument) {
+		t.Errorf("got error %v, want a failure to read the PDF", err)
+	}
+}
+
+func TestChunkText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		size int
+		want []string
+	}{
+		{
+			name: "short text",
+			text: "  Hello, world.\n",
+			size: 100,
+			want: []string{"Hello, world."},
+		},
+		{
+			name: "paragraphs",
+			text: "First paragraph.\n\nSecond paragraph.\n\nThird.",
+			size: 30,
+			want: []string{"First paragraph.", "Second paragraph.\n\nThird."},
+		},
+		{
+			name: "lines",
+			text: "One line\r\nAnother line\r\nLast line",
+			size: 20,
+			want: []string{"One line", "Another line", "Last line"},
+		},
+		{
+			name: "words",
+			text: "alpha beta gamma delta",
+			size: 11,
+			want: []string{"alpha beta", "gamma delta"},
+		},
+		{
+			name: "long word",
+			text: "abcdefghij",
+			size: 4,
+			want: []string{"abcd", "efgh", "ij"},
+		},
+		{
+			name: "multibyte characters",
+			text: "ñandú ñandú",
+			size: 5,
+			want: []string{"ñandú", "ñandú"},
+		},
+		{
+			name: "empty",
+			text: " \n\n ",
+			size: 10,
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := chunkText(tt.text, tt.size)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got chunks %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChunkTextSize(t *testing.T) {
+	text := strings.Repeat(string(readTestdata(t, "notes.txt")), 50)
+
+	chunks := chunkText(text, documentChunkSize)
+	if len(chunks) < 2 {
+		t.Fatalf("got %d chunks, want several", len(chunks))
+	}
+	for i, chunk := range chunks {
+		if n := utf8.RuneCountInString(chunk); n > documentChunkSize {
+			t.Errorf("chunk %d has %d characters, want at most %d", i, n, documentChunkSize)
+		}
+	}
+}
+
+func TestRankChunks(t *testing.T) {
+	chunks := []data.DocumentChunk{
+		{MessageID: "1", Index: 0, Content: "The invoice total is 42 euros."},
+		{MessageID: "1", Index: 1, Content: "Payment is due in March, by bank transfer."},
+		{MessageID: "2", Index: 0, Content: "The launch was moved to March. The launch party is in April."},
+		{MessageID: "2", Index: 1, Content: "Action items: update the roadmap."},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		n     int
+		want  []string // Contents of the returned chunks.
+	}{
+		{
+			name:  "single match",
+			query: "Invoice total?",
+			n:     4,
+			want:  []string{chunks[0].Content},
+		},
+		{
+			name:  "in the order of the documents",
+			query: "launch march",
+			n:     4,
+			want:  []string{chunks[1].Content, chunks[2].Content},
+		},
+		{
+			name:  "most relevant first",
+			query: "launch march",
+			n:     1,
+			want:  []string{chunks[2].Content},
+		},
+		{
+			name:  "case insensitive",
+			query: "ROADMAP",
+			n:     4,
+			want:  []string{chunks[3].Content},
+		},
+		{
+			name:  "no match",
+			query: "weather tomorrow",
+			n:     4,
+			want:  nil,
+		},
+		{
+			name:  "only short words",
+			query: "a b c",
+			n:     4,
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, chunk := range rankChunks(tt.query, chunks, tt.n) {
+				got = append(got, chunk.Content)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got chunks %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatHandleCorruptedDocument(t *testing.T) {
+	pdf := readTestdata(t, "invoice.pdf")
+	whatsApp := &fakeWhatsApp{downloads: map[string][]byte{"/invoice": pdf[:len(pdf)/2]}}
+	completer := &fakeCompleter{content: "I can't read it."}
+	chat, dataStore := newTestChat(t, whatsApp, completer)
+
+	msg := newTestMessage("1", &waProto.Message{
+		DocumentMessage: &waProto.DocumentMessage{
+			Caption:    proto.String("What's the total?"),
+			Mimetype:   proto.String("application/pdf"),
+			FileName:   proto.String("invoice.pdf"),
+			DirectPath: proto.String("/invoice"),
+		},
+	})
+	err := chat.handleMessage(msg)
+	if err != nil {
+		t.Fatalf("failed to handle message: %s", err)
+	}
+
+	sent := whatsApp.sentTexts()
+	if len(sent) != 1 || sent[0] != "I can't read it." {
+		t.Errorf("sent %q, want the response to the caption", sent)
+	}
+
+	messages := storedMessages(t, dataStore)
+	if len(messages) != 2 || messages[0].Conversation != "What's the total?" {
+		t.Errorf("got stored messages %+v, want the caption and the response", messages)
+	}
+}
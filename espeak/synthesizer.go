@@ -0,0 +1,71 @@
+// Package espeak implements chatbot.Synthesizer with the eSpeak NG speech synthesizer, so that voice
+// replies can be synthesized locally. It runs the espeak-ng and ffmpeg commands, which must be
+// installed, the latter to encode the audio with Opus as WhatsApp voice messages.
+package espeak
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os/exec"
+	"strings"
+
+	"github.com/happybydefault/chatbot"
+)
+
+// Synthesizer is a chatbot.Synthesizer backed by eSpeak NG. The voices of the requests are eSpeak NG
+// voices, e.g. "en-us" or "es", and their models are ignored.
+type Synthesizer struct{}
+
+func NewSynthesizer() *Synthesizer {
+	return &Synthesizer{}
+}
+
+func (s *Synthesizer) Synthesize(ctx context.Context, request chatbot.SpeechRequest) (chatbot.SpeechResponse, error) {
+	espeakArgs := []string{"--stdout"}
+	if request.Voice != "" {
+		espeakArgs = append(espeakArgs, "-v", request.Voice)
+	}
+
+	wav, err := run(ctx, strings.NewReader(request.Text), "espeak-ng", espeakArgs...)
+	if err != nil {
+		return chatbot.SpeechResponse{}, fmt.Errorf("failed to synthesize speech: %w", err)
+	}
+
+	audio, err := run(
+		ctx,
+		bytes.NewReader(wav),
+		"ffmpeg",
+		"-hide_banner", "-loglevel", "error",
+		"-f", "wav", "-i", "pipe:0",
+		"-ac", "1", "-ar", "48000",
+		"-c:a", "libopus", "-b:a", "32k", "-application", "voip",
+		"-f", "ogg", "pipe:1",
+	)
+	if err != nil {
+		return chatbot.SpeechResponse{}, fmt.Errorf("failed to encode audio: %w", err)
+	}
+
+	return chatbot.SpeechResponse{
+		Audio: audio,
+	}, nil
+}
+
+// run runs a command with the given standard input and returns its standard output, or an error with
+// its standard error if it fails.
+func run(ctx context.Context, stdin io.Reader, name string, args ...string) ([]byte, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdin = stdin
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+
+	err := cmd.Run()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w: %s", name, err, strings.TrimSpace(stderr.String()))
+	}
+
+	return stdout.Bytes(), nil
+}
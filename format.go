@@ -0,0 +1,194 @@
+package chatbot
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// The Markdown syntax that formatMarkdown converts. Inline code, links and URLs are replaced by
+// placeholders before the rest of the inline syntax is converted, so that they are kept intact.
+var (
+	markdownHeading       = regexp.MustCompile(`^\s{0,3}#{1,6}\s+(.*?)(?:\s+#+)?\s*$`)
+	markdownRule          = regexp.MustCompile(`^\s{0,3}(?:(?:\*\s*){3,}|(?:-\s*){3,}|(?:_\s*){3,})$`)
+	markdownBullet        = regexp.MustCompile(`^(\s*)[*+]\s+`)
+	markdownTableRule     = regexp.MustCompile(`^\s*\|?\s*:?-+:?\s*(?:\|\s*:?-+:?\s*)*\|?\s*$`)
+	markdownLink          = regexp.MustCompile(`!?\[([^\]]*)\]\(\s*<?([^\s)>]+)>?(?:\s+"[^"]*")?\s*\)`)
+	markdownAutolink      = regexp.MustCompile(`<((?:https?|mailto):[^>\s]+)>`)
+	markdownURL           = regexp.MustCompile(`(?:https?://|www\.)[^\s<>()]+[^\s<>().,;:!?'"]`)
+	markdownBoldItalic    = regexp.MustCompile(`\*\*\*(\S(?:.*?\S)?)\*\*\*`)
+	markdownBold          = regexp.MustCompile(`\*\*(\S(?:.*?\S)?)\*\*|__(\S(?:.*?\S)?)__`)
+	markdownItalic        = regexp.MustCompile(`\*(\S(?:[^*]*?\S)?)\*`)
+	markdownStrikethrough = regexp.MustCompile(`~~(\S(?:.*?\S)?)~~`)
+	markdownPlaceholder   = regexp.MustCompile("\x00([0-9]+)\x00")
+)
+
+// boldMarker stands for the asterisks of bold text while the italic text is converted, which uses
+// asterisks in Markdown too.
+const boldMarker = "\x01"
+
+// formatMarkdown converts the Markdown of the responses of the model to the formatting of WhatsApp:
+// bold, italic, strikethrough and monospace text, with headings in bold, links as their text
+// followed by their URL, and tables as lists of their rows. URLs and code are kept as they are.
+func formatMarkdown(text string) string {
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	formatted := make([]string, 0, len(lines))
+	var inCode bool
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		trimmed := strings.TrimSpace(line)
+
+		if strings.HasPrefix(trimmed, codeFence) && strings.Count(trimmed, codeFence) == 1 {
+			// WhatsApp doesn't highlight code, so the language of the opening fences is dropped.
+			inCode = !inCode
+			formatted = append(formatted, codeFence)
+			continue
+		}
+		if inCode {
+			formatted = append(formatted, line)
+			continue
+		}
+
+		if strings.Contains(line, "|") && i+1 < len(lines) && markdownTableRule.MatchString(lines[i+1]) &&
+			strings.Contains(lines[i+1], "-") {
+			header := tableCells(line)
+			i += 2
+			for ; i < len(lines) && strings.Contains(lines[i], "|"); i++ {
+				formatted = append(formatted, tableRow(header, tableCells(lines[i])))
+			}
+			i--
+			continue
+		}
+
+		switch {
+		case markdownRule.MatchString(line), trimmed == "":
+			// Rules become blank lines, which are not repeated.
+			if len(formatted) > 0 && formatted[len(formatted)-1] != "" {
+				formatted = append(formatted, "")
+			}
+		case markdownHeading.MatchString(line):
+			heading := markdownHeading.FindStringSubmatch(line)[1]
+			heading = strings.ReplaceAll(formatInline(heading, boldMarker), boldMarker, "")
+			formatted = append(formatted, "*"+heading+"*")
+		default:
+			line = markdownBullet.ReplaceAllString(line, "$1- ")
+			formatted = append(formatted, strings.ReplaceAll(formatInline(line, boldMarker), boldMarker, "*"))
+		}
+	}
+
+	return strings.Join(formatted, "\n")
+}
+
+// formatInline converts the inline Markdown of line, writing bold text between the given markers.
+func formatInline(line, bold string) string {
+	var protected []string
+	protect := func(s string) string {
+		protected = append(protected, s)
+		return fmt.Sprintf("\x00%d\x00", len(protected)-1)
+	}
+
+	line = replaceInlineCode(line, func(code string) string {
+		return protect(codeFence + code + codeFence)
+	})
+	line = markdownLink.ReplaceAllStringFunc(line, func(s string) string {
+		match := markdownLink.FindStringSubmatch(s)
+		linkText, url := strings.TrimSpace(match[1]), strings.TrimPrefix(match[2], "mailto:")
+		if linkText == "" || linkText == url {
+			return protect(url)
+		}
+		return linkText + " (" + protect(url) + ")"
+	})
+	line = markdownAutolink.ReplaceAllStringFunc(line, func(s string) string {
+		return protect(markdownAutolink.FindStringSubmatch(s)[1])
+	})
+	line = markdownURL.ReplaceAllStringFunc(line, protect)
+
+	line = markdownBoldItalic.ReplaceAllString(line, bold+"_${1}_"+bold)
+	line = markdownBold.ReplaceAllString(line, bold+"${1}${2}"+bold)
+	line = markdownItalic.ReplaceAllString(line, "_${1}_")
+	line = markdownStrikethrough.ReplaceAllString(line, "~${1}~")
+
+	return markdownPlaceholder.ReplaceAllStringFunc(line, func(s string) string {
+		i, _ := strconv.Atoi(markdownPlaceholder.FindStringSubmatch(s)[1])
+		return protected[i]
+	})
+}
+
+// replaceInlineCode replaces the inline code spans of line with the result of replace for their
+// content. A span starts with a run of backticks and ends with the next run of the same length, so
+// it can contain shorter runs of backticks. Unmatched backticks are left as they are.
+func replaceInlineCode(line string, replace func(code string) string) string {
+	var sb strings.Builder
+	for i := 0; i < len(line); {
+		if line[i] != '`' {
+			sb.WriteByte(line[i])
+			i++
+			continue
+		}
+
+		n := backtickRun(line[i:])
+		end := -1
+		for j := i + n; j < len(line); {
+			if line[j] != '`' {
+				j++
+				continue
+			}
+
+			m := backtickRun(line[j:])
+			if m == n {
+				end = j
+				break
+			}
+			j += m
+		}
+
+		if end < 0 || strings.TrimSpace(line[i+n:end]) == "" {
+			sb.WriteString(line[i : i+n])
+			i += n
+			continue
+		}
+
+		sb.WriteString(replace(strings.TrimSpace(line[i+n : end])))
+		i = end + n
+	}
+
+	return sb.String()
+}
+
+// backtickRun returns the number of backticks at the start of s.
+func backtickRun(s string) int {
+	return len(s) - len(strings.TrimLeft(s, "`"))
+}
+
+// tableCells returns the cells of a row of a Markdown table.
+func tableCells(row string) []string {
+	row = strings.TrimSpace(row)
+	row = strings.TrimPrefix(row, "|")
+	row = strings.TrimSuffix(row, "|")
+
+	cells := strings.Split(row, "|")
+	for i, cell := range cells {
+		cells[i] = strings.TrimSpace(cell)
+	}
+
+	return cells
+}
+
+// tableRow returns a row of a table as an item of a list, with the value of every cell preceded by
+// its header, skipping the empty cells.
+func tableRow(header, cells []string) string {
+	values := make([]string, 0, len(cells))
+	for i, cell := range cells {
+		if cell == "" {
+			continue
+		}
+		if i < len(header) && header[i] != "" {
+			cell = header[i] + ": " + cell
+		}
+		values = append(values, cell)
+	}
+
+	return "- " + strings.ReplaceAll(formatInline(strings.Join(values, ", "), boldMarker), boldMarker, "*")
+}
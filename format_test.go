@@ -0,0 +1,51 @@
+package chatbot
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "update the golden files of the tests")
+
+// TestFormatMarkdown checks that formatMarkdown converts every testdata/markdown/*.md file to its
+// .golden file. The golden files are rewritten with go test -update.
+func TestFormatMarkdown(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "markdown", "*.md"))
+	if err != nil {
+		t.Fatalf("failed to list test data: %s", err)
+	}
+	if len(paths) == 0 {
+		t.Fatal("no test data")
+	}
+
+	for _, path := range paths {
+		name := strings.TrimSuffix(filepath.Base(path), ".md")
+		t.Run(name, func(t *testing.T) {
+			markdown, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("failed to read Markdown: %s", err)
+			}
+
+			got := formatMarkdown(string(markdown))
+
+			goldenPath := strings.TrimSuffix(path, ".md") + ".golden"
+			if *update {
+				err := os.WriteFile(goldenPath, []byte(got), 0o644)
+				if err != nil {
+					t.Fatalf("failed to update golden file: %s", err)
+				}
+			}
+
+			want, err := os.ReadFile(goldenPath)
+			if err != nil {
+				t.Fatalf("failed to read golden file: %s", err)
+			}
+			if got != string(want) {
+				t.Errorf("got:\n%s\n\nwant:\n%s", got, want)
+			}
+		})
+	}
+}
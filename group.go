@@ -0,0 +1,51 @@
+package chatbot
+
+import (
+	"strings"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// isTriggered reports whether the chatbot should respond to msg. In group chats, it only responds to
+// the messages that mention it or reply to one of its messages, but the rest are still stored so
+// that they are part of the prompts.
+func (c *Client) isTriggered(msg message) bool {
+	if !msg.Info.IsGroup {
+		return true
+	}
+
+	botID := c.botID()
+
+	for _, mentioned := range msg.content.mentionedJIDs {
+		if jidUser(mentioned) == botID {
+			return true
+		}
+	}
+
+	return msg.content.quotedID != "" && jidUser(msg.content.quotedParticipant) == botID
+}
+
+// speakerContent returns the content of a message of a group chat in a prompt, prefixed by the name
+// of its sender so that the model can tell the participants apart. Mentions of the chatbot, which
+// WhatsApp writes as "@<phone number>", are replaced by its name.
+func (c *Client) speakerContent(msg data.Message) string {
+	name := msg.SenderName
+	if name == "" {
+		name = msg.SenderID
+	}
+
+	content := strings.ReplaceAll(msg.Conversation, "@"+c.botID(), "@"+c.botName)
+
+	return name + ": " + content
+}
+
+func jidUser(s string) string {
+	jid, err := types.ParseJID(s)
+	if err != nil {
+		return ""
+	}
+
+	return jid.User
+}
@@ -0,0 +1,145 @@
+package chatbot
+
+import (
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func TestClientIsTriggered(t *testing.T) {
+	const (
+		botJID   = testBotID + "@s.whatsapp.net"
+		otherJID = "15559876543@s.whatsapp.net"
+	)
+
+	tests := []struct {
+		name    string
+		group   bool
+		message *waProto.Message
+		want    bool
+	}{
+		{
+			name:    "direct chat",
+			message: &waProto.Message{Conversation: proto.String("Hi")},
+			want:    true,
+		},
+		{
+			name:    "unrelated group message",
+			group:   true,
+			message: &waProto.Message{Conversation: proto.String("Hi everyone")},
+		},
+		{
+			name:  "mentioned",
+			group: true,
+			message: &waProto.Message{
+				ExtendedTextMessage: &waProto.ExtendedTextMessage{
+					Text:        proto.String("@" + testBotID + " hi"),
+					ContextInfo: &waProto.ContextInfo{MentionedJid: []string{otherJID, botJID}},
+				},
+			},
+			want: true,
+		},
+		{
+			name:  "other participant mentioned",
+			group: true,
+			message: &waProto.Message{
+				ExtendedTextMessage: &waProto.ExtendedTextMessage{
+					Text:        proto.String("@15559876543 hi"),
+					ContextInfo: &waProto.ContextInfo{MentionedJid: []string{otherJID}},
+				},
+			},
+		},
+		{
+			name:  "reply to chatbot",
+			group: true,
+			message: &waProto.Message{
+				ExtendedTextMessage: &waProto.ExtendedTextMessage{
+					Text: proto.String("Why?"),
+					ContextInfo: &waProto.ContextInfo{
+						StanzaId:      proto.String("response"),
+						Participant:   proto.String(botJID),
+						QuotedMessage: &waProto.Message{Conversation: proto.String("Because.")},
+					},
+				},
+			},
+			want: true,
+		},
+		{
+			name:  "reply to other participant",
+			group: true,
+			message: &waProto.Message{
+				ExtendedTextMessage: &waProto.ExtendedTextMessage{
+					Text: proto.String("Why?"),
+					ContextInfo: &waProto.ContextInfo{
+						StanzaId:      proto.String("message"),
+						Participant:   proto.String(otherJID),
+						QuotedMessage: &waProto.Message{Conversation: proto.String("Because.")},
+					},
+				},
+			},
+		},
+		{
+			name:  "mentioned in image caption",
+			group: true,
+			message: &waProto.Message{
+				ImageMessage: &waProto.ImageMessage{
+					Caption:     proto.String("@" + testBotID + " what is this?"),
+					ContextInfo: &waProto.ContextInfo{MentionedJid: []string{botJID}},
+				},
+			},
+			want: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, _ := newTestChat(t, &fakeWhatsApp{}, &fakeCompleter{})
+
+			msg := newTestMessage("1", tt.message)
+			msg.Info.IsGroup = tt.group
+
+			got := chat.client.isTriggered(msg)
+			if got != tt.want {
+				t.Errorf("got %t, want %t", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClientSpeakerContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		message data.Message
+		want    string
+	}{
+		{
+			name:    "sender name",
+			message: data.Message{SenderID: testUserID, SenderName: "User", Conversation: "Hi"},
+			want:    "User: Hi",
+		},
+		{
+			name:    "sender without name",
+			message: data.Message{SenderID: testUserID, Conversation: "Hi"},
+			want:    testUserID + ": Hi",
+		},
+		{
+			name:    "mention of chatbot",
+			message: data.Message{SenderID: testUserID, SenderName: "User", Conversation: "@" + testBotID + " hi"},
+			want:    "User: @Chatbot hi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			chat, _ := newTestChat(t, &fakeWhatsApp{}, &fakeCompleter{})
+
+			got := chat.client.speakerContent(tt.message)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
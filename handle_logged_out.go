@@ -14,7 +14,7 @@ func (c *Client) handleLoggedOutEvent(loggedOut *events.LoggedOut) error {
 	}
 
 	if loggedOut.Reason.IsLoggedOut() {
-		err := c.whatsmeowClient.Store.Delete()
+		err := c.device.Delete()
 		if err != nil {
 			return fmt.Errorf("failed to delete store: %w", err)
 		}
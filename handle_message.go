@@ -1,26 +1,27 @@
 package chatbot
 
 import (
+	"go.mau.fi/whatsmeow/types"
 	"go.mau.fi/whatsmeow/types/events"
 )
 
 func (c *Client) handleMessageEvent(msg *events.Message) {
-	chatID := msg.Info.Chat.User
-	chat := c.getChat(chatID)
+	chat := c.getChat(msg.Info.Chat)
 
 	chat.messagesChan <- message{
-		clientState: c.state,
 		Message:     msg,
+		content:     parseMessageContent(msg.Message),
+		clientState: c.state,
 	}
 }
 
-func (c *Client) getChat(chatID string) *Chat {
+func (c *Client) getChat(jid types.JID) *Chat {
 	c.mu.Lock()
 
-	chat, ok := c.chats[chatID]
+	chat, ok := c.chats[jid.User]
 	if !ok {
-		chat = c.newChat(chatID)
-		c.chats[chatID] = chat
+		chat = c.newChat(jid)
+		c.chats[jid.User] = chat
 
 		go chat.handleMessages()
 	}
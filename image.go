@@ -0,0 +1,102 @@
+package chatbot
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	_ "image/gif" // Registers the GIF decoder for image.Decode.
+	"image/jpeg"
+	_ "image/png" // Registers the PNG decoder for image.Decode.
+
+	"go.uber.org/zap"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp" // Registers the WebP decoder for image.Decode.
+)
+
+// imageFallbackResponse is the response to images when the model of the chat can't see them.
+const imageFallbackResponse = "Sorry, I can't see images. Could you describe it with text instead?"
+
+func (c *Client) supportsVision(model string) bool {
+	_, ok := c.visionModels[model]
+	return ok
+}
+
+// downloadImage downloads the image of msg into its content, downscaled to the maximum resolution.
+// Images that are larger than the maximum size, that fail to download or that can't be decoded,
+// e.g. because of their format, are skipped, keeping only their caption.
+func (c *Chat) downloadImage(msg *message) {
+	imageMessage := msg.Message.Message.GetImageMessage()
+	if imageMessage == nil {
+		return
+	}
+
+	logger := c.logger.With(
+		zap.String("message_id", msg.Info.ID),
+		zap.String("mime_type", imageMessage.GetMimetype()),
+	)
+
+	if c.client.maxImageSize > 0 && imageMessage.GetFileLength() > uint64(c.client.maxImageSize) {
+		logger.Info("skipped image larger than the maximum size", zap.Uint64("size", imageMessage.GetFileLength()))
+		return
+	}
+
+	imageData, err := c.client.whatsmeowClient.Download(imageMessage)
+	if err != nil {
+		logger.Warn("skipped image that failed to download", zap.Error(err))
+		return
+	}
+
+	imageData, mimeType, err := limitImageResolution(imageData, imageMessage.GetMimetype(), c.client.maxImageResolution)
+	if err != nil {
+		logger.Warn("skipped image that can't be downscaled", zap.Error(err))
+		return
+	}
+
+	msg.content.image = &CompletionImage{
+		MIMEType: mimeType,
+		Data:     imageData,
+	}
+}
+
+// limitImageResolution downscales an image whose longest side is larger than maxResolution pixels,
+// re-encoding it as JPEG. Smaller images are returned as they are.
+func limitImageResolution(imageData []byte, mimeType string, maxResolution int) ([]byte, string, error) {
+	if maxResolution <= 0 {
+		return imageData, mimeType, nil
+	}
+
+	config, _, err := image.DecodeConfig(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image config: %w", err)
+	}
+	if config.Width <= maxResolution && config.Height <= maxResolution {
+		return imageData, mimeType, nil
+	}
+
+	src, _, err := image.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	width, height := maxResolution, config.Height*maxResolution/config.Width
+	if config.Height > config.Width {
+		width, height = config.Width*maxResolution/config.Height, maxResolution
+	}
+	if width == 0 {
+		width = 1
+	}
+	if height == 0 {
+		height = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), draw.Src, nil)
+
+	var buf bytes.Buffer
+	err = jpeg.Encode(&buf, dst, &jpeg.Options{Quality: 85})
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	return buf.Bytes(), "image/jpeg", nil
+}
@@ -0,0 +1,140 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+type ImageRequest struct {
+	Model  string // Ignored by backends that serve a single model.
+	Prompt string
+}
+
+type ImageResponse struct {
+	MIMEType string
+	Data     []byte
+}
+
+// ImageGenerator is implemented by the backends that generate the images of the imagine command.
+type ImageGenerator interface {
+	GenerateImage(ctx context.Context, request ImageRequest) (ImageResponse, error)
+}
+
+// generatedImagePrefix precedes the prompts of the generated images in the prompts of completions,
+// since the assistant messages can't carry images.
+const generatedImagePrefix = "(Generated image) "
+
+// runImagineCommand generates an image from the prompt in args and sends it to the chat, quoting the
+// command when the quote mode applies. The image is stored as a message of the chatbot, with the
+// prompt as its text.
+func (c *Chat) runImagineCommand(ctx context.Context, msg message, args string) (string, error) {
+	if c.client.imageGenerator == nil {
+		return "Image generation is not enabled.", nil
+	}
+
+	prompt := strings.TrimSpace(args)
+	if prompt == "" {
+		return "", fmt.Errorf("%w: missing prompt", errInvalidArgs)
+	}
+
+	err := c.client.whatsmeowClient.SendChatPresence(c.jid, types.ChatPresenceComposing, "")
+	if err != nil {
+		return "", fmt.Errorf("failed to send chat composing presence: %w", err)
+	}
+
+	imageResponse, err := c.client.imageGenerator.GenerateImage(ctx, ImageRequest{
+		Model:  c.client.imageModel,
+		Prompt: prompt,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to generate image: %w", err)
+	}
+	metricGeneratedImages.Add(1)
+
+	uploadResponse, err := c.client.whatsmeowClient.Upload(ctx, imageResponse.Data, whatsmeow.MediaImage)
+	if err != nil {
+		return "", fmt.Errorf("failed to upload image: %w", err)
+	}
+
+	imageMessage := &waProto.ImageMessage{
+		Caption:       proto.String(prompt),
+		Url:           proto.String(uploadResponse.URL),
+		DirectPath:    proto.String(uploadResponse.DirectPath),
+		MediaKey:      uploadResponse.MediaKey,
+		Mimetype:      proto.String(imageResponse.MIMEType),
+		FileEncSha256: uploadResponse.FileEncSHA256,
+		FileSha256:    uploadResponse.FileSHA256,
+		FileLength:    proto.Uint64(uploadResponse.FileLength),
+	}
+
+	var quotedID, quotedText string
+	if c.client.quoteMode.quotes(msg.Message) {
+		imageMessage.ContextInfo = quoteContextInfo(msg.Message)
+		quotedID = msg.Info.ID
+		quotedText = msg.content.text
+	}
+
+	report, err := c.client.whatsmeowClient.SendMessage(ctx, c.jid, "", &waProto.Message{
+		ImageMessage: imageMessage,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to send message: %w", err)
+	}
+	c.logger.Debug("sent generated image", zap.String("sent_message_id", report.ID))
+
+	err = c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		chat, err := c.client.store.Chat(ctx, tx, c.id)
+		if err != nil {
+			return fmt.Errorf("failed to get chat from data store: %w", err)
+		}
+
+		err = c.client.store.CreateMessage(ctx, tx, data.Message{
+			ID:           report.ID,
+			ChatID:       c.id,
+			SenderID:     c.client.botID(),
+			SenderName:   c.client.botName,
+			Conversation: prompt,
+			QuotedID:     quotedID,
+			QuotedText:   quotedText,
+			Segment:      chat.Segment,
+			Timestamp:    report.Timestamp,
+			CreatedAt:    time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create message from chatbot in data store: %w", err)
+		}
+
+		err = c.client.store.CreateMedia(ctx, tx, data.Media{
+			MessageID: report.ID,
+			ChatID:    c.id,
+			MIMEType:  imageResponse.MIMEType,
+			Data:      imageResponse.Data,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create media in data store: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	// The image is the reply.
+	return "", nil
+}
@@ -0,0 +1,154 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"sync"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeImageGenerator is an ImageGenerator that records the requests to it and returns the same image
+// for all of them, or fails with err if it's not nil.
+type fakeImageGenerator struct {
+	err error
+
+	mu       sync.Mutex
+	requests []ImageRequest
+}
+
+func (f *fakeImageGenerator) GenerateImage(ctx context.Context, request ImageRequest) (ImageResponse, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.requests = append(f.requests, request)
+	if f.err != nil {
+		return ImageResponse{}, f.err
+	}
+
+	return ImageResponse{MIMEType: "image/png", Data: []byte("image data")}, nil
+}
+
+func TestChatRunImagineCommand(t *testing.T) {
+	ctx := context.Background()
+	whatsApp := &fakeWhatsApp{}
+	generator := &fakeImageGenerator{}
+	completer := &fakeCompleter{content: "It's a cat."}
+	chat, dataStore := newTestChat(t, whatsApp, completer)
+	chat.client.commandPrefix = "/"
+	chat.client.imageGenerator = generator
+	chat.client.imageModel = "image-model"
+	chat.client.quoteMode = QuoteAlways
+
+	msg := newTestMessage("command", &waProto.Message{Conversation: proto.String("/imagine  a cat in space ")})
+	err := chat.handleMessage(msg)
+	if err != nil {
+		t.Fatalf("failed to handle message: %s", err)
+	}
+
+	if len(generator.requests) != 1 || generator.requests[0] != (ImageRequest{Model: "image-model", Prompt: "a cat in space"}) {
+		t.Errorf("got image requests %+v, want one with the model and the trimmed prompt", generator.requests)
+	}
+
+	whatsApp.mu.Lock()
+	sent := whatsApp.sent
+	whatsApp.mu.Unlock()
+	if len(sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(sent))
+	}
+	image := sent[0].GetImageMessage()
+	if image.GetCaption() != "a cat in space" || image.GetMimetype() != "image/png" ||
+		image.GetDirectPath() != "/uploaded" || image.GetContextInfo().GetStanzaId() != "command" {
+		t.Errorf("sent %v, want the uploaded image replying to the command", sent[0])
+	}
+
+	// The command isn't stored, but the image is, as a message of the chatbot with its media.
+	messages := storedMessages(t, dataStore)
+	if len(messages) != 1 {
+		t.Fatalf("got %d stored messages, want 1", len(messages))
+	}
+	stored := messages[0]
+	if stored.ID != "sent-1" || stored.SenderID != testBotID || stored.Conversation != "a cat in space" ||
+		stored.QuotedID != "command" || stored.QuotedText != "/imagine  a cat in space " {
+		t.Errorf("got stored message %+v, want the image", stored)
+	}
+
+	tx, err := dataStore.BeginTx(ctx, sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %s", err)
+	}
+	media, err := dataStore.Media(ctx, tx, testUserID, []string{"sent-1"})
+	_ = tx.Rollback(ctx)
+	if err != nil {
+		t.Fatalf("failed to get media: %s", err)
+	}
+	if len(media) != 1 || media[0].MIMEType != "image/png" || string(media[0].Data) != "image data" {
+		t.Errorf("got media %+v, want the generated image", media)
+	}
+
+	// The model sees the prompt of the image in place of it.
+	msg = newTestMessage("question", &waProto.Message{Conversation: proto.String("What is it?")})
+	err = chat.handleMessage(msg)
+	if err != nil {
+		t.Fatalf("failed to handle message: %s", err)
+	}
+	if len(completer.requests) != 1 {
+		t.Fatalf("got %d completion requests, want 1", len(completer.requests))
+	}
+	request := completer.requests[0]
+	if m := request.Messages[1]; m.Role != RoleAssistant || m.Content != generatedImagePrefix+"a cat in space" {
+		t.Errorf("got completion message %+v, want the prompt of the image", m)
+	}
+}
+
+func TestChatRunImagineCommandReplies(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		generator ImageGenerator
+		want      string
+	}{
+		{
+			name: "not enabled",
+			text: "/imagine a cat",
+			want: "Image generation is not enabled.",
+		},
+		{
+			name:      "missing prompt",
+			text:      "/imagine ",
+			generator: &fakeImageGenerator{},
+			want:      "Failed to run /imagine: invalid arguments: missing prompt.",
+		},
+		{
+			name:      "failed generation",
+			text:      "/imagine a cat",
+			generator: &fakeImageGenerator{err: errors.New("content policy violation")},
+			want:      "Failed to run /imagine. Please try again later.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whatsApp := &fakeWhatsApp{}
+			chat, dataStore := newTestChat(t, whatsApp, &fakeCompleter{})
+			chat.client.commandPrefix = "/"
+			chat.client.imageGenerator = tt.generator
+
+			msg := newTestMessage("command", &waProto.Message{Conversation: proto.String(tt.text)})
+			err := chat.handleMessage(msg)
+			if err != nil {
+				t.Fatalf("failed to handle message: %s", err)
+			}
+
+			if sent := whatsApp.sentTexts(); len(sent) != 1 || sent[0] != tt.want {
+				t.Errorf("sent %q, want %q", sent, tt.want)
+			}
+			if messages := storedMessages(t, dataStore); len(messages) != 0 {
+				t.Errorf("got stored messages %+v, want none", messages)
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package migration
+
+import (
+	"reflect"
+	"testing"
+	"testing/fstest"
+)
+
+func TestLoad(t *testing.T) {
+	tests := []struct {
+		name    string
+		files   []string
+		want    []Migration
+		wantErr bool
+	}{
+		{
+			name: "sorted by version",
+			files: []string{
+				"0002_summaries.down.sql",
+				"0002_summaries.up.sql",
+				"0001_initial.up.sql",
+				"0001_initial.down.sql",
+			},
+			want: []Migration{
+				{Version: 1, Name: "initial", Up: "0001_initial.up.sql", Down: "0001_initial.down.sql"},
+				{Version: 2, Name: "summaries", Up: "0002_summaries.up.sql", Down: "0002_summaries.down.sql"},
+			},
+		},
+		{
+			name:  "empty",
+			files: nil,
+			want:  []Migration{},
+		},
+		{
+			name:    "missing down",
+			files:   []string{"0001_initial.up.sql"},
+			wantErr: true,
+		},
+		{
+			name:    "gap between versions",
+			files:   []string{"0001_initial.up.sql", "0001_initial.down.sql", "0003_next.up.sql", "0003_next.down.sql"},
+			wantErr: true,
+		},
+		{
+			name:    "not starting at 1",
+			files:   []string{"0000_initial.up.sql", "0000_initial.down.sql"},
+			wantErr: true,
+		},
+		{
+			name:    "without name",
+			files:   []string{"0001.up.sql", "0001.down.sql"},
+			wantErr: true,
+		},
+		{
+			name:    "without direction",
+			files:   []string{"0001_initial.sql"},
+			wantErr: true,
+		},
+		{
+			name:    "invalid version",
+			files:   []string{"first_initial.up.sql", "first_initial.down.sql"},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The content of every file is its name.
+			fsys := make(fstest.MapFS, len(tt.files))
+			for _, file := range tt.files {
+				fsys[file] = &fstest.MapFile{Data: []byte(file)}
+			}
+
+			got, err := Load(fsys)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got migrations %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
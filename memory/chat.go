@@ -0,0 +1,90 @@
+package memory
+
+import (
+	"context"
+	"sort"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Chat(ctx context.Context, tx data.Tx, chatID string) (data.Chat, error) {
+	t, err := s.tx(tx, false)
+	if err != nil {
+		return data.Chat{}, err
+	}
+
+	chat, ok := t.tables.chats[chatID]
+	if !ok {
+		return data.Chat{}, data.ErrNotFound
+	}
+
+	return chat, nil
+}
+
+func (s *Store) Chats(ctx context.Context, tx data.Tx) ([]data.Chat, error) {
+	t, err := s.tx(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var chats []data.Chat
+	for _, chat := range t.tables.chats {
+		chats = append(chats, chat)
+	}
+	sort.Slice(chats, func(i, j int) bool {
+		return chats[i].ID < chats[j].ID
+	})
+
+	return chats, nil
+}
+
+func (s *Store) CreateChat(ctx context.Context, tx data.Tx, chat data.Chat) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.tables.chats[chat.ID]; ok {
+		return data.ErrAlreadyExists
+	}
+	t.tables.chats[chat.ID] = chat
+
+	return nil
+}
+
+func (s *Store) UpdateChat(ctx context.Context, tx data.Tx, chat data.Chat) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.tables.chats[chat.ID]; !ok {
+		return data.ErrNotFound
+	}
+	t.tables.chats[chat.ID] = chat
+
+	return nil
+}
+
+func (s *Store) DeleteChat(ctx context.Context, tx data.Tx, chatID string) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.tables.chats[chatID]; !ok {
+		return data.ErrNotFound
+	}
+	delete(t.tables.chats, chatID)
+
+	// Cascade like the foreign keys of the SQL stores.
+	t.tables.deleteMessages(chatID)
+	delete(t.tables.summaries, chatID)
+	for id, reminder := range t.tables.reminders {
+		if reminder.ChatID == chatID {
+			delete(t.tables.reminders, id)
+		}
+	}
+
+	return nil
+}
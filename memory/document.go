@@ -0,0 +1,76 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Documents(ctx context.Context, tx data.Tx, chatID string, segment int) ([]data.Document, error) {
+	t, err := s.tx(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return t.tables.segmentDocuments(chatID, segment), nil
+}
+
+func (s *Store) CreateDocument(ctx context.Context, tx data.Tx, document data.Document) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	if !t.tables.hasMessage(document.MessageID) {
+		return fmt.Errorf("message %q does not exist", document.MessageID)
+	}
+	if _, ok := t.tables.documents[document.MessageID]; ok {
+		return fmt.Errorf("document of message %q already exists", document.MessageID)
+	}
+	t.tables.documents[document.MessageID] = document
+
+	return nil
+}
+
+func (s *Store) DocumentChunks(ctx context.Context, tx data.Tx, chatID string, segment int) ([]data.DocumentChunk, error) {
+	t, err := s.tx(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var chunks []data.DocumentChunk
+	for _, document := range t.tables.segmentDocuments(chatID, segment) {
+		chunks = append(chunks, t.tables.documentChunks[document.MessageID]...)
+	}
+
+	return chunks, nil
+}
+
+func (s *Store) CreateDocumentChunk(ctx context.Context, tx data.Tx, chunk data.DocumentChunk) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.tables.documents[chunk.MessageID]; !ok {
+		return fmt.Errorf("document of message %q does not exist", chunk.MessageID)
+	}
+
+	chunks := t.tables.documentChunks[chunk.MessageID]
+	for _, c := range chunks {
+		if c.Index == chunk.Index {
+			return fmt.Errorf("chunk %d of document %q already exists", chunk.Index, chunk.MessageID)
+		}
+	}
+
+	// The slice is copied so that the clones of the tables don't share its backing array.
+	chunks = append(chunks[:len(chunks):len(chunks)], chunk)
+	sort.Slice(chunks, func(i, j int) bool {
+		return chunks[i].Index < chunks[j].Index
+	})
+	t.tables.documentChunks[chunk.MessageID] = chunks
+
+	return nil
+}
@@ -0,0 +1,45 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Media(ctx context.Context, tx data.Tx, chatID string, messageIDs []string) ([]data.Media, error) {
+	t, err := s.tx(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var media []data.Media
+	for _, id := range messageIDs {
+		m, ok := t.tables.media[id]
+		if ok && m.ChatID == chatID {
+			media = append(media, m)
+		}
+	}
+
+	return media, nil
+}
+
+func (s *Store) CreateMedia(ctx context.Context, tx data.Tx, media data.Media) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	if !t.tables.hasMessage(media.MessageID) {
+		return fmt.Errorf("message %q does not exist", media.MessageID)
+	}
+	if _, ok := t.tables.media[media.MessageID]; ok {
+		return fmt.Errorf("media of message %q already exists", media.MessageID)
+	}
+
+	// The data is never modified, so clones of the tables can share it, but not with the caller.
+	media.Data = append([]byte(nil), media.Data...)
+	t.tables.media[media.MessageID] = media
+
+	return nil
+}
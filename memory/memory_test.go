@@ -0,0 +1,15 @@
+package memory_test
+
+import (
+	"testing"
+
+	"github.com/happybydefault/chatbot/data"
+	"github.com/happybydefault/chatbot/data/datatest"
+	"github.com/happybydefault/chatbot/memory"
+)
+
+func TestStore(t *testing.T) {
+	datatest.TestStore(t, func(t *testing.T, chats ...data.Chat) data.Store {
+		return memory.NewStore(chats...)
+	})
+}
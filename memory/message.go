@@ -0,0 +1,109 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) CreateMessage(ctx context.Context, tx data.Tx, message data.Message) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.tables.chats[message.ChatID]; !ok {
+		return fmt.Errorf("chat %q does not exist", message.ChatID)
+	}
+	if t.tables.hasMessage(message.ID) {
+		return fmt.Errorf("message %q already exists", message.ID)
+	}
+
+	i := sort.Search(len(t.tables.messages), func(i int) bool {
+		return t.tables.messages[i].Timestamp.After(message.Timestamp)
+	})
+	t.tables.messages = append(t.tables.messages, data.Message{})
+	copy(t.tables.messages[i+1:], t.tables.messages[i:])
+	t.tables.messages[i] = message
+
+	return nil
+}
+
+func (s *Store) DeleteMessage(ctx context.Context, tx data.Tx, chatID, messageID string) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	for i, msg := range t.tables.messages {
+		if msg.ChatID == chatID && msg.ID == messageID {
+			t.tables.messages = append(t.tables.messages[:i], t.tables.messages[i+1:]...)
+			t.tables.deleteAttachments(messageID)
+			return nil
+		}
+	}
+
+	return data.ErrNotFound
+}
+
+func (s *Store) DeleteMessages(ctx context.Context, tx data.Tx, chatID string) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	t.tables.deleteMessages(chatID)
+
+	return nil
+}
+
+func (s *Store) Messages(ctx context.Context, tx data.Tx, chatID string) ([]data.Message, error) {
+	t, err := s.tx(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []data.Message
+	for _, msg := range t.tables.messages {
+		if msg.ChatID == chatID {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages, nil
+}
+
+func (s *Store) SegmentMessages(ctx context.Context, tx data.Tx, chatID string, segment int) ([]data.Message, error) {
+	t, err := s.tx(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []data.Message
+	for _, msg := range t.tables.messages {
+		if msg.ChatID == chatID && msg.Segment == segment {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages, nil
+}
+
+func (s *Store) AllMessagesSince(ctx context.Context, tx data.Tx, since time.Time) ([]data.Message, error) {
+	t, err := s.tx(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var messages []data.Message
+	for _, msg := range t.tables.messages {
+		if !msg.CreatedAt.Before(since) {
+			messages = append(messages, msg)
+		}
+	}
+
+	return messages, nil
+}
@@ -0,0 +1,112 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Reminders(ctx context.Context, tx data.Tx, chatID string) ([]data.Reminder, error) {
+	t, err := s.tx(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []data.Reminder
+	for _, reminder := range t.tables.reminders {
+		if reminder.ChatID == chatID && !reminder.sent {
+			reminders = append(reminders, reminder.Reminder)
+		}
+	}
+	sortReminders(reminders)
+
+	return reminders, nil
+}
+
+func (s *Store) CreateReminder(ctx context.Context, tx data.Tx, r data.Reminder) (int64, error) {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, ok := t.tables.chats[r.ChatID]; !ok {
+		return 0, fmt.Errorf("chat %q does not exist", r.ChatID)
+	}
+
+	t.tables.lastReminderID++
+	r.ID = t.tables.lastReminderID
+	t.tables.reminders[r.ID] = reminder{Reminder: r}
+
+	return r.ID, nil
+}
+
+func (s *Store) DeleteReminder(ctx context.Context, tx data.Tx, chatID string, reminderID int64) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	reminder, ok := t.tables.reminders[reminderID]
+	if !ok || reminder.ChatID != chatID || reminder.sent {
+		return data.ErrNotFound
+	}
+	delete(t.tables.reminders, reminderID)
+
+	return nil
+}
+
+// ClaimReminders claims the due reminders. Read-write transactions are serialized, so they are never
+// claimed twice.
+func (s *Store) ClaimReminders(ctx context.Context, tx data.Tx, now, until time.Time, limit int) ([]data.Reminder, error) {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return nil, err
+	}
+
+	var reminders []data.Reminder
+	for _, reminder := range t.tables.reminders {
+		if !reminder.DueAt.After(now) && !reminder.sent && !reminder.claimedUntil.After(now) {
+			reminders = append(reminders, reminder.Reminder)
+		}
+	}
+	sortReminders(reminders)
+	if len(reminders) > limit {
+		reminders = reminders[:limit]
+	}
+
+	for _, r := range reminders {
+		reminder := t.tables.reminders[r.ID]
+		reminder.claimedUntil = until
+		t.tables.reminders[r.ID] = reminder
+	}
+
+	return reminders, nil
+}
+
+func (s *Store) MarkReminderSent(ctx context.Context, tx data.Tx, reminderID int64, sentAt time.Time) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	reminder, ok := t.tables.reminders[reminderID]
+	if !ok {
+		return data.ErrNotFound
+	}
+	reminder.sent = true
+	t.tables.reminders[reminderID] = reminder
+
+	return nil
+}
+
+func sortReminders(reminders []data.Reminder) {
+	sort.Slice(reminders, func(i, j int) bool {
+		if !reminders[i].DueAt.Equal(reminders[j].DueAt) {
+			return reminders[i].DueAt.Before(reminders[j].DueAt)
+		}
+		return reminders[i].ID < reminders[j].ID
+	})
+}
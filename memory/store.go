@@ -0,0 +1,84 @@
+// Package memory implements data.Store in memory, for tests and ephemeral runs.
+package memory
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+var (
+	errReadOnly     = errors.New("cannot write in a read-only transaction")
+	errForeignStore = errors.New("transaction was not begun by this store")
+)
+
+// Store is a data.Store that keeps everything in memory.
+//
+// Read-only transactions work on a snapshot of the data taken when they begin. Read-write transactions
+// are serialized: only one can be in progress at a time, and its changes replace the data of the store
+// when it's committed.
+type Store struct {
+	writeSem chan struct{}
+
+	mu     sync.RWMutex
+	tables *tables
+}
+
+// NewStore constructs a Store that contains the given chats.
+func NewStore(chats ...data.Chat) *Store {
+	t := newTables()
+	for _, chat := range chats {
+		t.chats[chat.ID] = chat
+	}
+
+	return &Store{
+		writeSem: make(chan struct{}, 1),
+		tables:   t,
+	}
+}
+
+func (s *Store) BeginTx(ctx context.Context, options sql.TxOptions) (data.Tx, error) {
+	if !options.ReadOnly {
+		select {
+		case s.writeSem <- struct{}{}:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("failed to wait for the current read-write transaction: %w", ctx.Err())
+		}
+	}
+
+	s.mu.RLock()
+	t := s.tables.clone()
+	s.mu.RUnlock()
+
+	return &Tx{
+		store:    s,
+		tables:   t,
+		readOnly: options.ReadOnly,
+	}, nil
+}
+
+func (s *Store) Close() {}
+
+// tx returns the Tx of this store that tx is, or an error if it's done or, if write is true, read-only.
+func (s *Store) tx(tx data.Tx, write bool) (*Tx, error) {
+	t, ok := tx.(*Tx)
+	if !ok || t.store != s {
+		return nil, errForeignStore
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return nil, data.ErrTxDone
+	}
+	if write && t.readOnly {
+		return nil, errReadOnly
+	}
+
+	return t, nil
+}
@@ -0,0 +1,51 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Summary(ctx context.Context, tx data.Tx, chatID string) (data.Summary, error) {
+	t, err := s.tx(tx, false)
+	if err != nil {
+		return data.Summary{}, err
+	}
+
+	summary, ok := t.tables.summaries[chatID]
+	if !ok {
+		return data.Summary{}, data.ErrNotFound
+	}
+
+	return summary, nil
+}
+
+func (s *Store) UpsertSummary(ctx context.Context, tx data.Tx, summary data.Summary) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	if _, ok := t.tables.chats[summary.ChatID]; !ok {
+		return fmt.Errorf("chat %q does not exist", summary.ChatID)
+	}
+
+	if existing, ok := t.tables.summaries[summary.ChatID]; ok {
+		summary.CreatedAt = existing.CreatedAt
+	}
+	t.tables.summaries[summary.ChatID] = summary
+
+	return nil
+}
+
+func (s *Store) DeleteSummary(ctx context.Context, tx data.Tx, chatID string) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	delete(t.tables.summaries, chatID)
+
+	return nil
+}
@@ -0,0 +1,143 @@
+package memory
+
+import (
+	"sort"
+	"time"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// tables is the data of a Store. Transactions work on their own clone of it.
+type tables struct {
+	chats     map[string]data.Chat
+	messages  []data.Message // Sorted by timestamp.
+	summaries map[string]data.Summary
+	media     map[string]data.Media // By message ID.
+
+	documents      map[string]data.Document        // By message ID.
+	documentChunks map[string][]data.DocumentChunk // By message ID, sorted by index.
+
+	toolCalls map[string][]data.ToolCall // By message ID, sorted by index.
+
+	reminders      map[int64]reminder
+	lastReminderID int64
+}
+
+// reminder is a data.Reminder with the state of its delivery.
+type reminder struct {
+	data.Reminder
+	claimedUntil time.Time
+	sent         bool
+}
+
+func newTables() *tables {
+	return &tables{
+		chats:     make(map[string]data.Chat),
+		summaries: make(map[string]data.Summary),
+		media:     make(map[string]data.Media),
+
+		documents:      make(map[string]data.Document),
+		documentChunks: make(map[string][]data.DocumentChunk),
+
+		toolCalls: make(map[string][]data.ToolCall),
+
+		reminders: make(map[int64]reminder),
+	}
+}
+
+func (t *tables) clone() *tables {
+	c := &tables{
+		chats:     make(map[string]data.Chat, len(t.chats)),
+		messages:  make([]data.Message, len(t.messages)),
+		summaries: make(map[string]data.Summary, len(t.summaries)),
+		media:     make(map[string]data.Media, len(t.media)),
+
+		documents:      make(map[string]data.Document, len(t.documents)),
+		documentChunks: make(map[string][]data.DocumentChunk, len(t.documentChunks)),
+
+		toolCalls: make(map[string][]data.ToolCall, len(t.toolCalls)),
+
+		reminders:      make(map[int64]reminder, len(t.reminders)),
+		lastReminderID: t.lastReminderID,
+	}
+
+	for id, chat := range t.chats {
+		if chat.Temperature != nil {
+			temperature := *chat.Temperature
+			chat.Temperature = &temperature
+		}
+		chat.Tools = append([]string(nil), chat.Tools...)
+		c.chats[id] = chat
+	}
+	copy(c.messages, t.messages)
+	for id, summary := range t.summaries {
+		c.summaries[id] = summary
+	}
+	for id, media := range t.media {
+		c.media[id] = media
+	}
+	for id, document := range t.documents {
+		c.documents[id] = document
+	}
+	// Chunks and tool calls are never modified, only appended to copies of the slices.
+	for id, chunks := range t.documentChunks {
+		c.documentChunks[id] = chunks
+	}
+	for id, toolCalls := range t.toolCalls {
+		c.toolCalls[id] = toolCalls
+	}
+	for id, reminder := range t.reminders {
+		c.reminders[id] = reminder
+	}
+
+	return c
+}
+
+func (t *tables) hasMessage(id string) bool {
+	for _, msg := range t.messages {
+		if msg.ID == id {
+			return true
+		}
+	}
+
+	return false
+}
+
+// deleteMessages deletes the messages of a chat and, like the foreign keys of the SQL stores, their
+// media, documents and tool calls.
+func (t *tables) deleteMessages(chatID string) {
+	messages := t.messages[:0]
+	for _, msg := range t.messages {
+		if msg.ChatID != chatID {
+			messages = append(messages, msg)
+		} else {
+			t.deleteAttachments(msg.ID)
+		}
+	}
+	t.messages = messages
+}
+
+func (t *tables) deleteAttachments(messageID string) {
+	delete(t.media, messageID)
+	delete(t.documents, messageID)
+	delete(t.documentChunks, messageID)
+	delete(t.toolCalls, messageID)
+}
+
+// segmentDocuments returns the documents of a conversation segment ordered by creation time.
+func (t *tables) segmentDocuments(chatID string, segment int) []data.Document {
+	var documents []data.Document
+	for _, document := range t.documents {
+		if document.ChatID == chatID && document.Segment == segment {
+			documents = append(documents, document)
+		}
+	}
+	sort.Slice(documents, func(i, j int) bool {
+		if !documents[i].CreatedAt.Equal(documents[j].CreatedAt) {
+			return documents[i].CreatedAt.Before(documents[j].CreatedAt)
+		}
+		return documents[i].MessageID < documents[j].MessageID
+	})
+
+	return documents
+}
@@ -0,0 +1,60 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) ToolCalls(ctx context.Context, tx data.Tx, chatID string, messageIDs []string) ([]data.ToolCall, error) {
+	t, err := s.tx(tx, false)
+	if err != nil {
+		return nil, err
+	}
+
+	var toolCalls []data.ToolCall
+	for _, id := range messageIDs {
+		for _, toolCall := range t.tables.toolCalls[id] {
+			if toolCall.ChatID == chatID {
+				toolCalls = append(toolCalls, toolCall)
+			}
+		}
+	}
+	sort.SliceStable(toolCalls, func(i, j int) bool {
+		if !toolCalls[i].CreatedAt.Equal(toolCalls[j].CreatedAt) {
+			return toolCalls[i].CreatedAt.Before(toolCalls[j].CreatedAt)
+		}
+		return toolCalls[i].Index < toolCalls[j].Index
+	})
+
+	return toolCalls, nil
+}
+
+func (s *Store) CreateToolCall(ctx context.Context, tx data.Tx, toolCall data.ToolCall) error {
+	t, err := s.tx(tx, true)
+	if err != nil {
+		return err
+	}
+
+	if !t.tables.hasMessage(toolCall.MessageID) {
+		return fmt.Errorf("message %q does not exist", toolCall.MessageID)
+	}
+
+	toolCalls := t.tables.toolCalls[toolCall.MessageID]
+	for _, c := range toolCalls {
+		if c.Index == toolCall.Index {
+			return fmt.Errorf("tool call %d of message %q already exists", toolCall.Index, toolCall.MessageID)
+		}
+	}
+
+	// The slice is copied so that the clones of the tables don't share its backing array.
+	toolCalls = append(toolCalls[:len(toolCalls):len(toolCalls)], toolCall)
+	sort.Slice(toolCalls, func(i, j int) bool {
+		return toolCalls[i].Index < toolCalls[j].Index
+	})
+	t.tables.toolCalls[toolCall.MessageID] = toolCalls
+
+	return nil
+}
@@ -0,0 +1,69 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+var errQueryNotSupported = errors.New("memory store does not support queries")
+
+type Tx struct {
+	store    *Store
+	readOnly bool
+
+	mu     sync.Mutex
+	tables *tables
+	done   bool
+}
+
+func (tx *Tx) Commit(ctx context.Context) error {
+	return tx.finish(true)
+}
+
+func (tx *Tx) Rollback(ctx context.Context) error {
+	return tx.finish(false)
+}
+
+func (tx *Tx) finish(commit bool) error {
+	tx.mu.Lock()
+	defer tx.mu.Unlock()
+
+	if tx.done {
+		return data.ErrTxDone
+	}
+	tx.done = true
+
+	if tx.readOnly {
+		return nil
+	}
+
+	if commit {
+		tx.store.mu.Lock()
+		tx.store.tables = tx.tables
+		tx.store.mu.Unlock()
+	}
+	<-tx.store.writeSem
+
+	return nil
+}
+
+func (tx *Tx) QueryRow(ctx context.Context, query string, args ...interface{}) data.Row {
+	return errRow{}
+}
+
+func (tx *Tx) Query(ctx context.Context, query string, args ...interface{}) (data.Rows, error) {
+	return nil, errQueryNotSupported
+}
+
+func (tx *Tx) Exec(ctx context.Context, query string, args ...interface{}) (data.Result, error) {
+	return nil, errQueryNotSupported
+}
+
+type errRow struct{}
+
+func (errRow) Scan(dest ...any) error {
+	return errQueryNotSupported
+}
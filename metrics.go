@@ -0,0 +1,15 @@
+package chatbot
+
+import "expvar"
+
+// Metrics are published with expvar, so they are served by expvar.Handler.
+var (
+	metricContextTruncations     = expvar.NewInt("chatbot_context_truncations_total")
+	metricContextDroppedMessages = expvar.NewInt("chatbot_context_dropped_messages_total")
+	metricTranscribedSeconds     = expvar.NewInt("chatbot_transcribed_seconds_total")
+	metricGeneratedImages        = expvar.NewInt("chatbot_generated_images_total")
+	metricSynthesizedSeconds     = expvar.NewInt("chatbot_synthesized_seconds_total")
+	metricStreamedEdits          = expvar.NewInt("chatbot_streamed_edits_total")
+	metricToolCalls              = expvar.NewInt("chatbot_tool_calls_total")
+	metricSentReminders          = expvar.NewInt("chatbot_sent_reminders_total")
+)
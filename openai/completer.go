@@ -0,0 +1,279 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+
+	gpt "github.com/sashabaranov/go-openai"
+
+	"github.com/happybydefault/chatbot"
+)
+
+// Config configures the clients of this package. BaseURL may point to any server implementing the
+// OpenAI API, such as llama.cpp server, vLLM or Ollama.
+type Config struct {
+	APIKey  string
+	BaseURL string // Defaults to the OpenAI API if empty.
+}
+
+func newClient(cfg Config) *gpt.Client {
+	clientConfig := gpt.DefaultConfig(cfg.APIKey)
+	if cfg.BaseURL != "" {
+		clientConfig.BaseURL = cfg.BaseURL
+	}
+
+	return gpt.NewClientWithConfig(clientConfig)
+}
+
+// Completer is a chatbot.Completer backed by the OpenAI chat completions API.
+type Completer struct {
+	client *gpt.Client
+}
+
+func NewCompleter(cfg Config) *Completer {
+	return &Completer{
+		client: newClient(cfg),
+	}
+}
+
+func (c *Completer) Complete(ctx context.Context, request chatbot.CompletionRequest) (chatbot.CompletionResponse, error) {
+	completionResponse, err := c.client.CreateChatCompletion(ctx, newCompletionRequest(request))
+	if err != nil {
+		return chatbot.CompletionResponse{}, wrapError(err)
+	}
+
+	if len(completionResponse.Choices) == 0 {
+		return chatbot.CompletionResponse{}, chatbot.ErrEmptyCompletion
+	}
+
+	message := completionResponse.Choices[0].Message
+
+	return chatbot.CompletionResponse{
+		Message: chatbot.CompletionMessage{
+			Role:      chatbot.Role(message.Role),
+			Content:   message.Content,
+			ToolCalls: newToolCalls(message.ToolCalls),
+		},
+	}, nil
+}
+
+func (c *Completer) CompleteStream(ctx context.Context, request chatbot.CompletionRequest, onDelta func(delta string) error) (chatbot.CompletionResponse, error) {
+	completionRequest := newCompletionRequest(request)
+	completionRequest.Stream = true
+
+	stream, err := c.client.CreateChatCompletionStream(ctx, completionRequest)
+	if err != nil {
+		return chatbot.CompletionResponse{}, wrapError(err)
+	}
+	defer stream.Close()
+
+	var choices bool
+	role := chatbot.RoleAssistant
+	var content strings.Builder
+	var toolCalls []gpt.ToolCall
+	for {
+		streamResponse, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return chatbot.CompletionResponse{}, wrapError(err)
+		}
+
+		if len(streamResponse.Choices) == 0 {
+			continue
+		}
+		choices = true
+
+		delta := streamResponse.Choices[0].Delta
+		if delta.Role != "" {
+			role = chatbot.Role(delta.Role)
+		}
+		toolCalls = appendToolCallDeltas(toolCalls, delta.ToolCalls)
+		if delta.Content == "" {
+			continue
+		}
+
+		content.WriteString(delta.Content)
+		err = onDelta(delta.Content)
+		if err != nil {
+			return chatbot.CompletionResponse{}, err
+		}
+	}
+
+	if !choices {
+		return chatbot.CompletionResponse{}, chatbot.ErrEmptyCompletion
+	}
+
+	return chatbot.CompletionResponse{
+		Message: chatbot.CompletionMessage{
+			Role:      role,
+			Content:   content.String(),
+			ToolCalls: newToolCalls(toolCalls),
+		},
+	}, nil
+}
+
+// appendToolCallDeltas merges the pieces of tool calls of a streamed response into the calls
+// received so far. The first piece of each call has its ID and name, and the next ones continue
+// its arguments.
+func appendToolCallDeltas(toolCalls []gpt.ToolCall, deltas []gpt.ToolCall) []gpt.ToolCall {
+	for _, delta := range deltas {
+		// Servers that don't send the index start every call with its ID.
+		i := len(toolCalls)
+		switch {
+		case delta.Index != nil:
+			i = *delta.Index
+		case delta.ID == "":
+			i = len(toolCalls) - 1
+		}
+		if i < 0 {
+			continue
+		}
+		for len(toolCalls) <= i {
+			toolCalls = append(toolCalls, gpt.ToolCall{Type: gpt.ToolTypeFunction})
+		}
+
+		if delta.ID != "" {
+			toolCalls[i].ID = delta.ID
+		}
+		if delta.Function.Name != "" {
+			toolCalls[i].Function.Name = delta.Function.Name
+		}
+		toolCalls[i].Function.Arguments += delta.Function.Arguments
+	}
+
+	return toolCalls
+}
+
+func newToolCalls(toolCalls []gpt.ToolCall) []chatbot.ToolCall {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+
+	calls := make([]chatbot.ToolCall, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		calls = append(calls, chatbot.ToolCall{
+			ID:        toolCall.ID,
+			Name:      toolCall.Function.Name,
+			Arguments: toolCall.Function.Arguments,
+		})
+	}
+
+	return calls
+}
+
+func newCompletionRequest(request chatbot.CompletionRequest) gpt.ChatCompletionRequest {
+	messages := make([]gpt.ChatCompletionMessage, 0, len(request.Messages))
+	for _, message := range request.Messages {
+		if len(message.Images) == 0 {
+			messages = append(messages, gpt.ChatCompletionMessage{
+				Role:       string(message.Role),
+				Content:    message.Content,
+				ToolCalls:  newGPTToolCalls(message.ToolCalls),
+				ToolCallID: message.ToolCallID,
+			})
+			continue
+		}
+
+		// Images are sent as parts of the content, which can't be used together with plain content.
+		var parts []gpt.ChatMessagePart
+		if message.Content != "" {
+			parts = append(parts, gpt.ChatMessagePart{
+				Type: gpt.ChatMessagePartTypeText,
+				Text: message.Content,
+			})
+		}
+		for _, image := range message.Images {
+			parts = append(parts, gpt.ChatMessagePart{
+				Type: gpt.ChatMessagePartTypeImageURL,
+				ImageURL: &gpt.ChatMessageImageURL{
+					URL:    "data:" + image.MIMEType + ";base64," + base64.StdEncoding.EncodeToString(image.Data),
+					Detail: gpt.ImageURLDetailAuto,
+				},
+			})
+		}
+
+		messages = append(messages, gpt.ChatCompletionMessage{
+			Role:         string(message.Role),
+			MultiContent: parts,
+		})
+	}
+
+	completionRequest := gpt.ChatCompletionRequest{
+		Model:     request.Model,
+		Messages:  messages,
+		MaxTokens: request.MaxTokens,
+		Stop:      request.Stop,
+	}
+
+	// A zero temperature is omitted from the request, like an unset one, and the server would use
+	// its default instead. So when it's set to zero, the smallest positive value is sent to keep the
+	// output (nearly) deterministic.
+	if request.Temperature != nil {
+		completionRequest.Temperature = *request.Temperature
+		if completionRequest.Temperature == 0 {
+			completionRequest.Temperature = math.SmallestNonzeroFloat32
+		}
+	}
+
+	for _, tool := range request.Tools {
+		completionRequest.Tools = append(completionRequest.Tools, gpt.Tool{
+			Type: gpt.ToolTypeFunction,
+			Function: &gpt.FunctionDefinition{
+				Name:        tool.Name,
+				Description: tool.Description,
+				Parameters:  tool.Parameters,
+			},
+		})
+	}
+
+	return completionRequest
+}
+
+func newGPTToolCalls(toolCalls []chatbot.ToolCall) []gpt.ToolCall {
+	if len(toolCalls) == 0 {
+		return nil
+	}
+
+	calls := make([]gpt.ToolCall, 0, len(toolCalls))
+	for _, toolCall := range toolCalls {
+		calls = append(calls, gpt.ToolCall{
+			ID:   toolCall.ID,
+			Type: gpt.ToolTypeFunction,
+			Function: gpt.FunctionCall{
+				Name:      toolCall.Name,
+				Arguments: toolCall.Arguments,
+			},
+		})
+	}
+
+	return calls
+}
+
+// wrapError wraps client errors that are not worth retrying with chatbot.ErrCompletionRejected.
+func wrapError(err error) error {
+	var statusCode int
+
+	var apiErr *gpt.APIError
+	var requestErr *gpt.RequestError
+	switch {
+	case errors.As(err, &apiErr):
+		statusCode = apiErr.HTTPStatusCode
+	case errors.As(err, &requestErr):
+		statusCode = requestErr.HTTPStatusCode
+	default:
+		return err
+	}
+
+	if statusCode >= 400 && statusCode < 500 && statusCode != 429 {
+		return fmt.Errorf("%w: %w", chatbot.ErrCompletionRejected, err)
+	}
+
+	return err
+}
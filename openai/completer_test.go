@@ -0,0 +1,302 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	gpt "github.com/sashabaranov/go-openai"
+
+	"github.com/happybydefault/chatbot"
+)
+
+// newTestCompleter returns a Completer of a server that mimics the chat completions API with handler,
+// under the /v1 base URL.
+func newTestCompleter(t *testing.T, handler http.HandlerFunc) *Completer {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewCompleter(Config{
+		APIKey:  "key",
+		BaseURL: server.URL + "/v1",
+	})
+}
+
+func TestCompleterComplete(t *testing.T) {
+	var body struct {
+		Model       string   `json:"model"`
+		MaxTokens   int      `json:"max_tokens"`
+		Temperature float64  `json:"temperature"`
+		Stop        []string `json:"stop"`
+		Messages    []struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		} `json:"messages"`
+	}
+	var path, authorization string
+
+	completer := newTestCompleter(t, func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		authorization = r.Header.Get("Authorization")
+
+		err := json.NewDecoder(r.Body).Decode(&body)
+		if err != nil {
+			t.Errorf("failed to decode request body: %s", err)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"choices": [{"index": 0, "message": {"role": "assistant", "content": "Hello!"}}]}`)
+	})
+
+	response, err := completer.Complete(context.Background(), chatbot.CompletionRequest{
+		Model: "model",
+		Messages: []chatbot.CompletionMessage{
+			{Role: chatbot.RoleSystem, Content: "Be nice."},
+			{Role: chatbot.RoleUser, Content: "Hi"},
+		},
+		MaxTokens:   100,
+		Temperature: float32Ptr(0.7),
+		Stop:        []string{"'''"},
+	})
+	if err != nil {
+		t.Fatalf("failed to complete: %s", err)
+	}
+
+	if path != "/v1/chat/completions" || authorization != "Bearer key" {
+		t.Errorf("got request to %q with authorization %q, want /v1/chat/completions with the API key", path, authorization)
+	}
+	if body.Model != "model" || body.MaxTokens != 100 || math.Abs(body.Temperature-0.7) > 1e-6 ||
+		!reflect.DeepEqual(body.Stop, []string{"'''"}) {
+		t.Errorf("got request body %+v, want the parameters of the request", body)
+	}
+	if len(body.Messages) != 2 || body.Messages[0].Role != "system" || body.Messages[1].Content != "Hi" {
+		t.Errorf("got request messages %+v, want the ones of the request", body.Messages)
+	}
+	if response.Message.Role != chatbot.RoleAssistant || response.Message.Content != "Hello!" {
+		t.Errorf("got response message %+v, want the one of the server", response.Message)
+	}
+}
+
+func float32Ptr(f float32) *float32 {
+	return &f
+}
+
+func TestCompleterCompleteTemperature(t *testing.T) {
+	var body map[string]interface{}
+	completer := newTestCompleter(t, func(w http.ResponseWriter, r *http.Request) {
+		body = nil
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		fmt.Fprint(w, `{"choices": [{"message": {"role": "assistant", "content": "Hello!"}}]}`)
+	})
+
+	// An unset temperature is left to the server.
+	_, err := completer.Complete(context.Background(), chatbot.CompletionRequest{Model: "model"})
+	if err != nil {
+		t.Fatalf("failed to complete: %s", err)
+	}
+	if temperature, ok := body["temperature"]; ok {
+		t.Errorf("got temperature %v, want none", temperature)
+	}
+
+	// A zero temperature is sent as the smallest positive one, since a zero one would be omitted.
+	_, err = completer.Complete(context.Background(), chatbot.CompletionRequest{
+		Model:       "model",
+		Temperature: float32Ptr(0),
+	})
+	if err != nil {
+		t.Fatalf("failed to complete: %s", err)
+	}
+	temperature, ok := body["temperature"].(float64)
+	if !ok || temperature <= 0 || temperature > 1e-30 {
+		t.Errorf("got temperature %v, want the smallest positive one", body["temperature"])
+	}
+}
+
+func TestCompleterCompleteErrors(t *testing.T) {
+	tests := []struct {
+		statusCode int
+		rejected   bool
+	}{
+		{statusCode: http.StatusBadRequest, rejected: true},
+		{statusCode: http.StatusUnauthorized, rejected: true},
+		{statusCode: http.StatusNotFound, rejected: true},
+		{statusCode: http.StatusTooManyRequests, rejected: false},
+		{statusCode: http.StatusInternalServerError, rejected: false},
+		{statusCode: http.StatusServiceUnavailable, rejected: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.statusCode), func(t *testing.T) {
+			completer := newTestCompleter(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.statusCode)
+				fmt.Fprint(w, `{"error": {"message": "failed", "type": "error"}}`)
+			})
+
+			_, err := completer.Complete(context.Background(), chatbot.CompletionRequest{Model: "model"})
+			if err == nil {
+				t.Fatal("got no error")
+			}
+			if errors.Is(err, chatbot.ErrCompletionRejected) != tt.rejected {
+				t.Errorf("got error %v, want rejected %v", err, tt.rejected)
+			}
+
+			var apiErr *gpt.APIError
+			if !errors.As(err, &apiErr) || apiErr.HTTPStatusCode != tt.statusCode {
+				t.Errorf("got error %v, want the API error with status %d", err, tt.statusCode)
+			}
+		})
+	}
+}
+
+func TestCompleterCompleteEmpty(t *testing.T) {
+	completer := newTestCompleter(t, func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"choices": []}`)
+	})
+
+	_, err := completer.Complete(context.Background(), chatbot.CompletionRequest{Model: "model"})
+	if !errors.Is(err, chatbot.ErrEmptyCompletion) {
+		t.Errorf("got error %v, want %v", err, chatbot.ErrEmptyCompletion)
+	}
+}
+
+func TestCompleterCompleteStream(t *testing.T) {
+	chunks := []string{
+		`{"choices": [{"index": 0, "delta": {"role": "assistant"}}]}`,
+		`{"choices": [{"index": 0, "delta": {"content": "Hel"}}]}`,
+		`{"choices": [{"index": 0, "delta": {"content": "lo!"}}]}`,
+		`{"choices": [{"index": 0, "delta": {"tool_calls": [{"index": 0, "id": "call", "type": "function", "function": {"name": "current_time", "arguments": ""}}]}}]}`,
+		`{"choices": [{"index": 0, "delta": {"tool_calls": [{"index": 0, "function": {"arguments": "{\"timezone\":"}}]}}]}`,
+		`{"choices": [{"index": 0, "delta": {"tool_calls": [{"index": 0, "function": {"arguments": " \"UTC\"}"}}]}}]}`,
+	}
+
+	var stream bool
+	completer := newTestCompleter(t, func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			Stream bool `json:"stream"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&body)
+		stream = body.Stream
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		for _, chunk := range chunks {
+			fmt.Fprintf(w, "data: %s\n\n", chunk)
+		}
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	var deltas []string
+	response, err := completer.CompleteStream(
+		context.Background(),
+		chatbot.CompletionRequest{Model: "model"},
+		func(delta string) error {
+			deltas = append(deltas, delta)
+			return nil
+		},
+	)
+	if err != nil {
+		t.Fatalf("failed to complete stream: %s", err)
+	}
+
+	if !stream {
+		t.Error("request was not streamed")
+	}
+	if !reflect.DeepEqual(deltas, []string{"Hel", "lo!"}) {
+		t.Errorf("got deltas %q, want the content of the chunks", deltas)
+	}
+	wantToolCalls := []chatbot.ToolCall{{ID: "call", Name: "current_time", Arguments: `{"timezone": "UTC"}`}}
+	if response.Message.Role != chatbot.RoleAssistant || response.Message.Content != "Hello!" ||
+		!reflect.DeepEqual(response.Message.ToolCalls, wantToolCalls) {
+		t.Errorf("got response message %+v, want the merged chunks", response.Message)
+	}
+}
+
+func TestCompleterCompleteStreamDeltaError(t *testing.T) {
+	completer := newTestCompleter(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/event-stream")
+		fmt.Fprint(w, "data: {\"choices\": [{\"delta\": {\"content\": \"Hello\"}}]}\n\n")
+		fmt.Fprint(w, "data: [DONE]\n\n")
+	})
+
+	errDelta := errors.New("delta error")
+	_, err := completer.CompleteStream(
+		context.Background(),
+		chatbot.CompletionRequest{Model: "model"},
+		func(delta string) error {
+			return errDelta
+		},
+	)
+	if !errors.Is(err, errDelta) {
+		t.Errorf("got error %v, want the one of onDelta", err)
+	}
+}
+
+func TestAppendToolCallDeltas(t *testing.T) {
+	index := func(i int) *int {
+		return &i
+	}
+	function := func(name, arguments string) gpt.FunctionCall {
+		return gpt.FunctionCall{Name: name, Arguments: arguments}
+	}
+
+	tests := []struct {
+		name   string
+		deltas [][]gpt.ToolCall
+		want   []chatbot.ToolCall
+	}{
+		{
+			name: "indexed",
+			deltas: [][]gpt.ToolCall{
+				{{Index: index(0), ID: "a", Function: function("first", `{"x":`)}},
+				{{Index: index(1), ID: "b", Function: function("second", "")}},
+				{{Index: index(0), Function: function("", ` 1}`)}},
+				{{Index: index(1), Function: function("", `{}`)}},
+			},
+			want: []chatbot.ToolCall{
+				{ID: "a", Name: "first", Arguments: `{"x": 1}`},
+				{ID: "b", Name: "second", Arguments: `{}`},
+			},
+		},
+		{
+			name: "without index",
+			deltas: [][]gpt.ToolCall{
+				{{ID: "a", Function: function("first", `{`)}},
+				{{Function: function("", `}`)}},
+				{{ID: "b", Function: function("second", `{}`)}},
+			},
+			want: []chatbot.ToolCall{
+				{ID: "a", Name: "first", Arguments: `{}`},
+				{ID: "b", Name: "second", Arguments: `{}`},
+			},
+		},
+		{
+			name: "continuation without call",
+			deltas: [][]gpt.ToolCall{
+				{{Function: function("", `{}`)}},
+			},
+			want: nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var toolCalls []gpt.ToolCall
+			for _, deltas := range tt.deltas {
+				toolCalls = appendToolCallDeltas(toolCalls, deltas)
+			}
+
+			got := newToolCalls(toolCalls)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got tool calls %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+
+	gpt "github.com/sashabaranov/go-openai"
+
+	"github.com/happybydefault/chatbot"
+)
+
+// ImageGenerator is a chatbot.ImageGenerator backed by the OpenAI image generations API, i.e. DALL·E.
+type ImageGenerator struct {
+	client *gpt.Client
+}
+
+func NewImageGenerator(cfg Config) *ImageGenerator {
+	return &ImageGenerator{
+		client: newClient(cfg),
+	}
+}
+
+func (g *ImageGenerator) GenerateImage(ctx context.Context, request chatbot.ImageRequest) (chatbot.ImageResponse, error) {
+	model := request.Model
+	if model == "" {
+		model = gpt.CreateImageModelDallE3
+	}
+
+	imageResponse, err := g.client.CreateImage(ctx, gpt.ImageRequest{
+		Prompt:         request.Prompt,
+		Model:          model,
+		N:              1,
+		Size:           gpt.CreateImageSize1024x1024,
+		ResponseFormat: gpt.CreateImageResponseFormatB64JSON,
+	})
+	if err != nil {
+		return chatbot.ImageResponse{}, err
+	}
+
+	if len(imageResponse.Data) == 0 {
+		return chatbot.ImageResponse{}, errors.New("image response has no data")
+	}
+
+	imageData, err := base64.StdEncoding.DecodeString(imageResponse.Data[0].B64JSON)
+	if err != nil {
+		return chatbot.ImageResponse{}, fmt.Errorf("failed to decode image: %w", err)
+	}
+
+	return chatbot.ImageResponse{
+		MIMEType: "image/png",
+		Data:     imageData,
+	}, nil
+}
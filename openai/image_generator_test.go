@@ -0,0 +1,107 @@
+package openai
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/happybydefault/chatbot"
+)
+
+// newTestImageGenerator returns an ImageGenerator of a server that mimics the image generations API
+// with handler, under the /v1 base URL.
+func newTestImageGenerator(t *testing.T, handler http.HandlerFunc) *ImageGenerator {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewImageGenerator(Config{
+		APIKey:  "key",
+		BaseURL: server.URL + "/v1",
+	})
+}
+
+func TestImageGeneratorGenerateImage(t *testing.T) {
+	tests := []struct {
+		name      string
+		model     string
+		wantModel string
+	}{
+		{name: "default model", wantModel: "dall-e-3"},
+		{name: "model", model: "dall-e-2", wantModel: "dall-e-2"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				Prompt         string `json:"prompt"`
+				Model          string `json:"model"`
+				N              int    `json:"n"`
+				ResponseFormat string `json:"response_format"`
+			}
+			var path string
+
+			generator := newTestImageGenerator(t, func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+
+				err := json.NewDecoder(r.Body).Decode(&body)
+				if err != nil {
+					t.Errorf("failed to decode request body: %s", err)
+				}
+
+				w.Header().Set("Content-Type", "application/json")
+				fmt.Fprintf(w, `{"data": [{"b64_json": %q}]}`, base64.StdEncoding.EncodeToString([]byte("image data")))
+			})
+
+			response, err := generator.GenerateImage(context.Background(), chatbot.ImageRequest{
+				Model:  tt.model,
+				Prompt: "a cat in space",
+			})
+			if err != nil {
+				t.Fatalf("failed to generate image: %s", err)
+			}
+
+			if path != "/v1/images/generations" {
+				t.Errorf("got request to %q, want the image generations endpoint", path)
+			}
+			if body.Prompt != "a cat in space" || body.Model != tt.wantModel || body.N != 1 || body.ResponseFormat != "b64_json" {
+				t.Errorf("got request body %+v, want one base64 image of %s", body, tt.wantModel)
+			}
+			if response.MIMEType != "image/png" || string(response.Data) != "image data" {
+				t.Errorf("got response %+v, want the decoded image", response)
+			}
+		})
+	}
+}
+
+func TestImageGeneratorGenerateImageErrors(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+		body   string
+	}{
+		{name: "error status", status: http.StatusBadRequest, body: `{"error": {"message": "content policy violation"}}`},
+		{name: "no data", status: http.StatusOK, body: `{"data": []}`},
+		{name: "invalid base64", status: http.StatusOK, body: `{"data": [{"b64_json": "not base64!"}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generator := newTestImageGenerator(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+
+			_, err := generator.GenerateImage(context.Background(), chatbot.ImageRequest{Prompt: "a cat"})
+			if err == nil {
+				t.Error("got no error, want one")
+			}
+		})
+	}
+}
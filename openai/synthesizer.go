@@ -0,0 +1,54 @@
+package openai
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	gpt "github.com/sashabaranov/go-openai"
+
+	"github.com/happybydefault/chatbot"
+)
+
+// Synthesizer is a chatbot.Synthesizer backed by the OpenAI speech API. Its client only accepts the
+// models and voices of OpenAI, so compatible servers must serve them with the same names.
+type Synthesizer struct {
+	client *gpt.Client
+}
+
+func NewSynthesizer(cfg Config) *Synthesizer {
+	return &Synthesizer{
+		client: newClient(cfg),
+	}
+}
+
+func (s *Synthesizer) Synthesize(ctx context.Context, request chatbot.SpeechRequest) (chatbot.SpeechResponse, error) {
+	model := gpt.SpeechModel(request.Model)
+	if model == "" {
+		model = gpt.TTSModel1
+	}
+	voice := gpt.SpeechVoice(request.Voice)
+	if voice == "" {
+		voice = gpt.VoiceAlloy
+	}
+
+	body, err := s.client.CreateSpeech(ctx, gpt.CreateSpeechRequest{
+		Model:          model,
+		Input:          request.Text,
+		Voice:          voice,
+		ResponseFormat: gpt.SpeechResponseFormatOpus,
+	})
+	if err != nil {
+		return chatbot.SpeechResponse{}, err
+	}
+	defer body.Close()
+
+	audio, err := io.ReadAll(body)
+	if err != nil {
+		return chatbot.SpeechResponse{}, fmt.Errorf("failed to read audio: %w", err)
+	}
+
+	return chatbot.SpeechResponse{
+		Audio: audio,
+	}, nil
+}
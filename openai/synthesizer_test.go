@@ -0,0 +1,76 @@
+package openai
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/happybydefault/chatbot"
+)
+
+func TestSynthesizerSynthesize(t *testing.T) {
+	tests := []struct {
+		name      string
+		request   chatbot.SpeechRequest
+		wantModel string
+		wantVoice string
+	}{
+		{
+			name:      "defaults",
+			request:   chatbot.SpeechRequest{Text: "Hello!"},
+			wantModel: "tts-1",
+			wantVoice: "alloy",
+		},
+		{
+			name:      "model and voice",
+			request:   chatbot.SpeechRequest{Model: "tts-1-hd", Voice: "nova", Text: "Hello!"},
+			wantModel: "tts-1-hd",
+			wantVoice: "nova",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				Model          string `json:"model"`
+				Input          string `json:"input"`
+				Voice          string `json:"voice"`
+				ResponseFormat string `json:"response_format"`
+			}
+			var path string
+
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path = r.URL.Path
+
+				err := json.NewDecoder(r.Body).Decode(&body)
+				if err != nil {
+					t.Errorf("failed to decode request body: %s", err)
+				}
+
+				w.Header().Set("Content-Type", "audio/ogg")
+				fmt.Fprint(w, "audio data")
+			}))
+			t.Cleanup(server.Close)
+
+			synthesizer := NewSynthesizer(Config{APIKey: "key", BaseURL: server.URL + "/v1"})
+
+			response, err := synthesizer.Synthesize(context.Background(), tt.request)
+			if err != nil {
+				t.Fatalf("failed to synthesize: %s", err)
+			}
+
+			if path != "/v1/audio/speech" {
+				t.Errorf("got request to %q, want the speech endpoint", path)
+			}
+			if body.Model != tt.wantModel || body.Voice != tt.wantVoice || body.Input != "Hello!" || body.ResponseFormat != "opus" {
+				t.Errorf("got request body %+v, want Opus audio of %s with %s", body, tt.wantModel, tt.wantVoice)
+			}
+			if string(response.Audio) != "audio data" {
+				t.Errorf("got audio %q, want the one of the server", response.Audio)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package openai
+
+import (
+	"bytes"
+	"context"
+	"strings"
+
+	gpt "github.com/sashabaranov/go-openai"
+
+	"github.com/happybydefault/chatbot"
+)
+
+// Transcriber is a chatbot.Transcriber backed by the OpenAI audio transcriptions API, i.e. Whisper.
+type Transcriber struct {
+	client *gpt.Client
+}
+
+func NewTranscriber(cfg Config) *Transcriber {
+	return &Transcriber{
+		client: newClient(cfg),
+	}
+}
+
+func (t *Transcriber) Transcribe(ctx context.Context, request chatbot.TranscriptionRequest) (chatbot.TranscriptionResponse, error) {
+	model := request.Model
+	if model == "" {
+		model = gpt.Whisper1
+	}
+
+	audioResponse, err := t.client.CreateTranscription(ctx, gpt.AudioRequest{
+		Model: model,
+		// The API detects the format of the audio from the extension of the file name.
+		FilePath: "audio" + audioExtension(request.MIMEType),
+		Reader:   bytes.NewReader(request.Audio),
+		Format:   gpt.AudioResponseFormatJSON,
+	})
+	if err != nil {
+		return chatbot.TranscriptionResponse{}, err
+	}
+
+	return chatbot.TranscriptionResponse{
+		Text: audioResponse.Text,
+	}, nil
+}
+
+// audioExtension returns the file extension of the audio formats that WhatsApp uses, defaulting to
+// the one of voice messages.
+func audioExtension(mimeType string) string {
+	mimeType, _, _ = strings.Cut(mimeType, ";")
+
+	switch strings.TrimSpace(mimeType) {
+	case "audio/mpeg":
+		return ".mp3"
+	case "audio/mp4", "audio/aac":
+		return ".m4a"
+	case "audio/wav", "audio/x-wav":
+		return ".wav"
+	case "audio/webm":
+		return ".webm"
+	default:
+		return ".ogg"
+	}
+}
@@ -0,0 +1,119 @@
+// Package placeholder implements chatbot.ImageGenerator with images that are rendered locally, so the
+// imagine command can be tried without an image generation API.
+package placeholder
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"hash/fnv"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+
+	"github.com/happybydefault/chatbot"
+)
+
+const (
+	imageSize   = 512
+	textMargin  = 16
+	lineLength  = (imageSize - 2*textMargin) / 7 // The characters of basicfont.Face7x13 are 7 pixels wide.
+	lineSpacing = 16
+)
+
+// ImageGenerator is a chatbot.ImageGenerator that renders a PNG with a gradient derived from the
+// prompt, so every prompt has its own colors, and the prompt written on it. It ignores the model of
+// the requests.
+type ImageGenerator struct{}
+
+func NewImageGenerator() *ImageGenerator {
+	return &ImageGenerator{}
+}
+
+func (g *ImageGenerator) GenerateImage(ctx context.Context, request chatbot.ImageRequest) (chatbot.ImageResponse, error) {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(request.Prompt))
+	sum := hash.Sum32()
+
+	from := color.RGBA{R: uint8(sum), G: uint8(sum >> 8), B: uint8(sum >> 16), A: 255}
+	to := color.RGBA{R: 255 - from.R, G: 255 - from.G, B: 255 - from.B, A: 255}
+
+	img := image.NewRGBA(image.Rect(0, 0, imageSize, imageSize))
+	for y := 0; y < imageSize; y++ {
+		for x := 0; x < imageSize; x++ {
+			t := (x + y) * 255 / (2 * (imageSize - 1))
+			img.SetRGBA(x, y, color.RGBA{
+				R: blend(from.R, to.R, t),
+				G: blend(from.G, to.G, t),
+				B: blend(from.B, to.B, t),
+				A: 255,
+			})
+		}
+	}
+
+	drawer := font.Drawer{
+		Dst:  img,
+		Src:  image.White,
+		Face: basicfont.Face7x13,
+	}
+	for i, line := range wrap(request.Prompt, lineLength) {
+		y := textMargin + (i+1)*lineSpacing
+		if y > imageSize-textMargin {
+			break
+		}
+		drawer.Dot = fixed.P(textMargin, y)
+		drawer.DrawString(line)
+	}
+
+	var buf bytes.Buffer
+	err := png.Encode(&buf, img)
+	if err != nil {
+		return chatbot.ImageResponse{}, fmt.Errorf("failed to encode image: %w", err)
+	}
+
+	return chatbot.ImageResponse{
+		MIMEType: "image/png",
+		Data:     buf.Bytes(),
+	}, nil
+}
+
+// blend interpolates between a and b, where t goes from 0 (a) to 255 (b).
+func blend(a, b uint8, t int) uint8 {
+	return uint8((int(a)*(255-t) + int(b)*t) / 255)
+}
+
+// wrap splits text into lines of at most n characters at spaces, cutting the words that are longer.
+func wrap(text string, n int) []string {
+	var lines []string
+	var line []rune
+	for _, word := range strings.Fields(text) {
+		w := []rune(word)
+		for len(w) > n {
+			if len(line) > 0 {
+				lines = append(lines, string(line))
+				line = nil
+			}
+			lines = append(lines, string(w[:n]))
+			w = w[n:]
+		}
+
+		if len(line) > 0 && len(line)+1+len(w) > n {
+			lines = append(lines, string(line))
+			line = nil
+		}
+		if len(line) > 0 {
+			line = append(line, ' ')
+		}
+		line = append(line, w...)
+	}
+	if len(line) > 0 {
+		lines = append(lines, string(line))
+	}
+
+	return lines
+}
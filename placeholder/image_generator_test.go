@@ -0,0 +1,66 @@
+package placeholder
+
+import (
+	"bytes"
+	"context"
+	"image/png"
+	"reflect"
+	"testing"
+
+	"github.com/happybydefault/chatbot"
+)
+
+func TestImageGeneratorGenerateImage(t *testing.T) {
+	ctx := context.Background()
+	generator := NewImageGenerator()
+
+	response, err := generator.GenerateImage(ctx, chatbot.ImageRequest{Prompt: "a cat in space"})
+	if err != nil {
+		t.Fatalf("failed to generate image: %s", err)
+	}
+	if response.MIMEType != "image/png" {
+		t.Errorf("got MIME type %q, want image/png", response.MIMEType)
+	}
+
+	img, err := png.Decode(bytes.NewReader(response.Data))
+	if err != nil {
+		t.Fatalf("failed to decode image: %s", err)
+	}
+	if size := img.Bounds().Size(); size.X != imageSize || size.Y != imageSize {
+		t.Errorf("got image of %v, want %dx%d", size, imageSize, imageSize)
+	}
+
+	// Every prompt has its own image, but always the same one.
+	same, err := generator.GenerateImage(ctx, chatbot.ImageRequest{Prompt: "a cat in space"})
+	if err != nil {
+		t.Fatalf("failed to generate image again: %s", err)
+	}
+	other, err := generator.GenerateImage(ctx, chatbot.ImageRequest{Prompt: "a dog in space"})
+	if err != nil {
+		t.Fatalf("failed to generate other image: %s", err)
+	}
+	if !bytes.Equal(same.Data, response.Data) || bytes.Equal(other.Data, response.Data) {
+		t.Error("got images that don't depend only on their prompts")
+	}
+}
+
+func TestWrap(t *testing.T) {
+	tests := []struct {
+		text string
+		n    int
+		want []string
+	}{
+		{text: "", n: 10, want: nil},
+		{text: "a cat in space", n: 10, want: []string{"a cat in", "space"}},
+		{text: "  a   cat  ", n: 10, want: []string{"a cat"}},
+		{text: "a supercalifragilistic cat", n: 10, want: []string{"a", "supercalif", "ragilistic", "cat"}},
+		{text: "ñandú ñandú", n: 5, want: []string{"ñandú", "ñandú"}},
+	}
+
+	for _, tt := range tests {
+		got := wrap(tt.text, tt.n)
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("got %q for %q, want %q", got, tt.text, tt.want)
+		}
+	}
+}
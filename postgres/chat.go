@@ -6,23 +6,181 @@ import (
 	"fmt"
 
 	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
 
 	"github.com/happybydefault/chatbot/data"
 )
 
 func (s *Store) Chat(ctx context.Context, tx data.Tx, whatsappID string) (data.Chat, error) {
-	query := "SELECT chat_id FROM chats WHERE chat_id = $1 LIMIT 1"
+	query := `SELECT chat_id, system_prompt, model, temperature, max_tokens, language, timezone, segment,
+			         voice_replies, tools
+			  FROM chats
+			  WHERE chat_id = $1
+			  LIMIT 1`
 
 	row := tx.QueryRow(ctx, query, whatsappID)
 
-	var chat data.Chat
-	err := row.Scan(&chat.ID)
+	chat, err := s.scanChat(row)
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return data.Chat{}, data.ErrNotFound
 		}
+		return data.Chat{}, err
+	}
+
+	return chat, nil
+}
+
+func (s *Store) Chats(ctx context.Context, tx data.Tx) ([]data.Chat, error) {
+	query := `SELECT chat_id, system_prompt, model, temperature, max_tokens, language, timezone, segment,
+			         voice_replies, tools
+			  FROM chats
+			  ORDER BY chat_id`
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			s.logger.Error("failed to close rows", zap.Error(err))
+		}
+	}()
+
+	var chats []data.Chat
+	for rows.Next() {
+		chat, err := s.scanChat(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		chats = append(chats, chat)
+	}
+
+	return chats, nil
+}
+
+func (s *Store) CreateChat(ctx context.Context, tx data.Tx, chat data.Chat) error {
+	query := `INSERT INTO chats (
+			      chat_id, system_prompt, model, temperature, max_tokens, language, timezone, segment, voice_replies,
+			      tools
+			  )
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			  ON CONFLICT (chat_id) DO NOTHING`
+
+	result, err := tx.Exec(
+		ctx,
+		query,
+		chat.ID,
+		chat.SystemPrompt,
+		chat.Model,
+		chat.Temperature,
+		chat.MaxTokens,
+		chat.Language,
+		chat.Timezone,
+		chat.Segment,
+		chat.VoiceReplies,
+		toolNames(chat.Tools),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return data.ErrAlreadyExists
+	}
+
+	return nil
+}
+
+func (s *Store) UpdateChat(ctx context.Context, tx data.Tx, chat data.Chat) error {
+	query := `UPDATE chats
+			  SET system_prompt = $1, model = $2, temperature = $3, max_tokens = $4, language = $5, timezone = $6,
+			      segment = $7, voice_replies = $8, tools = $9
+			  WHERE chat_id = $10`
+
+	result, err := tx.Exec(
+		ctx,
+		query,
+		chat.SystemPrompt,
+		chat.Model,
+		chat.Temperature,
+		chat.MaxTokens,
+		chat.Language,
+		chat.Timezone,
+		chat.Segment,
+		chat.VoiceReplies,
+		toolNames(chat.Tools),
+		chat.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return data.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteChat(ctx context.Context, tx data.Tx, chatID string) error {
+	query := "DELETE FROM chats WHERE chat_id = $1"
+
+	result, err := tx.Exec(ctx, query, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return data.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) scanChat(row data.Row) (data.Chat, error) {
+	var chat data.Chat
+	err := row.Scan(
+		&chat.ID,
+		&chat.SystemPrompt,
+		&chat.Model,
+		&chat.Temperature,
+		&chat.MaxTokens,
+		&chat.Language,
+		&chat.Timezone,
+		&chat.Segment,
+		&chat.VoiceReplies,
+		&chat.Tools,
+	)
+	if err != nil {
 		return data.Chat{}, fmt.Errorf("failed to scan row: %w", err)
 	}
+	if len(chat.Tools) == 0 {
+		chat.Tools = nil
+	}
 
 	return chat, nil
 }
+
+// toolNames returns the tools of a chat as a non-nil slice, which pgx would encode as NULL otherwise.
+func toolNames(tools []string) []string {
+	if tools == nil {
+		return []string{}
+	}
+
+	return tools
+}
@@ -0,0 +1,117 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Documents(ctx context.Context, tx data.Tx, chatID string, segment int) ([]data.Document, error) {
+	query := `SELECT message_id, chat_id, segment, file_name, mime_type, created_at
+			  FROM documents
+			  WHERE chat_id = $1 AND segment = $2
+			  ORDER BY created_at, message_id`
+
+	rows, err := tx.Query(ctx, query, chatID, segment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			s.logger.Error("failed to close rows", zap.Error(err))
+		}
+	}()
+
+	var documents []data.Document
+	for rows.Next() {
+		var document data.Document
+		err := rows.Scan(
+			&document.MessageID,
+			&document.ChatID,
+			&document.Segment,
+			&document.FileName,
+			&document.MIMEType,
+			&document.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		documents = append(documents, document)
+	}
+
+	return documents, nil
+}
+
+func (s *Store) CreateDocument(ctx context.Context, tx data.Tx, document data.Document) error {
+	query := `INSERT INTO documents (message_id, chat_id, segment, file_name, mime_type, created_at)
+			  VALUES ($1, $2, $3, $4, $5, $6)`
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		document.MessageID,
+		document.ChatID,
+		document.Segment,
+		document.FileName,
+		document.MIMEType,
+		document.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) DocumentChunks(ctx context.Context, tx data.Tx, chatID string, segment int) ([]data.DocumentChunk, error) {
+	query := `SELECT c.message_id, c."index", c.content
+			  FROM document_chunks c
+			  JOIN documents d ON d.message_id = c.message_id
+			  WHERE d.chat_id = $1 AND d.segment = $2
+			  ORDER BY d.created_at, c.message_id, c."index"`
+
+	rows, err := tx.Query(ctx, query, chatID, segment)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			s.logger.Error("failed to close rows", zap.Error(err))
+		}
+	}()
+
+	var chunks []data.DocumentChunk
+	for rows.Next() {
+		var chunk data.DocumentChunk
+		err := rows.Scan(
+			&chunk.MessageID,
+			&chunk.Index,
+			&chunk.Content,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		chunks = append(chunks, chunk)
+	}
+
+	return chunks, nil
+}
+
+func (s *Store) CreateDocumentChunk(ctx context.Context, tx data.Tx, chunk data.DocumentChunk) error {
+	query := `INSERT INTO document_chunks (message_id, "index", content)
+			  VALUES ($1, $2, $3)`
+
+	_, err := tx.Exec(ctx, query, chunk.MessageID, chunk.Index, chunk.Content)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
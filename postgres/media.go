@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Media(ctx context.Context, tx data.Tx, chatID string, messageIDs []string) ([]data.Media, error) {
+	query := `SELECT message_id, chat_id, mime_type, data, created_at
+			  FROM media
+			  WHERE chat_id = $1 AND message_id = ANY($2)`
+
+	rows, err := tx.Query(ctx, query, chatID, messageIDs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			s.logger.Error("failed to close rows", zap.Error(err))
+		}
+	}()
+
+	var media []data.Media
+	for rows.Next() {
+		var m data.Media
+		err := rows.Scan(
+			&m.MessageID,
+			&m.ChatID,
+			&m.MIMEType,
+			&m.Data,
+			&m.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		media = append(media, m)
+	}
+
+	return media, nil
+}
+
+func (s *Store) CreateMedia(ctx context.Context, tx data.Tx, media data.Media) error {
+	query := `INSERT INTO media (message_id, chat_id, mime_type, data, created_at)
+			  VALUES ($1, $2, $3, $4, $5)`
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		media.MessageID,
+		media.ChatID,
+		media.MIMEType,
+		media.Data,
+		media.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
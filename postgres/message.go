@@ -11,16 +11,25 @@ import (
 )
 
 func (s *Store) CreateMessage(ctx context.Context, tx data.Tx, message data.Message) error {
-	query := `INSERT INTO messages (chat_id, sender_id, message_id, conversation, "timestamp", created_at)
-			  VALUES ($1, $2, $3, $4, $5, $6)`
+	query := `INSERT INTO messages (
+			      chat_id, sender_id, sender_name, message_id, conversation, quoted_message_id, quoted_text, transcribed,
+			      completion_id, segment, "timestamp", created_at
+			  )
+			  VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12)`
 
 	_, err := tx.Exec(
 		ctx,
 		query,
 		message.ChatID,
 		message.SenderID,
+		message.SenderName,
 		message.ID,
 		message.Conversation,
+		message.QuotedID,
+		message.QuotedText,
+		message.Transcribed,
+		message.CompletionID,
+		message.Segment,
 		message.Timestamp,
 		message.CreatedAt,
 	)
@@ -31,43 +40,68 @@ func (s *Store) CreateMessage(ctx context.Context, tx data.Tx, message data.Mess
 	return nil
 }
 
-func (s *Store) Messages(ctx context.Context, tx data.Tx, chatID string) ([]data.Message, error) {
-	query := `SELECT chat_id, sender_id, message_id, conversation, "timestamp", created_at
-			  FROM messages
-			  WHERE chat_id = $1
-			  ORDER BY "timestamp"`
+func (s *Store) DeleteMessage(ctx context.Context, tx data.Tx, chatID, messageID string) error {
+	query := "DELETE FROM messages WHERE chat_id = $1 AND message_id = $2"
 
-	rows, err := tx.Query(ctx, query, chatID)
+	result, err := tx.Exec(ctx, query, chatID, messageID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to execute query: %w", err)
+		return fmt.Errorf("failed to execute query: %w", err)
 	}
-	defer func() {
-		err := rows.Close()
-		if err != nil {
-			s.logger.Error("failed to close rows", zap.Error(err))
-		}
-	}()
 
-	var messages []data.Message
-	for rows.Next() {
-		message, err := s.scanMessage(rows)
-		if err != nil {
-			return nil, fmt.Errorf("failed to scan row: %w", err)
-		}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return data.ErrNotFound
+	}
 
-		messages = append(messages, message)
+	return nil
+}
+
+func (s *Store) DeleteMessages(ctx context.Context, tx data.Tx, chatID string) error {
+	query := "DELETE FROM messages WHERE chat_id = $1"
+
+	_, err := tx.Exec(ctx, query, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
 	}
 
-	return messages, nil
+	return nil
+}
+
+func (s *Store) Messages(ctx context.Context, tx data.Tx, chatID string) ([]data.Message, error) {
+	query := `SELECT chat_id, sender_id, sender_name, message_id, conversation, quoted_message_id, quoted_text, transcribed,
+			         completion_id, segment, "timestamp", created_at
+			  FROM messages
+			  WHERE chat_id = $1
+			  ORDER BY "timestamp"`
+
+	return s.queryMessages(ctx, tx, query, chatID)
+}
+
+func (s *Store) SegmentMessages(ctx context.Context, tx data.Tx, chatID string, segment int) ([]data.Message, error) {
+	query := `SELECT chat_id, sender_id, sender_name, message_id, conversation, quoted_message_id, quoted_text, transcribed,
+			         completion_id, segment, "timestamp", created_at
+			  FROM messages
+			  WHERE chat_id = $1 AND segment = $2
+			  ORDER BY "timestamp"`
+
+	return s.queryMessages(ctx, tx, query, chatID, segment)
 }
 
 func (s *Store) AllMessagesSince(ctx context.Context, tx data.Tx, t time.Time) ([]data.Message, error) {
-	query := `SELECT chat_id, sender_id, message_id, conversation, "timestamp", created_at
+	query := `SELECT chat_id, sender_id, sender_name, message_id, conversation, quoted_message_id, quoted_text, transcribed,
+			         completion_id, segment, "timestamp", created_at
 			  FROM messages
 			  WHERE created_at >= $1
 			  ORDER BY "timestamp"`
 
-	rows, err := tx.Query(ctx, query, t)
+	return s.queryMessages(ctx, tx, query, t)
+}
+
+func (s *Store) queryMessages(ctx context.Context, tx data.Tx, query string, args ...interface{}) ([]data.Message, error) {
+	rows, err := tx.Query(ctx, query, args...)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %w", err)
 	}
@@ -96,8 +130,14 @@ func (s *Store) scanMessage(row data.Row) (data.Message, error) {
 	err := row.Scan(
 		&message.ChatID,
 		&message.SenderID,
+		&message.SenderName,
 		&message.ID,
 		&message.Conversation,
+		&message.QuotedID,
+		&message.QuotedText,
+		&message.Transcribed,
+		&message.CompletionID,
+		&message.Segment,
 		&message.Timestamp,
 		&message.CreatedAt,
 	)
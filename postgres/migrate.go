@@ -0,0 +1,187 @@
+package postgres
+
+import (
+	"context"
+	"embed"
+	"fmt"
+	"io/fs"
+
+	"github.com/jackc/pgx/v5"
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/internal/migration"
+)
+
+// ErrSchemaTooNew is returned when the database schema was migrated by a newer version of the program.
+var ErrSchemaTooNew = migration.ErrSchemaTooNew
+
+// migrationsLockID is the key of the advisory lock that serializes concurrent migrations.
+const migrationsLockID = 7_361_274_912
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+func loadMigrations() ([]migration.Migration, error) {
+	fsys, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return migration.Load(fsys)
+}
+
+// LatestSchemaVersion returns the version of the newest migration embedded in the program.
+func (s *Store) LatestSchemaVersion() (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return len(migrations), nil
+}
+
+// SchemaVersion returns the version of the last migration applied to the database, or zero if none
+// has been applied.
+func (s *Store) SchemaVersion(ctx context.Context) (int, error) {
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	return schemaVersion(ctx, conn.Conn())
+}
+
+// MigrateUp applies all the migrations that haven't been applied to the database yet.
+func (s *Store) MigrateUp(ctx context.Context) error {
+	return s.migrate(ctx, func(conn *pgx.Conn, migrations []migration.Migration, version int) error {
+		for _, m := range migrations[version:] {
+			err := applyMigration(ctx, conn, m.Up, "INSERT INTO schema_migrations (version) VALUES ($1)", m.Version)
+			if err != nil {
+				return fmt.Errorf("failed to apply migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			s.logger.Info("applied migration", zap.Int("version", m.Version), zap.String("name", m.Name))
+		}
+
+		return nil
+	})
+}
+
+// MigrateDown reverts the last steps migrations applied to the database.
+func (s *Store) MigrateDown(ctx context.Context, steps int) error {
+	return s.migrate(ctx, func(conn *pgx.Conn, migrations []migration.Migration, version int) error {
+		for i := 0; i < steps && version > 0; i++ {
+			m := migrations[version-1]
+			err := applyMigration(ctx, conn, m.Down, "DELETE FROM schema_migrations WHERE version = $1", m.Version)
+			if err != nil {
+				return fmt.Errorf("failed to revert migration %d (%s): %w", m.Version, m.Name, err)
+			}
+			s.logger.Info("reverted migration", zap.Int("version", m.Version), zap.String("name", m.Name))
+
+			version--
+		}
+
+		return nil
+	})
+}
+
+// migrate calls fn with the embedded migrations and the current schema version while holding a lock,
+// so that several instances starting at the same time don't apply the same migrations.
+func (s *Store) migrate(ctx context.Context, fn func(conn *pgx.Conn, migrations []migration.Migration, version int) error) error {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	conn, err := s.pool.Acquire(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to acquire connection: %w", err)
+	}
+	defer conn.Release()
+
+	_, err = conn.Exec(ctx, "SELECT pg_advisory_lock($1)", migrationsLockID)
+	if err != nil {
+		return fmt.Errorf("failed to acquire migrations lock: %w", err)
+	}
+	defer func() {
+		_, err := conn.Exec(context.Background(), "SELECT pg_advisory_unlock($1)", migrationsLockID)
+		if err != nil {
+			s.logger.Error("failed to release migrations lock", zap.Error(err))
+		}
+	}()
+
+	_, err = conn.Exec(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version integer NOT NULL,
+		applied_at timestamp with time zone DEFAULT (now() AT TIME ZONE 'UTC'::text) NOT NULL,
+		CONSTRAINT schema_migrations_pkey PRIMARY KEY (version)
+	)`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	version, err := schemaVersion(ctx, conn.Conn())
+	if err != nil {
+		return err
+	}
+	if version > len(migrations) {
+		return fmt.Errorf("%w: database is at version %d, latest known is %d", ErrSchemaTooNew, version, len(migrations))
+	}
+
+	return fn(conn.Conn(), migrations, version)
+}
+
+// checkSchemaVersion returns ErrSchemaTooNew if the database was migrated by a newer version of the
+// program, whose schema this one may not be compatible with.
+func (s *Store) checkSchemaVersion(ctx context.Context) error {
+	latest, err := s.LatestSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version > latest {
+		return fmt.Errorf("%w: database is at version %d, latest known is %d", ErrSchemaTooNew, version, latest)
+	}
+
+	return nil
+}
+
+func schemaVersion(ctx context.Context, conn *pgx.Conn) (int, error) {
+	var exists bool
+	err := conn.QueryRow(ctx, "SELECT to_regclass('schema_migrations') IS NOT NULL").Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check if schema_migrations table exists: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+	err = conn.QueryRow(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+// applyMigration executes the statements of a migration and records it in the schema_migrations table
+// in a single transaction.
+func applyMigration(ctx context.Context, conn *pgx.Conn, statements, record string, version int) error {
+	return pgx.BeginFunc(ctx, conn, func(tx pgx.Tx) error {
+		_, err := tx.Exec(ctx, statements)
+		if err != nil {
+			return fmt.Errorf("failed to execute statements: %w", err)
+		}
+
+		_, err = tx.Exec(ctx, record, version)
+		if err != nil {
+			return fmt.Errorf("failed to record schema version: %w", err)
+		}
+
+		return nil
+	})
+}
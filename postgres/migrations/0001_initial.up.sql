@@ -0,0 +1,17 @@
+CREATE TABLE IF NOT EXISTS chats (
+    chat_id text NOT NULL,
+    CONSTRAINT chats_pkey PRIMARY KEY (chat_id)
+);
+
+CREATE TABLE IF NOT EXISTS messages (
+    chat_id text NOT NULL,
+    sender_id text NOT NULL,
+    message_id text NOT NULL,
+    conversation text DEFAULT ''::text NOT NULL,
+    "timestamp" timestamp with time zone DEFAULT (now() AT TIME ZONE 'UTC'::text) NOT NULL,
+    created_at timestamp with time zone DEFAULT (now() AT TIME ZONE 'UTC'::text) NOT NULL,
+    CONSTRAINT messages_pkey PRIMARY KEY (message_id),
+    CONSTRAINT messages_chats_chat_id_fk FOREIGN KEY (chat_id) REFERENCES chats (chat_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS messages_chat_id_index ON messages USING btree (chat_id);
@@ -0,0 +1,9 @@
+CREATE TABLE IF NOT EXISTS summaries (
+    chat_id text NOT NULL,
+    content text DEFAULT ''::text NOT NULL,
+    covered_until timestamp with time zone NOT NULL,
+    created_at timestamp with time zone DEFAULT (now() AT TIME ZONE 'UTC'::text) NOT NULL,
+    updated_at timestamp with time zone DEFAULT (now() AT TIME ZONE 'UTC'::text) NOT NULL,
+    CONSTRAINT summaries_pkey PRIMARY KEY (chat_id),
+    CONSTRAINT summaries_chats_chat_id_fk FOREIGN KEY (chat_id) REFERENCES chats (chat_id) ON DELETE CASCADE
+);
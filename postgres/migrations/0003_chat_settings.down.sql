@@ -0,0 +1,6 @@
+ALTER TABLE chats
+    DROP COLUMN system_prompt,
+    DROP COLUMN model,
+    DROP COLUMN temperature,
+    DROP COLUMN max_tokens,
+    DROP COLUMN language;
@@ -0,0 +1,6 @@
+ALTER TABLE chats
+    ADD COLUMN IF NOT EXISTS system_prompt text DEFAULT ''::text NOT NULL,
+    ADD COLUMN IF NOT EXISTS model text DEFAULT ''::text NOT NULL,
+    ADD COLUMN IF NOT EXISTS temperature real,
+    ADD COLUMN IF NOT EXISTS max_tokens integer DEFAULT 0 NOT NULL,
+    ADD COLUMN IF NOT EXISTS language text DEFAULT ''::text NOT NULL;
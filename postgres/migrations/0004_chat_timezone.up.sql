@@ -0,0 +1,2 @@
+ALTER TABLE chats
+    ADD COLUMN IF NOT EXISTS timezone text DEFAULT ''::text NOT NULL;
@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS messages_chat_id_segment_index;
+
+ALTER TABLE messages
+    DROP COLUMN segment;
+
+ALTER TABLE chats
+    DROP COLUMN segment;
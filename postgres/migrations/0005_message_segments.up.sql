@@ -0,0 +1,7 @@
+ALTER TABLE chats
+    ADD COLUMN IF NOT EXISTS segment integer DEFAULT 0 NOT NULL;
+
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS segment integer DEFAULT 0 NOT NULL;
+
+CREATE INDEX IF NOT EXISTS messages_chat_id_segment_index ON messages USING btree (chat_id, segment);
@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS sender_name text DEFAULT ''::text NOT NULL;
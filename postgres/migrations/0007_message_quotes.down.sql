@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    DROP COLUMN quoted_message_id;
@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS quoted_message_id text DEFAULT ''::text NOT NULL;
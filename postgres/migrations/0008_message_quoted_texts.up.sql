@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS quoted_text text DEFAULT ''::text NOT NULL;
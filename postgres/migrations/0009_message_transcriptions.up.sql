@@ -0,0 +1,2 @@
+ALTER TABLE messages
+    ADD COLUMN IF NOT EXISTS transcribed boolean DEFAULT false NOT NULL;
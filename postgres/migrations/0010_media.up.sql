@@ -0,0 +1,11 @@
+CREATE TABLE IF NOT EXISTS media (
+    message_id text NOT NULL,
+    chat_id text NOT NULL,
+    mime_type text NOT NULL,
+    data bytea NOT NULL,
+    created_at timestamp with time zone DEFAULT (now() AT TIME ZONE 'UTC'::text) NOT NULL,
+    CONSTRAINT media_pkey PRIMARY KEY (message_id),
+    CONSTRAINT media_messages_message_id_fk FOREIGN KEY (message_id) REFERENCES messages (message_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS media_chat_id_index ON media USING btree (chat_id);
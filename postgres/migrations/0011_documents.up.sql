@@ -0,0 +1,20 @@
+CREATE TABLE IF NOT EXISTS documents (
+    message_id text NOT NULL,
+    chat_id text NOT NULL,
+    segment integer DEFAULT 0 NOT NULL,
+    file_name text DEFAULT ''::text NOT NULL,
+    mime_type text NOT NULL,
+    created_at timestamp with time zone DEFAULT (now() AT TIME ZONE 'UTC'::text) NOT NULL,
+    CONSTRAINT documents_pkey PRIMARY KEY (message_id),
+    CONSTRAINT documents_messages_message_id_fk FOREIGN KEY (message_id) REFERENCES messages (message_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS documents_chat_id_segment_index ON documents USING btree (chat_id, segment);
+
+CREATE TABLE IF NOT EXISTS document_chunks (
+    message_id text NOT NULL,
+    "index" integer NOT NULL,
+    content text NOT NULL,
+    CONSTRAINT document_chunks_pkey PRIMARY KEY (message_id, "index"),
+    CONSTRAINT document_chunks_documents_message_id_fk FOREIGN KEY (message_id) REFERENCES documents (message_id) ON DELETE CASCADE
+);
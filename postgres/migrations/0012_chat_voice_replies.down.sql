@@ -0,0 +1,2 @@
+ALTER TABLE chats
+    DROP COLUMN voice_replies;
@@ -0,0 +1,2 @@
+ALTER TABLE chats
+    ADD COLUMN IF NOT EXISTS voice_replies boolean DEFAULT false NOT NULL;
@@ -0,0 +1,4 @@
+DROP TABLE tool_calls;
+
+ALTER TABLE chats
+    DROP COLUMN tools;
@@ -0,0 +1,18 @@
+ALTER TABLE chats
+    ADD COLUMN IF NOT EXISTS tools text[] DEFAULT '{}'::text[] NOT NULL;
+
+CREATE TABLE IF NOT EXISTS tool_calls (
+    message_id text NOT NULL,
+    "index" integer NOT NULL,
+    chat_id text NOT NULL,
+    call_id text DEFAULT ''::text NOT NULL,
+    name text NOT NULL,
+    arguments text DEFAULT ''::text NOT NULL,
+    result text DEFAULT ''::text NOT NULL,
+    failed boolean DEFAULT false NOT NULL,
+    created_at timestamp with time zone DEFAULT (now() AT TIME ZONE 'UTC'::text) NOT NULL,
+    CONSTRAINT tool_calls_pkey PRIMARY KEY (message_id, "index"),
+    CONSTRAINT tool_calls_messages_message_id_fk FOREIGN KEY (message_id) REFERENCES messages (message_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS tool_calls_chat_id_index ON tool_calls USING btree (chat_id);
@@ -0,0 +1,16 @@
+CREATE TABLE IF NOT EXISTS reminders (
+    reminder_id bigint GENERATED BY DEFAULT AS IDENTITY NOT NULL,
+    chat_id text NOT NULL,
+    chat_jid text NOT NULL,
+    sender_id text DEFAULT ''::text NOT NULL,
+    text text DEFAULT ''::text NOT NULL,
+    due_at timestamp with time zone NOT NULL,
+    claimed_until timestamp with time zone,
+    sent_at timestamp with time zone,
+    created_at timestamp with time zone DEFAULT (now() AT TIME ZONE 'UTC'::text) NOT NULL,
+    CONSTRAINT reminders_pkey PRIMARY KEY (reminder_id),
+    CONSTRAINT reminders_chats_chat_id_fk FOREIGN KEY (chat_id) REFERENCES chats (chat_id) ON DELETE CASCADE
+);
+
+CREATE INDEX IF NOT EXISTS reminders_chat_id_index ON reminders USING btree (chat_id);
+CREATE INDEX IF NOT EXISTS reminders_due_at_index ON reminders USING btree (due_at) WHERE sent_at IS NULL;
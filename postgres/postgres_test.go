@@ -0,0 +1,162 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+	"github.com/happybydefault/chatbot/data/datatest"
+)
+
+// testDatabaseURLEnv is the environment variable with the URL of the Postgres database that the tests
+// use. The tests delete all the data in it. Without it, they are skipped.
+const testDatabaseURLEnv = "CHATBOT_TEST_POSTGRES_URL"
+
+func TestStore(t *testing.T) {
+	connString := os.Getenv(testDatabaseURLEnv)
+	if connString == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	ctx := context.Background()
+
+	store, err := NewStore(ctx, connString, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to construct store: %s", err)
+	}
+	t.Cleanup(store.Close)
+
+	err = store.MigrateUp(ctx)
+	if err != nil {
+		t.Fatalf("failed to migrate database: %s", err)
+	}
+
+	datatest.TestStore(t, func(t *testing.T, chats ...data.Chat) data.Store {
+		t.Helper()
+
+		_, err := store.pool.Exec(
+			ctx,
+			"TRUNCATE chats, messages, summaries, media, documents, document_chunks, tool_calls, reminders",
+		)
+		if err != nil {
+			t.Fatalf("failed to truncate tables: %s", err)
+		}
+
+		createChats(t, store, chats)
+
+		return store
+	})
+}
+
+func createChats(t *testing.T, store data.Store, chats []data.Chat) {
+	t.Helper()
+
+	ctx := context.Background()
+	tx, err := store.BeginTx(ctx, sql.TxOptions{Isolation: sql.LevelReadCommitted, ReadOnly: false})
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %s", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	for _, chat := range chats {
+		err := store.CreateChat(ctx, tx, chat)
+		if err != nil {
+			t.Fatalf("failed to create chat: %s", err)
+		}
+	}
+
+	err = tx.Commit(ctx)
+	if err != nil {
+		t.Fatalf("failed to commit transaction: %s", err)
+	}
+}
+
+func TestLoadMigrations(t *testing.T) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		t.Fatalf("failed to load embedded migrations: %s", err)
+	}
+	if len(migrations) == 0 {
+		t.Fatal("got no migrations")
+	}
+	for _, m := range migrations {
+		if strings.TrimSpace(m.Up) == "" || strings.TrimSpace(m.Down) == "" {
+			t.Errorf("got migration %d (%s) without statements", m.Version, m.Name)
+		}
+	}
+}
+
+func TestMigrate(t *testing.T) {
+	connString := os.Getenv(testDatabaseURLEnv)
+	if connString == "" {
+		t.Skipf("%s is not set", testDatabaseURLEnv)
+	}
+
+	ctx := context.Background()
+
+	store, err := NewStore(ctx, connString, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to construct store: %s", err)
+	}
+	t.Cleanup(store.Close)
+
+	latest, err := store.LatestSchemaVersion()
+	if err != nil {
+		t.Fatalf("failed to get latest schema version: %s", err)
+	}
+
+	assertVersion := func(want int) {
+		t.Helper()
+
+		version, err := store.SchemaVersion(ctx)
+		if err != nil {
+			t.Fatalf("failed to get schema version: %s", err)
+		}
+		if version != want {
+			t.Fatalf("got schema version %d, want %d", version, want)
+		}
+	}
+
+	err = store.MigrateUp(ctx)
+	if err != nil {
+		t.Fatalf("failed to migrate up: %s", err)
+	}
+	assertVersion(latest)
+
+	err = store.MigrateDown(ctx, latest)
+	if err != nil {
+		t.Fatalf("failed to migrate down: %s", err)
+	}
+	assertVersion(0)
+
+	err = store.MigrateUp(ctx)
+	if err != nil {
+		t.Fatalf("failed to migrate up again: %s", err)
+	}
+	assertVersion(latest)
+
+	// A database migrated by a newer version of the program is refused.
+	_, err = store.pool.Exec(ctx, "INSERT INTO schema_migrations (version) VALUES ($1)", latest+1)
+	if err != nil {
+		t.Fatalf("failed to record newer schema version: %s", err)
+	}
+	t.Cleanup(func() {
+		_, err := store.pool.Exec(ctx, "DELETE FROM schema_migrations WHERE version = $1", latest+1)
+		if err != nil {
+			t.Errorf("failed to delete newer schema version: %s", err)
+		}
+	})
+
+	err = store.MigrateUp(ctx)
+	if !errors.Is(err, ErrSchemaTooNew) {
+		t.Errorf("got error %v, want %v", err, ErrSchemaTooNew)
+	}
+}
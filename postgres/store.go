@@ -17,16 +17,26 @@ type Store struct {
 	logger *zap.Logger
 }
 
+// NewStore constructs a Store, failing with ErrSchemaTooNew if the database was migrated by a newer
+// version of the program. Migrations are applied with MigrateUp.
 func NewStore(ctx context.Context, connString string, logger *zap.Logger) (*Store, error) {
 	pool, err := pgxpool.New(ctx, connString)
 	if err != nil {
 		return nil, fmt.Errorf("failed to construct Postgres connection pool: %w", err)
 	}
 
-	return &Store{
+	store := &Store{
 		pool:   pool,
 		logger: logger,
-	}, nil
+	}
+
+	err = store.checkSchemaVersion(ctx)
+	if err != nil {
+		pool.Close()
+		return nil, fmt.Errorf("failed to check schema version: %w", err)
+	}
+
+	return store, nil
 }
 
 func (s *Store) BeginTx(ctx context.Context, options sql.TxOptions) (data.Tx, error) {
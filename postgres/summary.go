@@ -0,0 +1,72 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jackc/pgx/v5"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Summary(ctx context.Context, tx data.Tx, chatID string) (data.Summary, error) {
+	query := `SELECT chat_id, content, covered_until, created_at, updated_at
+			  FROM summaries
+			  WHERE chat_id = $1
+			  LIMIT 1`
+
+	row := tx.QueryRow(ctx, query, chatID)
+
+	var summary data.Summary
+	err := row.Scan(
+		&summary.ChatID,
+		&summary.Content,
+		&summary.CoveredUntil,
+		&summary.CreatedAt,
+		&summary.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return data.Summary{}, data.ErrNotFound
+		}
+		return data.Summary{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return summary, nil
+}
+
+func (s *Store) UpsertSummary(ctx context.Context, tx data.Tx, summary data.Summary) error {
+	query := `INSERT INTO summaries (chat_id, content, covered_until, created_at, updated_at)
+			  VALUES ($1, $2, $3, $4, $5)
+			  ON CONFLICT (chat_id) DO UPDATE
+			  SET content = excluded.content,
+			      covered_until = excluded.covered_until,
+			      updated_at = excluded.updated_at`
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		summary.ChatID,
+		summary.Content,
+		summary.CoveredUntil,
+		summary.CreatedAt,
+		summary.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteSummary(ctx context.Context, tx data.Tx, chatID string) error {
+	query := "DELETE FROM summaries WHERE chat_id = $1"
+
+	_, err := tx.Exec(ctx, query, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
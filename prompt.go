@@ -0,0 +1,83 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// PromptData is the data that system prompt templates are executed with, e.g.:
+//
+//	You are {{.BotName}}. It is {{.Now.Format "Monday 15:04"}} and you are talking to {{.SenderPushName}}.
+type PromptData struct {
+	ChatID         string
+	SenderPushName string    // Push name of the sender of the message being responded.
+	Now            time.Time // In the timezone of the chat.
+	BotName        string
+	IsGroup        bool
+	GroupSubject   string // Empty if the chat is not a group.
+	MessageCount   int    // Number of messages of the current conversation, including summarized ones.
+}
+
+// ParsePromptTemplate parses a system prompt template written with text/template syntax and checks
+// that it can be executed with PromptData, so that broken templates are rejected before they are used.
+func ParsePromptTemplate(name, text string) (*template.Template, error) {
+	tmpl, err := template.New(name).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template: %w", err)
+	}
+
+	err = tmpl.Execute(io.Discard, PromptData{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute template: %w", err)
+	}
+
+	return tmpl, nil
+}
+
+func executePromptTemplate(tmpl *template.Template, promptData PromptData) (string, error) {
+	var sb strings.Builder
+	err := tmpl.Execute(&sb, promptData)
+	if err != nil {
+		return "", err
+	}
+
+	return sb.String(), nil
+}
+
+// validateChats checks that the settings of every chat in the data store can be used, so that a broken
+// template or timezone makes the client fail at startup instead of at the first message of the chat.
+func (c *Client) validateChats(ctx context.Context) error {
+	var chats []data.Chat
+	err := c.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  true,
+	}, func(tx data.Tx) error {
+		var err error
+
+		chats, err = c.store.Chats(ctx, tx)
+		if err != nil {
+			return fmt.Errorf("failed to get chats from data store: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	for _, chat := range chats {
+		_, err := c.chatSettings(chat)
+		if err != nil {
+			return fmt.Errorf("invalid settings of chat %q: %w", chat.ID, err)
+		}
+	}
+
+	return nil
+}
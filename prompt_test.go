@@ -0,0 +1,85 @@
+package chatbot
+
+import (
+	"context"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestParsePromptTemplate(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		wantErr bool
+	}{
+		{name: "plain text", text: "You are a helpful assistant."},
+		{name: "variables", text: `You are {{.BotName}}. It is {{.Now.Format "15:04"}}.{{if .IsGroup}} {{.GroupSubject}}{{end}}`},
+		{name: "syntax error", text: "You are {{.BotName", wantErr: true},
+		{name: "unknown variable", text: "You are {{.Name}}.", wantErr: true},
+		{name: "unknown function", text: "You are {{upper .BotName}}.", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParsePromptTemplate("test", tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %t", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// TestChatRespondMessageCount checks that the message count of the system prompt only includes the
+// messages of the current conversation.
+func TestChatRespondMessageCount(t *testing.T) {
+	tests := []struct {
+		name  string
+		reset bool
+		want  string
+	}{
+		{name: "whole conversation", want: "Chatbot has 3 messages with User."},
+		{name: "after reset", reset: true, want: "Chatbot has 1 messages with User."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			completer := &fakeCompleter{content: "Hello!"}
+			chat, _ := newTestChat(t, &fakeWhatsApp{}, completer)
+
+			systemPrompt, err := ParsePromptTemplate("test", "{{.BotName}} has {{.MessageCount}} messages with {{.SenderPushName}}.")
+			if err != nil {
+				t.Fatalf("failed to parse system prompt: %s", err)
+			}
+			chat.client.systemPrompt = systemPrompt
+
+			createMessages(t, chat, testUserID, testBotID)
+			if tt.reset {
+				_, err := chat.runResetCommand(ctx, message{}, "")
+				if err != nil {
+					t.Fatalf("failed to reset chat: %s", err)
+				}
+			}
+
+			msg := newTestMessage("trigger", &waProto.Message{Conversation: proto.String("Hi")})
+			err = chat.storeMessageReceived(ctx, msg)
+			if err != nil {
+				t.Fatalf("failed to store message: %s", err)
+			}
+
+			err = chat.respond(msg.Message)
+			if err != nil {
+				t.Fatalf("failed to respond: %s", err)
+			}
+
+			if len(completer.requests) != 1 {
+				t.Fatalf("got %d completion requests, want 1", len(completer.requests))
+			}
+			if got := completer.requests[0].Messages[0].Content; got != tt.want {
+				t.Errorf("got system message %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,112 @@
+package chatbot
+
+import (
+	"fmt"
+	"strings"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// QuoteMode selects the responses of the chatbot that quote the message they respond.
+type QuoteMode int
+
+const (
+	// QuoteGroups quotes the responses in group chats only, where other messages are usually sent
+	// between a message and its response.
+	QuoteGroups QuoteMode = iota
+	QuoteAlways
+	QuoteNever
+)
+
+// maxQuoteLength is the maximum number of characters of a quoted message that are included in the
+// prompt before the message that replies to it.
+const maxQuoteLength = 200
+
+// ParseQuoteMode parses "groups", "always" or "never" into a QuoteMode.
+func ParseQuoteMode(s string) (QuoteMode, error) {
+	switch s {
+	case "groups":
+		return QuoteGroups, nil
+	case "always":
+		return QuoteAlways, nil
+	case "never":
+		return QuoteNever, nil
+	default:
+		return 0, fmt.Errorf("unknown quote mode %q", s)
+	}
+}
+
+func (m QuoteMode) quotes(trigger *events.Message) bool {
+	switch m {
+	case QuoteAlways:
+		return true
+	case QuoteGroups:
+		return trigger.Info.IsGroup
+	default:
+		return false
+	}
+}
+
+// textMessage returns a message with text, which quotes the given message if it's not nil.
+func textMessage(text string, quoted *events.Message) *waProto.Message {
+	if quoted != nil {
+		return quotedTextMessage(text, quoted)
+	}
+
+	return &waProto.Message{
+		Conversation: proto.String(text),
+	}
+}
+
+// quotedTextMessage returns a message with text that quotes the given one, so that WhatsApp shows it
+// as a reply to it.
+func quotedTextMessage(text string, quoted *events.Message) *waProto.Message {
+	return &waProto.Message{
+		ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text:        proto.String(text),
+			ContextInfo: quoteContextInfo(quoted),
+		},
+	}
+}
+
+// quoteContextInfo returns the context info of a message that quotes the given one, for the message
+// types other than text.
+func quoteContextInfo(quoted *events.Message) *waProto.ContextInfo {
+	return &waProto.ContextInfo{
+		StanzaId:      proto.String(quoted.Info.ID),
+		Participant:   proto.String(quoted.Info.Sender.ToNonAD().String()),
+		QuotedMessage: quoted.Message,
+	}
+}
+
+// quoteContent returns the content of msg in a prompt, preceded by an excerpt of the message that it
+// replies to when that one is not right before it, so that the model can follow the thread.
+func quoteContent(content string, msg data.Message, previous []data.Message) string {
+	if msg.QuotedID == "" || len(previous) > 0 && previous[len(previous)-1].ID == msg.QuotedID {
+		return content
+	}
+
+	// The quoted message may not be in the prompt, e.g. if it's from a previous segment, so its text
+	// is stored with the reply too.
+	quotedText := msg.QuotedText
+	for i := len(previous) - 1; i >= 0; i-- {
+		if previous[i].ID == msg.QuotedID {
+			quotedText = previous[i].Conversation
+			break
+		}
+	}
+	if quotedText == "" {
+		return content
+	}
+
+	quoted := []rune(strings.ReplaceAll(quotedText, "\n", " "))
+	if len(quoted) > maxQuoteLength {
+		quoted = append(quoted[:maxQuoteLength], '…')
+	}
+
+	return fmt.Sprintf("(In reply to: %q)\n%s", string(quoted), content)
+}
@@ -0,0 +1,163 @@
+package chatbot
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func TestParseQuoteMode(t *testing.T) {
+	tests := []struct {
+		s       string
+		want    QuoteMode
+		wantErr bool
+	}{
+		{s: "groups", want: QuoteGroups},
+		{s: "always", want: QuoteAlways},
+		{s: "never", want: QuoteNever},
+		{s: "sometimes", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseQuoteMode(tt.s)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("got error %v for %q, want error %t", err, tt.s, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("got %d for %q, want %d", got, tt.s, tt.want)
+		}
+	}
+}
+
+func TestQuoteModeQuotes(t *testing.T) {
+	tests := []struct {
+		mode  QuoteMode
+		group bool
+		want  bool
+	}{
+		{mode: QuoteGroups, group: true, want: true},
+		{mode: QuoteGroups, group: false, want: false},
+		{mode: QuoteAlways, group: false, want: true},
+		{mode: QuoteNever, group: true, want: false},
+	}
+
+	for _, tt := range tests {
+		trigger := &events.Message{Info: types.MessageInfo{MessageSource: types.MessageSource{IsGroup: tt.group}}}
+
+		got := tt.mode.quotes(trigger)
+		if got != tt.want {
+			t.Errorf("got %t for mode %d in group %t, want %t", got, tt.mode, tt.group, tt.want)
+		}
+	}
+}
+
+func TestTextMessage(t *testing.T) {
+	if msg := textMessage("Hi", nil); msg.GetConversation() != "Hi" {
+		t.Errorf("got message %v, want a plain conversation", msg)
+	}
+
+	quoted := newTestMessage("1", &waProto.Message{Conversation: proto.String("Hello")})
+	quoted.Info.Sender.Device = 2
+
+	content := parseMessageContent(textMessage("Hi", quoted.Message))
+	if content.text != "Hi" || content.quotedID != "1" || content.quotedText != "Hello" ||
+		content.quotedParticipant != testUserID+"@s.whatsapp.net" {
+		t.Errorf("got content %+v, want a reply to the message from the sender without device", content)
+	}
+}
+
+func TestQuoteContent(t *testing.T) {
+	previous := []data.Message{
+		{ID: "1", Conversation: "First\nmessage"},
+		{ID: "2", Conversation: "Second message"},
+	}
+
+	tests := []struct {
+		name     string
+		message  data.Message
+		previous []data.Message
+		want     string
+	}{
+		{
+			name:     "not a reply",
+			message:  data.Message{ID: "3"},
+			previous: previous,
+			want:     "Hi",
+		},
+		{
+			name:     "reply to previous message",
+			message:  data.Message{ID: "3", QuotedID: "2", QuotedText: "Second message"},
+			previous: previous,
+			want:     "Hi",
+		},
+		{
+			name:     "reply to earlier message",
+			message:  data.Message{ID: "3", QuotedID: "1", QuotedText: "Stored text"},
+			previous: previous,
+			want:     "(In reply to: \"First message\")\nHi",
+		},
+		{
+			name:     "reply to message out of prompt",
+			message:  data.Message{ID: "3", QuotedID: "0", QuotedText: "Old message"},
+			previous: previous,
+			want:     "(In reply to: \"Old message\")\nHi",
+		},
+		{
+			name:    "reply to message without text",
+			message: data.Message{ID: "3", QuotedID: "0"},
+			want:    "Hi",
+		},
+		{
+			name:    "long quote",
+			message: data.Message{ID: "3", QuotedID: "0", QuotedText: strings.Repeat("a", maxQuoteLength+1)},
+			want:    "(In reply to: \"" + strings.Repeat("a", maxQuoteLength) + "…\")\nHi",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := quoteContent("Hi", tt.message, tt.previous)
+			if got != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatRespondQuoting(t *testing.T) {
+	ctx := context.Background()
+	whatsApp := &fakeWhatsApp{}
+	chat, dataStore := newTestChat(t, whatsApp, &fakeCompleter{content: "Hello, User!"})
+	chat.client.quoteMode = QuoteAlways
+
+	msg := newTestMessage("1", &waProto.Message{Conversation: proto.String("Hi there")})
+	err := chat.storeMessageReceived(ctx, msg)
+	if err != nil {
+		t.Fatalf("failed to store message: %s", err)
+	}
+
+	err = chat.respond(msg.Message)
+	if err != nil {
+		t.Fatalf("failed to respond: %s", err)
+	}
+
+	whatsApp.mu.Lock()
+	sent := whatsApp.sent
+	whatsApp.mu.Unlock()
+	if len(sent) != 1 || sent[0].GetExtendedTextMessage().GetContextInfo().GetStanzaId() != "1" {
+		t.Errorf("sent %v, want a reply to the message", sent)
+	}
+
+	messages := storedMessages(t, dataStore)
+	if len(messages) != 2 || messages[1].QuotedID != "1" || messages[1].QuotedText != "Hi there" {
+		t.Errorf("got stored messages %+v, want the response replying to the message", messages)
+	}
+}
@@ -0,0 +1,378 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types"
+	"go.uber.org/zap"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+const (
+	// reminderInterval is how often the due reminders are checked, so they are sent at most this late.
+	reminderInterval = 15 * time.Second
+
+	// reminderClaimTimeout is how long the reminders claimed by an instance of the chatbot are
+	// reserved for it. The ones that it fails to send are claimed again after that.
+	reminderClaimTimeout = 5 * time.Minute
+
+	// reminderBatchSize is the maximum number of reminders claimed at once.
+	reminderBatchSize = 20
+
+	// reminderTimeout is the time that every reminder has to be sent and marked as sent, once its chat
+	// isn't responding anymore.
+	reminderTimeout = 30 * time.Second
+
+	// maxPendingReminders is the maximum number of reminders of a chat that haven't been sent.
+	maxPendingReminders = 20
+
+	// reminderTimeLayout is the layout of the times of reminders in the results of the reminders tool.
+	reminderTimeLayout = "Monday, 2 January 2006 15:04 MST"
+)
+
+// remindersTool returns the tool that creates, lists and cancels the reminders of the chat where it's
+// called.
+func (c *Client) remindersTool() Tool {
+	return Tool{
+		ToolDefinition: ToolDefinition{
+			Name: "reminders",
+			Description: "Create, list or cancel reminders, which are sent to this chat at the given time." +
+				" Times without an offset are in the timezone of the chat.",
+			Parameters: json.RawMessage(`{
+				"type": "object",
+				"properties": {
+					"action": {
+						"type": "string",
+						"enum": ["create", "list", "cancel"]
+					},
+					"text": {
+						"type": "string",
+						"description": "Text of the reminder to create"
+					},
+					"time": {
+						"type": "string",
+						"description": "Date and time of the reminder to create, e.g. 2006-01-02T15:04 or 2006-01-02T15:04:05-07:00"
+					},
+					"in_minutes": {
+						"type": "integer",
+						"description": "Minutes from now of the reminder to create, instead of time"
+					},
+					"id": {
+						"type": "integer",
+						"description": "ID of the reminder to cancel"
+					}
+				},
+				"required": ["action"]
+			}`),
+		},
+		Run: c.runRemindersTool,
+	}
+}
+
+func (c *Client) runRemindersTool(ctx context.Context, input ToolInput) (string, error) {
+	var arguments struct {
+		Action    string `json:"action"`
+		Text      string `json:"text"`
+		Time      string `json:"time"`
+		InMinutes int    `json:"in_minutes"`
+		ID        int64  `json:"id"`
+	}
+	err := json.Unmarshal(input.Arguments, &arguments)
+	if err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	switch arguments.Action {
+	case "create":
+		text := strings.TrimSpace(arguments.Text)
+		if text == "" {
+			return "", errors.New("missing text")
+		}
+
+		var dueAt time.Time
+		switch {
+		case arguments.InMinutes > 0:
+			dueAt = time.Now().Add(time.Duration(arguments.InMinutes) * time.Minute)
+		case arguments.Time != "":
+			dueAt, err = parseReminderTime(arguments.Time, input.Location)
+			if err != nil {
+				return "", err
+			}
+		default:
+			return "", errors.New("missing time or in_minutes")
+		}
+		if !dueAt.After(time.Now()) {
+			return "", fmt.Errorf("%s is in the past", dueAt.In(input.Location).Format(reminderTimeLayout))
+		}
+
+		return c.createReminder(ctx, input, text, dueAt)
+	case "list":
+		return c.listReminders(ctx, input)
+	case "cancel":
+		return c.cancelReminder(ctx, input, arguments.ID)
+	default:
+		return "", fmt.Errorf(`%q is neither "create", "list" nor "cancel"`, arguments.Action)
+	}
+}
+
+func (c *Client) createReminder(ctx context.Context, input ToolInput, text string, dueAt time.Time) (string, error) {
+	var id int64
+	err := c.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		reminders, err := c.store.Reminders(ctx, tx, input.ChatID)
+		if err != nil {
+			return fmt.Errorf("failed to get reminders from data store: %w", err)
+		}
+		if len(reminders) >= maxPendingReminders {
+			return fmt.Errorf("this chat already has %d reminders", len(reminders))
+		}
+
+		id, err = c.store.CreateReminder(ctx, tx, data.Reminder{
+			ChatID:    input.ChatID,
+			ChatJID:   input.ChatJID,
+			SenderID:  input.SenderID,
+			Text:      text,
+			DueAt:     dueAt.UTC(),
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create reminder in data store: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	return fmt.Sprintf("Created reminder %d for %s.", id, dueAt.In(input.Location).Format(reminderTimeLayout)), nil
+}
+
+func (c *Client) listReminders(ctx context.Context, input ToolInput) (string, error) {
+	var reminders []data.Reminder
+	err := c.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  true,
+	}, func(tx data.Tx) error {
+		var err error
+		reminders, err = c.store.Reminders(ctx, tx, input.ChatID)
+		if err != nil {
+			return fmt.Errorf("failed to get reminders from data store: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	if len(reminders) == 0 {
+		return "There are no reminders.", nil
+	}
+
+	var sb strings.Builder
+	for i, reminder := range reminders {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		fmt.Fprintf(
+			&sb,
+			"Reminder %d, for %s: %s",
+			reminder.ID,
+			reminder.DueAt.In(input.Location).Format(reminderTimeLayout),
+			reminder.Text,
+		)
+	}
+
+	return sb.String(), nil
+}
+
+func (c *Client) cancelReminder(ctx context.Context, input ToolInput, id int64) (string, error) {
+	if id == 0 {
+		return "", errors.New("missing id")
+	}
+
+	err := c.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		return c.store.DeleteReminder(ctx, tx, input.ChatID, id)
+	})
+	if errors.Is(err, data.ErrNotFound) {
+		return "", fmt.Errorf("reminder %d doesn't exist or was already sent", id)
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to delete reminder from data store: %w", err)
+	}
+
+	return fmt.Sprintf("Canceled reminder %d.", id), nil
+}
+
+// parseReminderTime parses a date and time in RFC 3339 format, or without its offset or seconds, in
+// which case it's in location.
+func parseReminderTime(s string, location *time.Location) (time.Time, error) {
+	s = strings.TrimSpace(s)
+
+	t, err := time.Parse(time.RFC3339, s)
+	if err == nil {
+		return t, nil
+	}
+
+	for _, layout := range []string{"2006-01-02T15:04:05", "2006-01-02T15:04", "2006-01-02 15:04:05", "2006-01-02 15:04"} {
+		t, err := time.ParseInLocation(layout, s, location)
+		if err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("%q is not a date and time like 2006-01-02T15:04", s)
+}
+
+// sendReminders sends the due reminders every reminderInterval until the client is stopped.
+func (c *Client) sendReminders() {
+	defer c.wg.Done()
+
+	ticker := time.NewTicker(reminderInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-c.stopChan:
+			return
+		case <-ticker.C:
+		}
+
+		err := c.sendDueReminders()
+		if err != nil {
+			c.logger.Error("failed to send due reminders", zap.Error(err))
+		}
+	}
+}
+
+// sendDueReminders claims the reminders that are due and sends them. The reminders are claimed before
+// they are sent, so that other instances of the chatbot don't send them too, and they are marked as
+// sent once they are, so the ones that fail are sent by the next claim after reminderClaimTimeout.
+// While the chatbot is paused, reminders are left pending.
+func (c *Client) sendDueReminders() error {
+	if !c.whatsmeowClient.IsLoggedIn() || c.paused.Load() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	now := time.Now()
+
+	var reminders []data.Reminder
+	err := c.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		var err error
+		reminders, err = c.store.ClaimReminders(ctx, tx, now, now.Add(reminderClaimTimeout), reminderBatchSize)
+		if err != nil {
+			return fmt.Errorf("failed to claim reminders in data store: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	for _, reminder := range reminders {
+		logger := c.logger.With(zap.String("chat_id", reminder.ChatID), zap.Int64("reminder_id", reminder.ID))
+
+		jid, err := types.ParseJID(reminder.ChatJID)
+		if err != nil {
+			logger.Error("failed to parse JID of reminder", zap.Error(err))
+			continue
+		}
+
+		err = c.getChat(jid).sendReminder(reminder)
+		if err != nil {
+			logger.Error("failed to send reminder", zap.Error(err))
+			continue
+		}
+		logger.Info("sent reminder")
+		metricSentReminders.Add(1)
+	}
+
+	return nil
+}
+
+// sendReminder sends a reminder to the chat, mentioning the user who asked for it in groups, and
+// stores it as a message of the chatbot. It waits for the chat to finish responding, so its timeout
+// only starts after that.
+func (c *Chat) sendReminder(reminder data.Reminder) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	ctx, cancel := context.WithTimeout(context.Background(), reminderTimeout)
+	defer cancel()
+
+	text := "Reminder: " + reminder.Text
+	var contextInfo *waProto.ContextInfo
+	if c.jid.Server == types.GroupServer && reminder.SenderID != "" {
+		text = "@" + reminder.SenderID + " " + text
+		contextInfo = &waProto.ContextInfo{
+			MentionedJid: []string{types.NewJID(reminder.SenderID, types.DefaultUserServer).String()},
+		}
+	}
+
+	report, err := c.client.whatsmeowClient.SendMessage(ctx, c.jid, "", &waProto.Message{
+		ExtendedTextMessage: &waProto.ExtendedTextMessage{
+			Text:        proto.String(text),
+			ContextInfo: contextInfo,
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to send message: %w", err)
+	}
+
+	// If this fails, the reminder is sent again after its claim expires.
+	err = c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		err := c.client.store.MarkReminderSent(ctx, tx, reminder.ID, time.Now())
+		if err != nil {
+			return fmt.Errorf("failed to mark reminder as sent in data store: %w", err)
+		}
+
+		chat, err := c.client.store.Chat(ctx, tx, c.id)
+		if err != nil {
+			return fmt.Errorf("failed to get chat from data store: %w", err)
+		}
+
+		err = c.client.store.CreateMessage(ctx, tx, data.Message{
+			ID:           report.ID,
+			ChatID:       c.id,
+			SenderID:     c.client.botID(),
+			SenderName:   c.client.botName,
+			Conversation: text,
+			Segment:      chat.Segment,
+			Timestamp:    report.Timestamp,
+			CreatedAt:    time.Now(),
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create message from chatbot in data store: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"go.mau.fi/whatsmeow/types"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func TestParseReminderTime(t *testing.T) {
+	madrid, err := time.LoadLocation("Europe/Madrid")
+	if err != nil {
+		t.Fatalf("failed to load location: %s", err)
+	}
+
+	tests := []struct {
+		s       string
+		want    time.Time
+		wantErr bool
+	}{
+		{s: "2030-01-02T15:04:05-07:00", want: time.Date(2030, 1, 2, 22, 4, 5, 0, time.UTC)},
+		{s: "2030-01-02T15:04:05Z", want: time.Date(2030, 1, 2, 15, 4, 5, 0, time.UTC)},
+		{s: "2030-01-02T15:04:05", want: time.Date(2030, 1, 2, 15, 4, 5, 0, madrid)},
+		{s: "2030-01-02T15:04", want: time.Date(2030, 1, 2, 15, 4, 0, 0, madrid)},
+		{s: " 2030-07-02 15:04 ", want: time.Date(2030, 7, 2, 15, 4, 0, 0, madrid)},
+		{s: "2030-01-02 15:04:05", want: time.Date(2030, 1, 2, 15, 4, 5, 0, madrid)},
+		{s: "tomorrow at 9", wantErr: true},
+		{s: "2030-01-02", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			got, err := parseReminderTime(tt.s, madrid)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && !got.Equal(tt.want) {
+				t.Errorf("got %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// runReminders calls the reminders tool of the chat of newTestChat with the given arguments.
+func runReminders(t *testing.T, chat *Chat, arguments string) (string, error) {
+	t.Helper()
+
+	return chat.client.runRemindersTool(context.Background(), ToolInput{
+		ChatID:    chat.id,
+		ChatJID:   chat.jid.String(),
+		SenderID:  testUserID,
+		Location:  time.UTC,
+		Arguments: json.RawMessage(arguments),
+	})
+}
+
+func TestRemindersTool(t *testing.T) {
+	chat, _ := newTestChat(t, &fakeWhatsApp{}, &fakeCompleter{})
+
+	result, err := runReminders(t, chat, `{"action": "list"}`)
+	if err != nil || result != "There are no reminders." {
+		t.Fatalf("got result %q and error %v, want no reminders", result, err)
+	}
+
+	result, err = runReminders(t, chat, `{"action": "create", "text": "Call the bank", "in_minutes": 30}`)
+	if err != nil || !strings.HasPrefix(result, "Created reminder 1 for ") {
+		t.Fatalf("got result %q and error %v, want the created reminder", result, err)
+	}
+
+	result, err = runReminders(t, chat, `{"action": "create", "text": "Water the plants", "time": "2999-01-02T09:00"}`)
+	if err != nil || result != "Created reminder 2 for Wednesday, 2 January 2999 09:00 UTC." {
+		t.Fatalf("got result %q and error %v, want the created reminder", result, err)
+	}
+
+	result, err = runReminders(t, chat, `{"action": "list"}`)
+	if err != nil {
+		t.Fatalf("failed to list reminders: %s", err)
+	}
+	lines := strings.Split(result, "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], ": Call the bank") ||
+		lines[1] != "Reminder 2, for Wednesday, 2 January 2999 09:00 UTC: Water the plants" {
+		t.Errorf("got list %q, want both reminders by due time", result)
+	}
+
+	result, err = runReminders(t, chat, `{"action": "cancel", "id": 1}`)
+	if err != nil || result != "Canceled reminder 1." {
+		t.Fatalf("got result %q and error %v, want the canceled reminder", result, err)
+	}
+
+	result, err = runReminders(t, chat, `{"action": "list"}`)
+	if err != nil || strings.Contains(result, "Call the bank") {
+		t.Errorf("got list %q and error %v, want only the reminder that wasn't canceled", result, err)
+	}
+
+	for _, arguments := range []string{
+		`{"action": "cancel", "id": 1}`,
+		`{"action": "cancel"}`,
+		`{"action": "create", "time": "2999-01-02T09:00"}`,
+		`{"action": "create", "text": "Too late", "time": "2000-01-02T09:00"}`,
+		`{"action": "create", "text": "When?"}`,
+		`{"action": "snooze"}`,
+		`not JSON`,
+	} {
+		_, err := runReminders(t, chat, arguments)
+		if err == nil {
+			t.Errorf("got no error for arguments %s", arguments)
+		}
+	}
+}
+
+func TestSendDueReminders(t *testing.T) {
+	ctx := context.Background()
+	whatsApp := &fakeWhatsApp{}
+	chat, dataStore := newTestChat(t, whatsApp, &fakeCompleter{})
+	chat.client.chats[chat.jid.User] = chat
+
+	err := chat.client.execTx(ctx, sql.TxOptions{}, func(tx data.Tx) error {
+		for _, reminder := range []data.Reminder{
+			{Text: "Call the bank", DueAt: time.Now().Add(-time.Minute)},
+			{Text: "Water the plants", DueAt: time.Now().Add(time.Hour)},
+		} {
+			reminder.ChatID = chat.id
+			reminder.ChatJID = chat.jid.String()
+			reminder.SenderID = testUserID
+			reminder.CreatedAt = time.Now()
+
+			_, err := dataStore.CreateReminder(ctx, tx, reminder)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to create reminders: %s", err)
+	}
+
+	for i := 0; i < 2; i++ {
+		err = chat.client.sendDueReminders()
+		if err != nil {
+			t.Fatalf("failed to send due reminders: %s", err)
+		}
+	}
+
+	sent := whatsApp.sentTexts()
+	if len(sent) != 1 || sent[0] != "Reminder: Call the bank" {
+		t.Errorf("sent %q, want the due reminder once", sent)
+	}
+
+	messages := storedMessages(t, dataStore)
+	if len(messages) != 1 || messages[0].SenderID != testBotID || messages[0].Conversation != "Reminder: Call the bank" {
+		t.Errorf("got stored messages %+v, want the sent reminder", messages)
+	}
+
+	result, err := runReminders(t, chat, `{"action": "list"}`)
+	if err != nil || strings.Contains(result, "Call the bank") || !strings.Contains(result, "Water the plants") {
+		t.Errorf("got list %q and error %v, want only the pending reminder", result, err)
+	}
+}
+
+func TestSendReminderInGroup(t *testing.T) {
+	ctx := context.Background()
+	whatsApp := &fakeWhatsApp{}
+	chat, dataStore := newTestChat(t, whatsApp, &fakeCompleter{})
+	chat.jid = types.NewJID("120363000000000000", types.GroupServer)
+
+	reminder := data.Reminder{
+		ChatID:    chat.id,
+		ChatJID:   chat.jid.String(),
+		SenderID:  testUserID,
+		Text:      "Stand-up",
+		DueAt:     time.Now(),
+		CreatedAt: time.Now(),
+	}
+	err := chat.client.execTx(ctx, sql.TxOptions{}, func(tx data.Tx) error {
+		var err error
+		reminder.ID, err = dataStore.CreateReminder(ctx, tx, reminder)
+		return err
+	})
+	if err != nil {
+		t.Fatalf("failed to create reminder: %s", err)
+	}
+
+	err = chat.sendReminder(reminder)
+	if err != nil {
+		t.Fatalf("failed to send reminder: %s", err)
+	}
+
+	if len(whatsApp.sent) != 1 {
+		t.Fatalf("sent %d messages, want 1", len(whatsApp.sent))
+	}
+	extended := whatsApp.sent[0].GetExtendedTextMessage()
+	mentioned := extended.GetContextInfo().GetMentionedJid()
+	if extended.GetText() != "@"+testUserID+" Reminder: Stand-up" ||
+		len(mentioned) != 1 || mentioned[0] != testUserID+"@"+types.DefaultUserServer {
+		t.Errorf("sent %v, want the reminder mentioning its sender", extended)
+	}
+}
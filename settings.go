@@ -0,0 +1,83 @@
+package chatbot
+
+import (
+	"fmt"
+	"text/template"
+	"time"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// DefaultSystemPrompt is the system prompt template used when neither the Config nor the chat set one.
+const DefaultSystemPrompt = "The following is a conversation with an AI called {{.BotName}}, the smartest of all beings." +
+	" The assistant is helpful, creative, clever, and very friendly."
+
+// chatSettings are the settings of a chat after falling back to the global defaults.
+type chatSettings struct {
+	systemPrompt *template.Template
+	model        string
+	temperature  *float32 // Nil for the default of the backend.
+	maxTokens    int
+	language     string
+	location     *time.Location
+}
+
+func (c *Client) chatSettings(chat data.Chat) (chatSettings, error) {
+	settings := chatSettings{
+		systemPrompt: c.systemPrompt,
+		model:        c.model,
+		temperature:  c.temperature,
+		maxTokens:    c.maxTokens,
+		location:     c.location,
+	}
+
+	if chat.SystemPrompt != "" {
+		tmpl, err := ParsePromptTemplate(chat.ID, chat.SystemPrompt)
+		if err != nil {
+			return chatSettings{}, fmt.Errorf("invalid system prompt: %w", err)
+		}
+		settings.systemPrompt = tmpl
+	}
+	if chat.Model != "" {
+		settings.model = chat.Model
+	}
+	if chat.Temperature != nil {
+		settings.temperature = chat.Temperature
+	}
+	if chat.MaxTokens > 0 {
+		settings.maxTokens = chat.MaxTokens
+	}
+	if chat.Timezone != "" {
+		location, err := time.LoadLocation(chat.Timezone)
+		if err != nil {
+			return chatSettings{}, fmt.Errorf("invalid timezone: %w", err)
+		}
+		settings.location = location
+	}
+	settings.language = chat.Language
+
+	return settings, nil
+}
+
+// systemMessage renders the system prompt of the chat. The Now field of promptData is set by this
+// method.
+func (s chatSettings) systemMessage(promptData PromptData) (CompletionMessage, error) {
+	promptData.Now = time.Now().In(s.location)
+
+	content, err := executePromptTemplate(s.systemPrompt, promptData)
+	if err != nil {
+		return CompletionMessage{}, fmt.Errorf("failed to execute system prompt template: %w", err)
+	}
+
+	if promptData.IsGroup {
+		content += " This is a group chat, where the messages of each participant start with their name."
+	}
+	if s.language != "" {
+		content += fmt.Sprintf(" Always answer in %s.", s.language)
+	}
+
+	return CompletionMessage{
+		Role:    RoleSystem,
+		Content: content,
+	}, nil
+}
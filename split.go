@@ -0,0 +1,139 @@
+package chatbot
+
+import (
+	"context"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	codeFence = "```"
+
+	// typingCharactersPerSecond is the typing speed that the delays between the parts of a response
+	// simulate, within minPartDelay and maxPartDelay.
+	typingCharactersPerSecond = 50
+	minPartDelay              = time.Second
+	maxPartDelay              = 3 * time.Second
+)
+
+// responseSeparators are the boundaries that the paragraphs longer than a message are split at, from
+// the most natural to the least: lines, sentences and words.
+var responseSeparators = []string{"\n", ". ", "? ", "! ", "; ", ", ", " "}
+
+// splitResponse splits text into parts of at most limit characters, preferably between paragraphs.
+// Code blocks are kept whole if they fit in a part, and split between lines into code blocks of their
+// own otherwise. A limit of zero means unlimited.
+func splitResponse(text string, limit int) []string {
+	if limit <= 0 || utf8.RuneCountInString(text) <= limit {
+		return []string{text}
+	}
+
+	var pieces []string
+	for _, block := range responseBlocks(text) {
+		switch {
+		case utf8.RuneCountInString(block) <= limit:
+			pieces = append(pieces, block+"\n\n")
+		case strings.HasPrefix(block, codeFence):
+			for _, codeBlock := range splitCodeBlock(block, limit) {
+				pieces = append(pieces, codeBlock+"\n\n")
+			}
+		default:
+			pieces = append(pieces, splitText(block+"\n\n", limit, responseSeparators)...)
+		}
+	}
+
+	return joinPieces(pieces, limit)
+}
+
+// responseBlocks returns the paragraphs and code blocks of text, which are separated by blank lines
+// except for the ones inside code blocks.
+func responseBlocks(text string) []string {
+	var blocks []string
+	var block []string
+	var inCode bool
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		// Fences that are closed in the same line are inline code.
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, codeFence) && strings.Count(trimmed, codeFence)%2 == 1 {
+			if !inCode && len(block) > 0 {
+				blocks = append(blocks, strings.Join(block, "\n"))
+				block = nil
+			}
+			inCode = !inCode
+		}
+
+		if !inCode && trimmed == "" {
+			if len(block) > 0 {
+				blocks = append(blocks, strings.Join(block, "\n"))
+				block = nil
+			}
+			continue
+		}
+
+		block = append(block, line)
+	}
+	if len(block) > 0 {
+		blocks = append(blocks, strings.Join(block, "\n"))
+	}
+
+	return blocks
+}
+
+// splitCodeBlock splits a code block between lines into code blocks of at most limit characters, all
+// with the opening fence of the original one.
+func splitCodeBlock(block string, limit int) []string {
+	opening, code, _ := strings.Cut(block, "\n")
+	code = strings.TrimSuffix(strings.TrimRight(code, "\n"), codeFence)
+
+	size := limit - utf8.RuneCountInString(opening) - len("\n\n"+codeFence)
+	if size <= 0 {
+		return splitText(block, limit, responseSeparators)
+	}
+
+	var codeBlocks []string
+	var current strings.Builder
+	appendCodeBlock := func() {
+		// Blank lines that are cut off from the code around them don't make code blocks of their own.
+		code := strings.TrimRight(current.String(), "\n")
+		if strings.TrimSpace(code) != "" {
+			codeBlocks = append(codeBlocks, opening+"\n"+code+"\n"+codeFence)
+		}
+		current.Reset()
+	}
+	for _, piece := range splitText(code, size, []string{"\n", " "}) {
+		if utf8.RuneCountInString(current.String())+utf8.RuneCountInString(piece) > size {
+			appendCodeBlock()
+		}
+		current.WriteString(piece)
+	}
+	appendCodeBlock()
+
+	return codeBlocks
+}
+
+// partDelay returns the time that a person would take to type part.
+func partDelay(part string) time.Duration {
+	delay := time.Duration(utf8.RuneCountInString(part)) * time.Second / typingCharactersPerSecond
+	if delay < minPartDelay {
+		return minPartDelay
+	}
+	if delay > maxPartDelay {
+		return maxPartDelay
+	}
+
+	return delay
+}
+
+// sleep waits for d, returning early with the error of ctx if it's done before.
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
@@ -0,0 +1,142 @@
+package chatbot
+
+import (
+	"reflect"
+	"testing"
+	"time"
+	"unicode/utf8"
+)
+
+func TestSplitResponse(t *testing.T) {
+	tests := []struct {
+		name  string
+		text  string
+		limit int
+		want  []string
+	}{
+		{
+			name:  "unlimited",
+			text:  "First paragraph.\n\nSecond paragraph.",
+			limit: 0,
+			want:  []string{"First paragraph.\n\nSecond paragraph."},
+		},
+		{
+			name:  "within limit",
+			text:  "First paragraph.\n\nSecond paragraph.",
+			limit: 100,
+			want:  []string{"First paragraph.\n\nSecond paragraph."},
+		},
+		{
+			name:  "paragraphs",
+			text:  "First paragraph.\n\nSecond paragraph.",
+			limit: 20,
+			want:  []string{"First paragraph.", "Second paragraph."},
+		},
+		{
+			name:  "paragraphs joined up to limit",
+			text:  "Short.\n\nAlso short.\n\nThird.",
+			limit: 25,
+			want:  []string{"Short.\n\nAlso short.", "Third."},
+		},
+		{
+			name:  "lines",
+			text:  "One line here\nTwo line here",
+			limit: 15,
+			want:  []string{"One line here", "Two line here"},
+		},
+		{
+			name:  "sentences",
+			text:  "First sentence. Second sentence.",
+			limit: 20,
+			want:  []string{"First sentence.", "Second sentence."},
+		},
+		{
+			name:  "words",
+			text:  "alpha beta gamma delta",
+			limit: 11,
+			want:  []string{"alpha beta", "gamma", "delta"},
+		},
+		{
+			name:  "hard cut",
+			text:  "abcdefghijkl",
+			limit: 5,
+			want:  []string{"abcde", "fghij", "kl"},
+		},
+		{
+			name:  "multibyte runes at limit",
+			text:  "ñandú",
+			limit: 5,
+			want:  []string{"ñandú"},
+		},
+		{
+			name:  "hard cut of multibyte runes",
+			text:  "ñandúñandú",
+			limit: 5,
+			want:  []string{"ñandú", "ñandú"},
+		},
+		{
+			name:  "words of multibyte runes",
+			text:  "日本語 日本語",
+			limit: 3,
+			want:  []string{"日本語", "日本語"},
+		},
+		{
+			// The blank line inside the code block doesn't split it.
+			name:  "whole code block",
+			text:  "Intro.\n\n```go\nx := 1\n\ny := 2\n```\n\nOutro.",
+			limit: 30,
+			want:  []string{"Intro.", "```go\nx := 1\n\ny := 2\n```", "Outro."},
+		},
+		{
+			name:  "split code block",
+			text:  "```go\nline one\nline two\nline three\n```",
+			limit: 22,
+			want:  []string{"```go\nline one\n```", "```go\nline two\n```", "```go\nline three\n```"},
+		},
+		{
+			name:  "unterminated code fence",
+			text:  "Text.\n\n```\nfmt.Println(1)\n\nfmt.Println(2)",
+			limit: 25,
+			want:  []string{"Text.", "```\nfmt.Println(1)\n```", "```\nfmt.Println(2)\n```"},
+		},
+		{
+			name:  "inline code",
+			text:  "Run ```go test``` first.\n\nThen build it.",
+			limit: 25,
+			want:  []string{"Run ```go test``` first.", "Then build it."},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := splitResponse(tt.text, tt.limit)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+
+			for _, part := range got {
+				if tt.limit > 0 && utf8.RuneCountInString(part) > tt.limit {
+					t.Errorf("got part %q longer than %d characters", part, tt.limit)
+				}
+			}
+		})
+	}
+}
+
+func TestPartDelay(t *testing.T) {
+	tests := []struct {
+		part string
+		want time.Duration
+	}{
+		{part: "Hi", want: minPartDelay},
+		{part: string(make([]rune, 100)), want: 2 * time.Second},
+		{part: string(make([]rune, 1000)), want: maxPartDelay},
+	}
+
+	for _, tt := range tests {
+		got := partDelay(tt.part)
+		if got != tt.want {
+			t.Errorf("got delay %s for %d characters, want %s", got, utf8.RuneCountInString(tt.part), tt.want)
+		}
+	}
+}
@@ -0,0 +1,179 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Chat(ctx context.Context, tx data.Tx, whatsappID string) (data.Chat, error) {
+	query := `SELECT chat_id, system_prompt, model, temperature, max_tokens, language, timezone, segment,
+			         voice_replies, tools
+			  FROM chats
+			  WHERE chat_id = ?
+			  LIMIT 1`
+
+	row := tx.QueryRow(ctx, query, whatsappID)
+
+	chat, err := s.scanChat(row)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return data.Chat{}, data.ErrNotFound
+		}
+		return data.Chat{}, err
+	}
+
+	return chat, nil
+}
+
+func (s *Store) Chats(ctx context.Context, tx data.Tx) ([]data.Chat, error) {
+	query := `SELECT chat_id, system_prompt, model, temperature, max_tokens, language, timezone, segment,
+			         voice_replies, tools
+			  FROM chats
+			  ORDER BY chat_id`
+
+	rows, err := tx.Query(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			s.logger.Error("failed to close rows", zap.Error(err))
+		}
+	}()
+
+	var chats []data.Chat
+	for rows.Next() {
+		chat, err := s.scanChat(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		chats = append(chats, chat)
+	}
+
+	return chats, nil
+}
+
+func (s *Store) CreateChat(ctx context.Context, tx data.Tx, chat data.Chat) error {
+	query := `INSERT INTO chats (
+			      chat_id, system_prompt, model, temperature, max_tokens, language, timezone, segment, voice_replies,
+			      tools
+			  )
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			  ON CONFLICT (chat_id) DO NOTHING`
+
+	result, err := tx.Exec(
+		ctx,
+		query,
+		chat.ID,
+		chat.SystemPrompt,
+		chat.Model,
+		chat.Temperature,
+		chat.MaxTokens,
+		chat.Language,
+		chat.Timezone,
+		chat.Segment,
+		chat.VoiceReplies,
+		strings.Join(chat.Tools, ","),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return data.ErrAlreadyExists
+	}
+
+	return nil
+}
+
+func (s *Store) UpdateChat(ctx context.Context, tx data.Tx, chat data.Chat) error {
+	query := `UPDATE chats
+			  SET system_prompt = ?, model = ?, temperature = ?, max_tokens = ?, language = ?, timezone = ?,
+			      segment = ?, voice_replies = ?, tools = ?
+			  WHERE chat_id = ?`
+
+	result, err := tx.Exec(
+		ctx,
+		query,
+		chat.SystemPrompt,
+		chat.Model,
+		chat.Temperature,
+		chat.MaxTokens,
+		chat.Language,
+		chat.Timezone,
+		chat.Segment,
+		chat.VoiceReplies,
+		strings.Join(chat.Tools, ","),
+		chat.ID,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return data.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteChat(ctx context.Context, tx data.Tx, chatID string) error {
+	query := "DELETE FROM chats WHERE chat_id = ?"
+
+	result, err := tx.Exec(ctx, query, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return data.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) scanChat(row data.Row) (data.Chat, error) {
+	var chat data.Chat
+	var tools string
+	err := row.Scan(
+		&chat.ID,
+		&chat.SystemPrompt,
+		&chat.Model,
+		&chat.Temperature,
+		&chat.MaxTokens,
+		&chat.Language,
+		&chat.Timezone,
+		&chat.Segment,
+		&chat.VoiceReplies,
+		&tools,
+	)
+	if err != nil {
+		return data.Chat{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+	if tools != "" {
+		chat.Tools = strings.Split(tools, ",")
+	}
+
+	return chat, nil
+}
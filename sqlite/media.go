@@ -0,0 +1,77 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Media(ctx context.Context, tx data.Tx, chatID string, messageIDs []string) ([]data.Media, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, len(messageIDs)+1)
+	args = append(args, chatID)
+	for _, id := range messageIDs {
+		args = append(args, id)
+	}
+
+	query := `SELECT message_id, chat_id, mime_type, data, created_at
+			  FROM media
+			  WHERE chat_id = ? AND message_id IN (?` + strings.Repeat(", ?", len(messageIDs)-1) + `)`
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			s.logger.Error("failed to close rows", zap.Error(err))
+		}
+	}()
+
+	var media []data.Media
+	for rows.Next() {
+		var m data.Media
+		err := rows.Scan(
+			&m.MessageID,
+			&m.ChatID,
+			&m.MIMEType,
+			&m.Data,
+			&m.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		media = append(media, m)
+	}
+
+	return media, nil
+}
+
+func (s *Store) CreateMedia(ctx context.Context, tx data.Tx, media data.Media) error {
+	query := `INSERT INTO media (message_id, chat_id, mime_type, data, created_at)
+			  VALUES (?, ?, ?, ?, ?)`
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		media.MessageID,
+		media.ChatID,
+		media.MIMEType,
+		media.Data,
+		formatTime(media.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
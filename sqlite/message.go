@@ -0,0 +1,149 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) CreateMessage(ctx context.Context, tx data.Tx, message data.Message) error {
+	query := `INSERT INTO messages (
+			      chat_id, sender_id, sender_name, message_id, conversation, quoted_message_id, quoted_text, transcribed,
+			      completion_id, segment, "timestamp", created_at
+			  )
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		message.ChatID,
+		message.SenderID,
+		message.SenderName,
+		message.ID,
+		message.Conversation,
+		message.QuotedID,
+		message.QuotedText,
+		message.Transcribed,
+		message.CompletionID,
+		message.Segment,
+		formatTime(message.Timestamp),
+		formatTime(message.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteMessage(ctx context.Context, tx data.Tx, chatID, messageID string) error {
+	query := "DELETE FROM messages WHERE chat_id = ? AND message_id = ?"
+
+	result, err := tx.Exec(ctx, query, chatID, messageID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return data.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteMessages(ctx context.Context, tx data.Tx, chatID string) error {
+	query := "DELETE FROM messages WHERE chat_id = ?"
+
+	_, err := tx.Exec(ctx, query, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) Messages(ctx context.Context, tx data.Tx, chatID string) ([]data.Message, error) {
+	query := `SELECT chat_id, sender_id, sender_name, message_id, conversation, quoted_message_id, quoted_text, transcribed,
+			         completion_id, segment, "timestamp", created_at
+			  FROM messages
+			  WHERE chat_id = ?
+			  ORDER BY "timestamp"`
+
+	return s.queryMessages(ctx, tx, query, chatID)
+}
+
+func (s *Store) SegmentMessages(ctx context.Context, tx data.Tx, chatID string, segment int) ([]data.Message, error) {
+	query := `SELECT chat_id, sender_id, sender_name, message_id, conversation, quoted_message_id, quoted_text, transcribed,
+			         completion_id, segment, "timestamp", created_at
+			  FROM messages
+			  WHERE chat_id = ? AND segment = ?
+			  ORDER BY "timestamp"`
+
+	return s.queryMessages(ctx, tx, query, chatID, segment)
+}
+
+func (s *Store) AllMessagesSince(ctx context.Context, tx data.Tx, t time.Time) ([]data.Message, error) {
+	query := `SELECT chat_id, sender_id, sender_name, message_id, conversation, quoted_message_id, quoted_text, transcribed,
+			         completion_id, segment, "timestamp", created_at
+			  FROM messages
+			  WHERE created_at >= ?
+			  ORDER BY "timestamp"`
+
+	return s.queryMessages(ctx, tx, query, formatTime(t))
+}
+
+func (s *Store) queryMessages(ctx context.Context, tx data.Tx, query string, args ...interface{}) ([]data.Message, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			s.logger.Error("failed to close rows", zap.Error(err))
+		}
+	}()
+
+	var messages []data.Message
+	for rows.Next() {
+		message, err := s.scanMessage(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		messages = append(messages, message)
+	}
+
+	return messages, nil
+}
+
+func (s *Store) scanMessage(row data.Row) (data.Message, error) {
+	var message data.Message
+	err := row.Scan(
+		&message.ChatID,
+		&message.SenderID,
+		&message.SenderName,
+		&message.ID,
+		&message.Conversation,
+		&message.QuotedID,
+		&message.QuotedText,
+		&message.Transcribed,
+		&message.CompletionID,
+		&message.Segment,
+		&message.Timestamp,
+		&message.CreatedAt,
+	)
+	if err != nil {
+		return data.Message{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return message, nil
+}
@@ -0,0 +1,190 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/internal/migration"
+)
+
+// ErrSchemaTooNew is returned when the database schema was migrated by a newer version of the program.
+var ErrSchemaTooNew = migration.ErrSchemaTooNew
+
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+func loadMigrations() ([]migration.Migration, error) {
+	fsys, err := fs.Sub(migrationsFS, "migrations")
+	if err != nil {
+		return nil, err
+	}
+
+	return migration.Load(fsys)
+}
+
+// LatestSchemaVersion returns the version of the newest migration embedded in the program.
+func (s *Store) LatestSchemaVersion() (int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return 0, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	return len(migrations), nil
+}
+
+// SchemaVersion returns the version of the last migration applied to the database, or zero if none
+// has been applied.
+func (s *Store) SchemaVersion(ctx context.Context) (int, error) {
+	var exists bool
+	err := s.db.QueryRowContext(
+		ctx,
+		"SELECT EXISTS (SELECT 1 FROM sqlite_master WHERE type = 'table' AND name = 'schema_migrations')",
+	).Scan(&exists)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check if schema_migrations table exists: %w", err)
+	}
+	if !exists {
+		return 0, nil
+	}
+
+	var version int
+	err = s.db.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return version, nil
+}
+
+// MigrateUp applies all the migrations that haven't been applied to the database yet.
+func (s *Store) MigrateUp(ctx context.Context) error {
+	migrations, version, err := s.prepareMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for _, m := range migrations[version:] {
+		err := s.applyMigration(ctx, m, true)
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		s.logger.Info("applied migration", zap.Int("version", m.Version), zap.String("name", m.Name))
+	}
+
+	return nil
+}
+
+// MigrateDown reverts the last steps migrations applied to the database.
+func (s *Store) MigrateDown(ctx context.Context, steps int) error {
+	migrations, version, err := s.prepareMigrations(ctx)
+	if err != nil {
+		return err
+	}
+
+	for i := 0; i < steps && version > 0; i++ {
+		m := migrations[version-1]
+		err := s.applyMigration(ctx, m, false)
+		if err != nil {
+			return fmt.Errorf("failed to revert migration %d (%s): %w", m.Version, m.Name, err)
+		}
+		s.logger.Info("reverted migration", zap.Int("version", m.Version), zap.String("name", m.Name))
+
+		version--
+	}
+
+	return nil
+}
+
+// prepareMigrations creates the schema_migrations table if it doesn't exist, and returns the embedded
+// migrations and the current schema version.
+func (s *Store) prepareMigrations(ctx context.Context) ([]migration.Migration, int, error) {
+	migrations, err := loadMigrations()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	_, err = s.db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER NOT NULL PRIMARY KEY,
+		applied_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+	)`)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return nil, 0, err
+	}
+	if version > len(migrations) {
+		return nil, 0, fmt.Errorf("%w: database is at version %d, latest known is %d", ErrSchemaTooNew, version, len(migrations))
+	}
+
+	return migrations, version, nil
+}
+
+// checkSchemaVersion returns ErrSchemaTooNew if the database was migrated by a newer version of the
+// program, whose schema this one may not be compatible with.
+func (s *Store) checkSchemaVersion(ctx context.Context) error {
+	latest, err := s.LatestSchemaVersion()
+	if err != nil {
+		return err
+	}
+
+	version, err := s.SchemaVersion(ctx)
+	if err != nil {
+		return err
+	}
+	if version > latest {
+		return fmt.Errorf("%w: database is at version %d, latest known is %d", ErrSchemaTooNew, version, latest)
+	}
+
+	return nil
+}
+
+// applyMigration executes the up or down statements of a migration and records it in the
+// schema_migrations table in a single transaction. Write transactions are serialized by SQLite, and
+// the migration is skipped if a concurrent migration already applied or reverted it.
+func (s *Store) applyMigration(ctx context.Context, m migration.Migration, up bool) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() {
+		err := tx.Rollback()
+		if err != nil && !errors.Is(err, sql.ErrTxDone) {
+			s.logger.Error("failed to rollback transaction", zap.Error(err))
+		}
+	}()
+
+	var version int
+	err = tx.QueryRowContext(ctx, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations").Scan(&version)
+	if err != nil {
+		return fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	statements, record := m.Up, "INSERT INTO schema_migrations (version) VALUES (?)"
+	if !up {
+		statements, record = m.Down, "DELETE FROM schema_migrations WHERE version = ?"
+	}
+	if (up && version >= m.Version) || (!up && version < m.Version) {
+		return nil
+	}
+
+	_, err = tx.ExecContext(ctx, statements)
+	if err != nil {
+		return fmt.Errorf("failed to execute statements: %w", err)
+	}
+
+	_, err = tx.ExecContext(ctx, record, m.Version)
+	if err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+
+	return tx.Commit()
+}
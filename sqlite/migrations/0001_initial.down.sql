@@ -0,0 +1,3 @@
+DROP TABLE summaries;
+DROP TABLE messages;
+DROP TABLE chats;
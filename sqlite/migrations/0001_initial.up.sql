@@ -0,0 +1,28 @@
+CREATE TABLE chats (
+    chat_id TEXT NOT NULL PRIMARY KEY,
+    system_prompt TEXT NOT NULL DEFAULT '',
+    model TEXT NOT NULL DEFAULT '',
+    temperature REAL,
+    max_tokens INTEGER NOT NULL DEFAULT 0,
+    language TEXT NOT NULL DEFAULT '',
+    timezone TEXT NOT NULL DEFAULT ''
+);
+
+CREATE TABLE messages (
+    chat_id TEXT NOT NULL REFERENCES chats (chat_id) ON DELETE CASCADE,
+    sender_id TEXT NOT NULL,
+    message_id TEXT NOT NULL PRIMARY KEY,
+    conversation TEXT NOT NULL DEFAULT '',
+    "timestamp" DATETIME NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX messages_chat_id_index ON messages (chat_id);
+
+CREATE TABLE summaries (
+    chat_id TEXT NOT NULL PRIMARY KEY REFERENCES chats (chat_id) ON DELETE CASCADE,
+    content TEXT NOT NULL DEFAULT '',
+    covered_until DATETIME NOT NULL,
+    created_at DATETIME NOT NULL,
+    updated_at DATETIME NOT NULL
+);
@@ -0,0 +1,5 @@
+ALTER TABLE chats ADD COLUMN segment INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE messages ADD COLUMN segment INTEGER NOT NULL DEFAULT 0;
+
+CREATE INDEX messages_chat_id_segment_index ON messages (chat_id, segment);
@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN sender_name TEXT NOT NULL DEFAULT '';
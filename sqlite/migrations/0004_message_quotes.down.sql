@@ -0,0 +1 @@
+ALTER TABLE messages DROP COLUMN quoted_message_id;
@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN quoted_message_id TEXT NOT NULL DEFAULT '';
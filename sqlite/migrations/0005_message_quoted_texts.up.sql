@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN quoted_text TEXT NOT NULL DEFAULT '';
@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN transcribed BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1,9 @@
+CREATE TABLE media (
+    message_id TEXT NOT NULL PRIMARY KEY REFERENCES messages (message_id) ON DELETE CASCADE,
+    chat_id TEXT NOT NULL,
+    mime_type TEXT NOT NULL,
+    data BLOB NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX media_chat_id_index ON media (chat_id);
@@ -0,0 +1,17 @@
+CREATE TABLE documents (
+    message_id TEXT NOT NULL PRIMARY KEY REFERENCES messages (message_id) ON DELETE CASCADE,
+    chat_id TEXT NOT NULL,
+    segment INTEGER NOT NULL DEFAULT 0,
+    file_name TEXT NOT NULL DEFAULT '',
+    mime_type TEXT NOT NULL,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX documents_chat_id_segment_index ON documents (chat_id, segment);
+
+CREATE TABLE document_chunks (
+    message_id TEXT NOT NULL REFERENCES documents (message_id) ON DELETE CASCADE,
+    "index" INTEGER NOT NULL,
+    content TEXT NOT NULL,
+    PRIMARY KEY (message_id, "index")
+);
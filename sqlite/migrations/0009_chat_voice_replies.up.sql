@@ -0,0 +1 @@
+ALTER TABLE chats ADD COLUMN voice_replies BOOLEAN NOT NULL DEFAULT FALSE;
@@ -0,0 +1 @@
+ALTER TABLE messages ADD COLUMN completion_id TEXT NOT NULL DEFAULT '';
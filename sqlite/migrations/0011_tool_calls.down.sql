@@ -0,0 +1,3 @@
+DROP TABLE tool_calls;
+
+ALTER TABLE chats DROP COLUMN tools;
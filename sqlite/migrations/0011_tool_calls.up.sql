@@ -0,0 +1,16 @@
+ALTER TABLE chats ADD COLUMN tools TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE tool_calls (
+    message_id TEXT NOT NULL REFERENCES messages (message_id) ON DELETE CASCADE,
+    "index" INTEGER NOT NULL,
+    chat_id TEXT NOT NULL,
+    call_id TEXT NOT NULL DEFAULT '',
+    name TEXT NOT NULL,
+    arguments TEXT NOT NULL DEFAULT '',
+    result TEXT NOT NULL DEFAULT '',
+    failed BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at DATETIME NOT NULL,
+    PRIMARY KEY (message_id, "index")
+);
+
+CREATE INDEX tool_calls_chat_id_index ON tool_calls (chat_id);
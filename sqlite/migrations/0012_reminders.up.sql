@@ -0,0 +1,14 @@
+CREATE TABLE reminders (
+    reminder_id INTEGER PRIMARY KEY AUTOINCREMENT,
+    chat_id TEXT NOT NULL REFERENCES chats (chat_id) ON DELETE CASCADE,
+    chat_jid TEXT NOT NULL,
+    sender_id TEXT NOT NULL DEFAULT '',
+    text TEXT NOT NULL DEFAULT '',
+    due_at DATETIME NOT NULL,
+    claimed_until DATETIME,
+    sent_at DATETIME,
+    created_at DATETIME NOT NULL
+);
+
+CREATE INDEX reminders_chat_id_index ON reminders (chat_id);
+CREATE INDEX reminders_due_at_index ON reminders (due_at) WHERE sent_at IS NULL;
@@ -0,0 +1,131 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Reminders(ctx context.Context, tx data.Tx, chatID string) ([]data.Reminder, error) {
+	query := `SELECT reminder_id, chat_id, chat_jid, sender_id, text, due_at, created_at
+			  FROM reminders
+			  WHERE chat_id = ? AND sent_at IS NULL
+			  ORDER BY due_at, reminder_id`
+
+	return s.queryReminders(ctx, tx, query, chatID)
+}
+
+func (s *Store) CreateReminder(ctx context.Context, tx data.Tx, reminder data.Reminder) (int64, error) {
+	query := `INSERT INTO reminders (chat_id, chat_jid, sender_id, text, due_at, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?)
+			  RETURNING reminder_id`
+
+	var id int64
+	err := tx.QueryRow(
+		ctx,
+		query,
+		reminder.ChatID,
+		reminder.ChatJID,
+		reminder.SenderID,
+		reminder.Text,
+		formatTime(reminder.DueAt),
+		formatTime(reminder.CreatedAt),
+	).Scan(&id)
+	if err != nil {
+		return 0, fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return id, nil
+}
+
+func (s *Store) DeleteReminder(ctx context.Context, tx data.Tx, chatID string, reminderID int64) error {
+	query := "DELETE FROM reminders WHERE chat_id = ? AND reminder_id = ? AND sent_at IS NULL"
+
+	result, err := tx.Exec(ctx, query, chatID, reminderID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return data.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) ClaimReminders(ctx context.Context, tx data.Tx, now, until time.Time, limit int) ([]data.Reminder, error) {
+	// SQLite serializes writes, so the reminders are claimed by a single statement.
+	query := `UPDATE reminders
+			  SET claimed_until = ?
+			  WHERE reminder_id IN (
+			      SELECT reminder_id
+			      FROM reminders
+			      WHERE due_at <= ? AND sent_at IS NULL AND (claimed_until IS NULL OR claimed_until <= ?)
+			      ORDER BY due_at
+			      LIMIT ?
+			  )
+			  RETURNING reminder_id, chat_id, chat_jid, sender_id, text, due_at, created_at`
+
+	return s.queryReminders(ctx, tx, query, formatTime(until), formatTime(now), formatTime(now), limit)
+}
+
+func (s *Store) MarkReminderSent(ctx context.Context, tx data.Tx, reminderID int64, sentAt time.Time) error {
+	query := "UPDATE reminders SET sent_at = ? WHERE reminder_id = ?"
+
+	result, err := tx.Exec(ctx, query, formatTime(sentAt), reminderID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get affected rows: %w", err)
+	}
+	if rowsAffected == 0 {
+		return data.ErrNotFound
+	}
+
+	return nil
+}
+
+func (s *Store) queryReminders(ctx context.Context, tx data.Tx, query string, args ...interface{}) ([]data.Reminder, error) {
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			s.logger.Error("failed to close rows", zap.Error(err))
+		}
+	}()
+
+	var reminders []data.Reminder
+	for rows.Next() {
+		var reminder data.Reminder
+		err := rows.Scan(
+			&reminder.ID,
+			&reminder.ChatID,
+			&reminder.ChatJID,
+			&reminder.SenderID,
+			&reminder.Text,
+			&reminder.DueAt,
+			&reminder.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		reminders = append(reminders, reminder)
+	}
+
+	return reminders, nil
+}
@@ -0,0 +1,129 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+	"github.com/happybydefault/chatbot/data/datatest"
+)
+
+// newTestStore returns a Store of a new database in a temporary directory, without migrations applied.
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+
+	db, err := OpenDB(filepath.Join(t.TempDir(), "chatbot.db"))
+	if err != nil {
+		t.Fatalf("failed to open database: %s", err)
+	}
+	t.Cleanup(func() {
+		_ = db.Close()
+	})
+
+	store, err := NewStore(context.Background(), db, zap.NewNop())
+	if err != nil {
+		t.Fatalf("failed to construct store: %s", err)
+	}
+
+	return store
+}
+
+func TestStore(t *testing.T) {
+	datatest.TestStore(t, func(t *testing.T, chats ...data.Chat) data.Store {
+		t.Helper()
+
+		ctx := context.Background()
+		store := newTestStore(t)
+
+		err := store.MigrateUp(ctx)
+		if err != nil {
+			t.Fatalf("failed to migrate database: %s", err)
+		}
+
+		tx, err := store.BeginTx(ctx, sql.TxOptions{Isolation: sql.LevelReadCommitted, ReadOnly: false})
+		if err != nil {
+			t.Fatalf("failed to begin transaction: %s", err)
+		}
+		defer func() {
+			_ = tx.Rollback(ctx)
+		}()
+
+		for _, chat := range chats {
+			err := store.CreateChat(ctx, tx, chat)
+			if err != nil {
+				t.Fatalf("failed to create chat: %s", err)
+			}
+		}
+
+		err = tx.Commit(ctx)
+		if err != nil {
+			t.Fatalf("failed to commit transaction: %s", err)
+		}
+
+		return store
+	})
+}
+
+func TestMigrate(t *testing.T) {
+	ctx := context.Background()
+	store := newTestStore(t)
+
+	latest, err := store.LatestSchemaVersion()
+	if err != nil {
+		t.Fatalf("failed to get latest schema version: %s", err)
+	}
+
+	assertVersion := func(want int) {
+		t.Helper()
+
+		version, err := store.SchemaVersion(ctx)
+		if err != nil {
+			t.Fatalf("failed to get schema version: %s", err)
+		}
+		if version != want {
+			t.Fatalf("got schema version %d, want %d", version, want)
+		}
+	}
+
+	assertVersion(0)
+
+	err = store.MigrateUp(ctx)
+	if err != nil {
+		t.Fatalf("failed to migrate up: %s", err)
+	}
+	assertVersion(latest)
+
+	err = store.MigrateDown(ctx, 1)
+	if err != nil {
+		t.Fatalf("failed to migrate down one step: %s", err)
+	}
+	assertVersion(latest - 1)
+
+	err = store.MigrateDown(ctx, latest)
+	if err != nil {
+		t.Fatalf("failed to migrate down: %s", err)
+	}
+	assertVersion(0)
+
+	var tables int
+	err = store.db.QueryRowContext(
+		ctx,
+		"SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name NOT IN ('schema_migrations', 'sqlite_sequence')",
+	).Scan(&tables)
+	if err != nil {
+		t.Fatalf("failed to count tables: %s", err)
+	}
+	if tables != 0 {
+		t.Errorf("got %d tables after migrating down, want none", tables)
+	}
+
+	err = store.MigrateUp(ctx)
+	if err != nil {
+		t.Fatalf("failed to migrate up again: %s", err)
+	}
+	assertVersion(latest)
+}
@@ -0,0 +1,90 @@
+// Package sqlite implements data.Store with SQLite, for single-node deployments.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+
+	"go.uber.org/zap"
+	_ "modernc.org/sqlite" // Registers the "sqlite" database/sql driver.
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+type Store struct {
+	db     *sql.DB
+	logger *zap.Logger
+}
+
+// OpenDB opens the SQLite database at path with the settings expected by Store, i.e. with foreign keys
+// enforced, write-ahead logging and a busy timeout. The path ":memory:" opens an ephemeral in-memory
+// database. The database can be shared with the whatsmeow store using the "sqlite3" dialect.
+func OpenDB(path string) (*sql.DB, error) {
+	query := url.Values{}
+	query.Add("_pragma", "foreign_keys(1)")
+	query.Add("_pragma", "busy_timeout(5000)")
+	query.Set("_txlock", "immediate")
+
+	inMemory := path == ":memory:"
+	if !inMemory {
+		query.Add("_pragma", "journal_mode(WAL)")
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?"+query.Encode())
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %w", err)
+	}
+
+	// Every connection to an in-memory database has its own database, so there must be only one.
+	if inMemory {
+		db.SetMaxOpenConns(1)
+	}
+
+	return db, nil
+}
+
+// PathFromURL returns the path of the database file of a URL like "sqlite:///var/lib/chatbot.db" or
+// "sqlite://chatbot.db".
+func PathFromURL(rawURL string) (string, error) {
+	path, ok := strings.CutPrefix(rawURL, "sqlite://")
+	if !ok || path == "" {
+		return "", errors.New(`SQLite URL must have the format "sqlite://<path>"`)
+	}
+
+	return path, nil
+}
+
+// NewStore constructs a Store, failing with ErrSchemaTooNew if the database was migrated by a newer
+// version of the program. Migrations are applied with MigrateUp.
+func NewStore(ctx context.Context, db *sql.DB, logger *zap.Logger) (*Store, error) {
+	store := &Store{
+		db:     db,
+		logger: logger,
+	}
+
+	err := store.checkSchemaVersion(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check schema version: %w", err)
+	}
+
+	return store, nil
+}
+
+func (s *Store) BeginTx(ctx context.Context, options sql.TxOptions) (data.Tx, error) {
+	// SQLite transactions are always serializable.
+	options.Isolation = sql.LevelDefault
+
+	tx, err := s.db.BeginTx(ctx, &options)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	return newTx(tx), nil
+}
+
+// Close does nothing, since the database is owned by the caller of NewStore.
+func (s *Store) Close() {}
@@ -0,0 +1,71 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) Summary(ctx context.Context, tx data.Tx, chatID string) (data.Summary, error) {
+	query := `SELECT chat_id, content, covered_until, created_at, updated_at
+			  FROM summaries
+			  WHERE chat_id = ?
+			  LIMIT 1`
+
+	row := tx.QueryRow(ctx, query, chatID)
+
+	var summary data.Summary
+	err := row.Scan(
+		&summary.ChatID,
+		&summary.Content,
+		&summary.CoveredUntil,
+		&summary.CreatedAt,
+		&summary.UpdatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return data.Summary{}, data.ErrNotFound
+		}
+		return data.Summary{}, fmt.Errorf("failed to scan row: %w", err)
+	}
+
+	return summary, nil
+}
+
+func (s *Store) UpsertSummary(ctx context.Context, tx data.Tx, summary data.Summary) error {
+	query := `INSERT INTO summaries (chat_id, content, covered_until, created_at, updated_at)
+			  VALUES (?, ?, ?, ?, ?)
+			  ON CONFLICT (chat_id) DO UPDATE
+			  SET content = excluded.content,
+			      covered_until = excluded.covered_until,
+			      updated_at = excluded.updated_at`
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		summary.ChatID,
+		summary.Content,
+		formatTime(summary.CoveredUntil),
+		formatTime(summary.CreatedAt),
+		formatTime(summary.UpdatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
+
+func (s *Store) DeleteSummary(ctx context.Context, tx data.Tx, chatID string) error {
+	query := "DELETE FROM summaries WHERE chat_id = ?"
+
+	_, err := tx.Exec(ctx, query, chatID)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
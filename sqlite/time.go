@@ -0,0 +1,11 @@
+package sqlite
+
+import "time"
+
+// timeLayout is the layout of the times written to DATETIME columns. Times are written in UTC and with
+// a fixed width, so that they sort chronologically as text.
+const timeLayout = "2006-01-02 15:04:05.000000000"
+
+func formatTime(t time.Time) string {
+	return t.UTC().Format(timeLayout)
+}
@@ -0,0 +1,86 @@
+package sqlite
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func (s *Store) ToolCalls(ctx context.Context, tx data.Tx, chatID string, messageIDs []string) ([]data.ToolCall, error) {
+	if len(messageIDs) == 0 {
+		return nil, nil
+	}
+
+	args := make([]interface{}, 0, len(messageIDs)+1)
+	args = append(args, chatID)
+	for _, id := range messageIDs {
+		args = append(args, id)
+	}
+
+	query := `SELECT message_id, "index", chat_id, call_id, name, arguments, result, failed, created_at
+			  FROM tool_calls
+			  WHERE chat_id = ? AND message_id IN (?` + strings.Repeat(", ?", len(messageIDs)-1) + `)
+			  ORDER BY created_at, "index"`
+
+	rows, err := tx.Query(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to execute query: %w", err)
+	}
+	defer func() {
+		err := rows.Close()
+		if err != nil {
+			s.logger.Error("failed to close rows", zap.Error(err))
+		}
+	}()
+
+	var toolCalls []data.ToolCall
+	for rows.Next() {
+		var toolCall data.ToolCall
+		err := rows.Scan(
+			&toolCall.MessageID,
+			&toolCall.Index,
+			&toolCall.ChatID,
+			&toolCall.CallID,
+			&toolCall.Name,
+			&toolCall.Arguments,
+			&toolCall.Result,
+			&toolCall.Failed,
+			&toolCall.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan row: %w", err)
+		}
+
+		toolCalls = append(toolCalls, toolCall)
+	}
+
+	return toolCalls, nil
+}
+
+func (s *Store) CreateToolCall(ctx context.Context, tx data.Tx, toolCall data.ToolCall) error {
+	query := `INSERT INTO tool_calls (message_id, "index", chat_id, call_id, name, arguments, result, failed, created_at)
+			  VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`
+
+	_, err := tx.Exec(
+		ctx,
+		query,
+		toolCall.MessageID,
+		toolCall.Index,
+		toolCall.ChatID,
+		toolCall.CallID,
+		toolCall.Name,
+		toolCall.Arguments,
+		toolCall.Result,
+		toolCall.Failed,
+		formatTime(toolCall.CreatedAt),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to execute query: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+type Tx struct {
+	*sql.Tx
+}
+
+func newTx(tx *sql.Tx) *Tx {
+	return &Tx{Tx: tx}
+}
+
+func (tx Tx) Commit(ctx context.Context) error {
+	err := tx.Tx.Commit()
+	if err != nil {
+		if errors.Is(err, sql.ErrTxDone) {
+			return fmt.Errorf("%w: %s", data.ErrTxDone, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (tx Tx) Rollback(ctx context.Context) error {
+	err := tx.Tx.Rollback()
+	if err != nil {
+		if errors.Is(err, sql.ErrTxDone) {
+			return fmt.Errorf("%w: %s", data.ErrTxDone, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+func (tx Tx) QueryRow(ctx context.Context, query string, args ...interface{}) data.Row {
+	return tx.Tx.QueryRowContext(ctx, query, args...)
+}
+
+func (tx Tx) Query(ctx context.Context, query string, args ...interface{}) (data.Rows, error) {
+	return tx.Tx.QueryContext(ctx, query, args...)
+}
+
+func (tx Tx) Exec(ctx context.Context, query string, args ...interface{}) (data.Result, error) {
+	return tx.Tx.ExecContext(ctx, query, args...)
+}
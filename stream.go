@@ -0,0 +1,122 @@
+package chatbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mau.fi/whatsmeow"
+	"go.mau.fi/whatsmeow/types/events"
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// streamFailedNotice replaces the text of a streamed message whose response failed to complete.
+const streamFailedNotice = "Failed to finish this response. Please try again later."
+
+// responseStream delivers a streamed response as a message that is sent as soon as there is text and
+// then edited as more of it arrives, at most once per interval to avoid being rate limited.
+type responseStream struct {
+	chat     *Chat
+	ctx      context.Context
+	quoted   *events.Message // Quoted by the message if not nil.
+	delay    <-chan time.Time
+	interval time.Duration
+
+	report   whatsmeow.SendResponse // Of the first message, whose ID is empty until it's sent.
+	sentText string
+	sentAt   time.Time
+}
+
+// newResponseStream returns a responseStream whose first message is sent after receiving from delay.
+func (c *Chat) newResponseStream(ctx context.Context, quoted *events.Message, delay <-chan time.Time) *responseStream {
+	return &responseStream{
+		chat:     c,
+		ctx:      ctx,
+		quoted:   quoted,
+		delay:    delay,
+		interval: c.client.streamInterval,
+	}
+}
+
+func (c *Client) streamsResponses(chat data.Chat) bool {
+	_, ok := c.completer.(StreamingCompleter)
+	return c.streamResponses && ok && !c.repliesWithVoice(chat.VoiceReplies)
+}
+
+// sent reports whether the first message was sent.
+func (s *responseStream) sent() bool {
+	return s.report.ID != ""
+}
+
+// update delivers text, which is all the text of the response received so far.
+func (s *responseStream) update(text string) error {
+	text = formatMarkdown(strings.TrimSpace(text))
+	if text == "" || text == s.sentText {
+		return nil
+	}
+
+	if !s.sent() {
+		<-s.delay
+
+		report, err := s.chat.client.whatsmeowClient.SendMessage(s.ctx, s.chat.jid, "", textMessage(text, s.quoted))
+		if err != nil {
+			return fmt.Errorf("failed to send message: %w", err)
+		}
+		s.chat.logger.Debug("sent first part of streamed message", zap.String("sent_message_id", report.ID))
+
+		s.report = report
+		s.sentText = text
+		s.sentAt = time.Now()
+		return nil
+	}
+
+	if time.Since(s.sentAt) < s.interval {
+		return nil
+	}
+
+	return s.edit(s.ctx, text)
+}
+
+// finish edits the message with the whole text of the response, if it was not delivered yet, and
+// returns the report of the first message.
+func (s *responseStream) finish(text string) (whatsmeow.SendResponse, error) {
+	if text != s.sentText {
+		err := s.edit(s.ctx, text)
+		if err != nil {
+			return whatsmeow.SendResponse{}, err
+		}
+	}
+
+	return s.report, nil
+}
+
+// fail edits the message, if it was sent, to tell that the response failed, so the partial text
+// doesn't look like the whole response. It doesn't use the context of the stream, which may be the
+// cause of the failure.
+func (s *responseStream) fail() error {
+	if !s.sent() {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	return s.edit(ctx, streamFailedNotice)
+}
+
+func (s *responseStream) edit(ctx context.Context, text string) error {
+	client := s.chat.client.whatsmeowClient
+	_, err := client.SendMessage(ctx, s.chat.jid, "", client.BuildEdit(s.chat.jid, s.report.ID, textMessage(text, s.quoted)))
+	if err != nil {
+		return fmt.Errorf("failed to send message edit: %w", err)
+	}
+	metricStreamedEdits.Add(1)
+
+	s.sentText = text
+	s.sentAt = time.Now()
+
+	return nil
+}
@@ -0,0 +1,187 @@
+package chatbot
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeStreamingCompleter is a StreamingCompleter that streams the same deltas for every request and
+// then fails with err if it's not nil.
+type fakeStreamingCompleter struct {
+	fakeCompleter
+
+	deltas []string
+	err    error
+}
+
+func (f *fakeStreamingCompleter) CompleteStream(
+	ctx context.Context,
+	request CompletionRequest,
+	onDelta func(delta string) error,
+) (CompletionResponse, error) {
+	var content string
+	for _, delta := range f.deltas {
+		err := onDelta(delta)
+		if err != nil {
+			return CompletionResponse{}, err
+		}
+		content += delta
+	}
+
+	if f.err != nil {
+		return CompletionResponse{}, f.err
+	}
+
+	return CompletionResponse{
+		Message: CompletionMessage{
+			Role:    RoleAssistant,
+			Content: content,
+		},
+	}, nil
+}
+
+func TestChatRespondStream(t *testing.T) {
+	tests := []struct {
+		name       string
+		deltas     []string
+		err        error
+		wantSent   []string
+		wantStored string // Empty if the response is not stored.
+		wantErr    bool
+	}{
+		{
+			name:       "complete",
+			deltas:     []string{"Hello", ", **User**", "!\n"},
+			wantSent:   []string{"Hello", "Hello, *User*", "Hello, *User*!"},
+			wantStored: "Hello, *User*!",
+		},
+		{
+			name:     "failed after first message",
+			deltas:   []string{"Hello", ", User"},
+			err:      ErrCompletionRejected,
+			wantSent: []string{"Hello", "Hello, User", streamFailedNotice},
+			wantErr:  true,
+		},
+		{
+			name:    "failed before first message",
+			err:     ErrCompletionRejected,
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whatsApp := &fakeWhatsApp{}
+			completer := &fakeStreamingCompleter{deltas: tt.deltas, err: tt.err}
+			chat, dataStore := newTestChat(t, whatsApp, completer)
+			chat.client.streamResponses = true
+
+			msg := newTestMessage("1", &waProto.Message{Conversation: proto.String("Hi there")})
+			err := chat.storeMessageReceived(context.Background(), msg)
+			if err != nil {
+				t.Fatalf("failed to store message: %s", err)
+			}
+
+			err = chat.respond(msg.Message)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+
+			sent := whatsApp.sentTexts()
+			if len(sent) != len(tt.wantSent) || (len(sent) > 0 && !reflect.DeepEqual(sent, tt.wantSent)) {
+				t.Errorf("sent %q, want %q", sent, tt.wantSent)
+			}
+
+			messages := storedMessages(t, dataStore)
+			if tt.wantStored == "" {
+				if len(messages) != 1 {
+					t.Errorf("got stored messages %+v, want only the one of the user", messages)
+				}
+				return
+			}
+			if len(messages) != 2 {
+				t.Fatalf("got %d stored messages, want 2", len(messages))
+			}
+			response := messages[1]
+			if response.ID != "sent-1" || response.CompletionID != "sent-1" || response.Conversation != tt.wantStored {
+				t.Errorf("got stored response %+v, want the first sent message with %q", response, tt.wantStored)
+			}
+		})
+	}
+}
+
+func TestResponseStreamInterval(t *testing.T) {
+	whatsApp := &fakeWhatsApp{}
+	chat, _ := newTestChat(t, whatsApp, &fakeCompleter{})
+	chat.client.streamInterval = time.Hour
+
+	delay := make(chan time.Time)
+	close(delay)
+	stream := chat.newResponseStream(context.Background(), nil, delay)
+
+	for _, text := range []string{" ", "Hello", "Hello ", "Hello, User"} {
+		err := stream.update(text)
+		if err != nil {
+			t.Fatalf("failed to update stream with %q: %s", text, err)
+		}
+	}
+
+	// The edits wait for the interval, except the last one.
+	sent := whatsApp.sentTexts()
+	if len(sent) != 1 || sent[0] != "Hello" {
+		t.Errorf("sent %q before finishing, want only the first text", sent)
+	}
+
+	report, err := stream.finish("Hello, User!")
+	if err != nil {
+		t.Fatalf("failed to finish stream: %s", err)
+	}
+	if report.ID != "sent-1" {
+		t.Errorf("got report of message %q, want the first one", report.ID)
+	}
+
+	sent = whatsApp.sentTexts()
+	if len(sent) != 2 || sent[1] != "Hello, User!" {
+		t.Errorf("sent %q, want the whole text at the end", sent)
+	}
+}
+
+func TestClientCompletionStreamWithoutStreaming(t *testing.T) {
+	chat, _ := newTestChat(t, &fakeWhatsApp{}, &fakeCompleter{})
+
+	_, err := chat.client.completionStream(context.Background(), CompletionRequest{}, func(text string) error {
+		return nil
+	})
+	if err == nil {
+		t.Error("got no error, want one for a completer that doesn't stream")
+	}
+}
+
+func TestClientCompletionStreamTextError(t *testing.T) {
+	completer := &fakeStreamingCompleter{deltas: []string{"a", "b", "c"}}
+	chat, _ := newTestChat(t, &fakeWhatsApp{}, completer)
+
+	errStop := errors.New("stop")
+	var texts []string
+	_, err := chat.client.completionStream(context.Background(), CompletionRequest{}, func(text string) error {
+		texts = append(texts, text)
+		if len(texts) == 2 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("got error %v, want the one of onText", err)
+	}
+
+	// The error of onText is not retried.
+	if !reflect.DeepEqual(texts, []string{"a", "ab"}) {
+		t.Errorf("got texts %q, want the content received so far until the error", texts)
+	}
+}
@@ -0,0 +1,200 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// summarySystemPrompt is the system prompt of the summaries, formatted with the name of the chatbot.
+const summarySystemPrompt = "You summarize conversations between users and an AI assistant called %s." +
+	" Write a concise summary of the conversation that keeps every fact, name, preference and open question" +
+	" that could be needed to continue it. If there is a previous summary, merge it into the new one."
+
+// history is what the chatbot knows about a chat when it builds a prompt.
+type history struct {
+	chat     data.Chat
+	summary  data.Summary          // Zero if the chat hasn't been summarized yet.
+	messages []data.Message        // Only the messages of the current segment that are not covered by summary.
+	media    map[string]data.Media // Media of messages, by message ID.
+
+	documents      map[string]data.Document // Documents of the current segment, by message ID.
+	documentChunks []data.DocumentChunk
+
+	messageCount int // Number of messages of the current segment, including the ones covered by summary.
+}
+
+func (c *Chat) loadHistory(ctx context.Context) (history, error) {
+	var h history
+
+	err := c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	}, func(tx data.Tx) error {
+		var err error
+
+		h.chat, err = c.client.store.Chat(ctx, tx, c.id)
+		if err != nil {
+			return fmt.Errorf("failed to get chat from data store: %w", err)
+		}
+
+		h.summary, err = c.client.store.Summary(ctx, tx, c.id)
+		if err != nil && !errors.Is(err, data.ErrNotFound) {
+			return fmt.Errorf("failed to get summary from data store: %w", err)
+		}
+
+		h.messages, err = c.client.store.SegmentMessages(ctx, tx, c.id, h.chat.Segment)
+		if err != nil {
+			return fmt.Errorf("failed to get messages from data store: %w", err)
+		}
+
+		h.messageCount = len(h.messages)
+
+		if h.summary.Content != "" {
+			uncovered := make([]data.Message, 0, len(h.messages))
+			for _, msg := range h.messages {
+				if msg.Timestamp.After(h.summary.CoveredUntil) {
+					uncovered = append(uncovered, msg)
+				}
+			}
+			h.messages = uncovered
+		}
+
+		messageIDs := make([]string, 0, len(h.messages))
+		for _, msg := range h.messages {
+			messageIDs = append(messageIDs, msg.ID)
+		}
+
+		media, err := c.client.store.Media(ctx, tx, c.id, messageIDs)
+		if err != nil {
+			return fmt.Errorf("failed to get media from data store: %w", err)
+		}
+
+		h.media = make(map[string]data.Media, len(media))
+		for _, m := range media {
+			h.media[m.MessageID] = m
+		}
+
+		documents, err := c.client.store.Documents(ctx, tx, c.id, h.chat.Segment)
+		if err != nil {
+			return fmt.Errorf("failed to get documents from data store: %w", err)
+		}
+
+		h.documents = make(map[string]data.Document, len(documents))
+		for _, document := range documents {
+			h.documents[document.MessageID] = document
+		}
+
+		h.documentChunks, err = c.client.store.DocumentChunks(ctx, tx, c.id, h.chat.Segment)
+		if err != nil {
+			return fmt.Errorf("failed to get document chunks from data store: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return history{}, fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	return h, nil
+}
+
+// summarize merges the oldest messages of the chat that are not covered by its summary yet into the
+// summary, once there are more than summarizeThreshold of them. The most recent half of them are
+// left out, so the prompt still has the latest turns verbatim.
+func (c *Chat) summarize() error {
+	threshold := c.client.summarizeThreshold
+	if threshold <= 0 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	h, err := c.loadHistory(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get chat history: %w", err)
+	}
+	summary, messages := h.summary, h.messages
+	settings, err := c.client.chatSettings(h.chat)
+	if err != nil {
+		return fmt.Errorf("failed to get chat settings: %w", err)
+	}
+
+	if len(messages) <= threshold {
+		return nil
+	}
+
+	// The summary covers messages by timestamp, so messages with the same timestamp must be summarized
+	// together. Otherwise, the ones left out would be lost.
+	n := len(messages) - threshold/2
+	for n < len(messages) && messages[n].Timestamp.Equal(messages[n-1].Timestamp) {
+		n++
+	}
+	summarized := messages[:n]
+
+	var transcript strings.Builder
+	if summary.Content != "" {
+		transcript.WriteString("Previous summary:\n")
+		transcript.WriteString(summary.Content)
+		transcript.WriteString("\n\n")
+	}
+	transcript.WriteString("Conversation:\n")
+	for _, msg := range summarized {
+		if msg.SenderID == c.client.botID() {
+			fmt.Fprintf(&transcript, "%s: %s\n", c.client.botName, msg.Conversation)
+			continue
+		}
+		fmt.Fprintln(&transcript, c.client.speakerContent(msg))
+	}
+
+	completionResponse, err := c.client.completion(ctx, CompletionRequest{
+		Model: settings.model,
+		Messages: []CompletionMessage{
+			{Role: RoleSystem, Content: fmt.Sprintf(summarySystemPrompt, c.client.botName)},
+			{Role: RoleUser, Content: transcript.String()},
+		},
+		MaxTokens: settings.maxTokens,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get completion response: %w", err)
+	}
+
+	now := time.Now()
+	if summary.CreatedAt.IsZero() {
+		summary.CreatedAt = now
+	}
+	summary.ChatID = c.id
+	summary.Content = strings.TrimSpace(completionResponse.Message.Content)
+	summary.CoveredUntil = summarized[len(summarized)-1].Timestamp
+	summary.UpdatedAt = now
+
+	err = c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		err := c.client.store.UpsertSummary(ctx, tx, summary)
+		if err != nil {
+			return fmt.Errorf("failed to upsert summary in data store: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	c.logger.Info(
+		"summarized chat messages",
+		zap.Int("summarized_messages", len(summarized)),
+		zap.Time("covered_until", summary.CoveredUntil),
+	)
+
+	return nil
+}
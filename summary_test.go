@@ -0,0 +1,197 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func TestChatSummarizeBotName(t *testing.T) {
+	ctx := context.Background()
+	completer := &fakeCompleter{content: "The user greeted Ava."}
+	chat, dataStore := newTestChat(t, &fakeWhatsApp{}, completer)
+	chat.client.botName = "Ava"
+	chat.client.summarizeThreshold = 2
+
+	start := time.Now().Add(-time.Hour)
+	err := chat.client.execTx(ctx, sql.TxOptions{}, func(tx data.Tx) error {
+		for i, senderID := range []string{testUserID, testBotID, testUserID, testBotID} {
+			err := dataStore.CreateMessage(ctx, tx, data.Message{
+				ID:           strconv.Itoa(i + 1),
+				ChatID:       testUserID,
+				SenderID:     senderID,
+				SenderName:   "Sender",
+				Conversation: "Message",
+				Timestamp:    start.Add(time.Duration(i) * time.Minute),
+				CreatedAt:    time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to create messages: %s", err)
+	}
+
+	err = chat.summarize()
+	if err != nil {
+		t.Fatalf("failed to summarize: %s", err)
+	}
+
+	if len(completer.requests) != 1 {
+		t.Fatalf("got %d completion requests, want 1", len(completer.requests))
+	}
+	messages := completer.requests[0].Messages
+	if !strings.Contains(messages[0].Content, "called Ava.") {
+		t.Errorf("got system prompt %q, want the name of the chatbot", messages[0].Content)
+	}
+	if transcript := messages[1].Content; !strings.Contains(transcript, "Ava: Message") || strings.Contains(transcript, "Chatbot") {
+		t.Errorf("got transcript %q, want the messages of the chatbot by its name", transcript)
+	}
+}
+
+func TestChatSummarize(t *testing.T) {
+	ctx := context.Background()
+	completer := &fakeCompleter{content: " The user asked about the weather.\n"}
+	chat, _ := newTestChat(t, &fakeWhatsApp{}, completer)
+	chat.client.summarizeThreshold = 4
+
+	createMessages(t, chat, testUserID, testBotID, testUserID, testBotID, testUserID, testBotID)
+
+	err := chat.summarize()
+	if err != nil {
+		t.Fatalf("failed to summarize: %s", err)
+	}
+
+	if len(completer.requests) != 1 {
+		t.Fatalf("got %d completion requests, want 1", len(completer.requests))
+	}
+	transcript := completer.requests[0].Messages[1].Content
+	if !strings.Contains(transcript, "Message 4") || strings.Contains(transcript, "Message 5") ||
+		strings.Contains(transcript, "Previous summary") {
+		t.Errorf("got transcript %q, want the first 4 messages only", transcript)
+	}
+
+	h, err := chat.loadHistory(ctx)
+	if err != nil {
+		t.Fatalf("failed to load history: %s", err)
+	}
+	if h.summary.Content != "The user asked about the weather." {
+		t.Errorf("got summary %q, want the trimmed completion", h.summary.Content)
+	}
+	if len(h.messages) != 2 || h.messages[0].ID != "5" || h.messageCount != 6 {
+		t.Errorf("got %d messages from %+v of %d, want the 2 that are not summarized of 6", len(h.messages), h.messages, h.messageCount)
+	}
+
+	// The messages left out are not enough to summarize again.
+	err = chat.summarize()
+	if err != nil {
+		t.Fatalf("failed to summarize again: %s", err)
+	}
+	if len(completer.requests) != 1 {
+		t.Errorf("got %d completion requests, want no more", len(completer.requests))
+	}
+
+	// The summary is part of the prompts of the responses.
+	msg := newTestMessage("trigger", &waProto.Message{Conversation: proto.String("And tomorrow?")})
+	err = chat.storeMessageReceived(ctx, msg)
+	if err != nil {
+		t.Fatalf("failed to store message: %s", err)
+	}
+	err = chat.respond(msg.Message)
+	if err != nil {
+		t.Fatalf("failed to respond: %s", err)
+	}
+	messages := completer.requests[1].Messages
+	if messages[1].Role != RoleSystem || messages[1].Content != "Summary of the earlier conversation:\nThe user asked about the weather." {
+		t.Errorf("got completion message %+v, want the summary after the system prompt", messages[1])
+	}
+	if last := messages[len(messages)-1]; last.Content != "And tomorrow?" || len(messages) != 5 {
+		t.Errorf("got completion messages %+v, want the summary, the 2 messages not summarized and the trigger", messages)
+	}
+}
+
+func TestChatSummarizeMerge(t *testing.T) {
+	ctx := context.Background()
+	completer := &fakeCompleter{content: "Merged summary."}
+	chat, dataStore := newTestChat(t, &fakeWhatsApp{}, completer)
+	chat.client.summarizeThreshold = 2
+
+	// Messages 3 and 4 have the same timestamp, so they are summarized together.
+	start := time.Now().Add(-time.Hour)
+	err := chat.client.execTx(ctx, sql.TxOptions{}, func(tx data.Tx) error {
+		err := dataStore.UpsertSummary(ctx, tx, data.Summary{
+			ChatID:       testUserID,
+			Content:      "Previous summary text.",
+			CoveredUntil: start,
+		})
+		if err != nil {
+			return err
+		}
+
+		for i, minutes := range []int{1, 2, 3, 3} {
+			err := dataStore.CreateMessage(ctx, tx, data.Message{
+				ID:           strconv.Itoa(i + 1),
+				ChatID:       testUserID,
+				SenderID:     testUserID,
+				Conversation: "Message " + strconv.Itoa(i+1),
+				Timestamp:    start.Add(time.Duration(minutes) * time.Minute),
+				CreatedAt:    time.Now(),
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("failed to create summary and messages: %s", err)
+	}
+
+	err = chat.summarize()
+	if err != nil {
+		t.Fatalf("failed to summarize: %s", err)
+	}
+
+	if len(completer.requests) != 1 {
+		t.Fatalf("got %d completion requests, want 1", len(completer.requests))
+	}
+	transcript := completer.requests[0].Messages[1].Content
+	if !strings.HasPrefix(transcript, "Previous summary:\nPrevious summary text.\n\nConversation:\n") ||
+		!strings.Contains(transcript, "Message 4") {
+		t.Errorf("got transcript %q, want the previous summary and all the messages", transcript)
+	}
+
+	h, err := chat.loadHistory(ctx)
+	if err != nil {
+		t.Fatalf("failed to load history: %s", err)
+	}
+	if h.summary.Content != "Merged summary." || len(h.messages) != 0 {
+		t.Errorf("got summary %q and %d messages, want the merged summary covering all", h.summary.Content, len(h.messages))
+	}
+}
+
+func TestChatSummarizeDisabled(t *testing.T) {
+	completer := &fakeCompleter{content: "Summary."}
+	chat, _ := newTestChat(t, &fakeWhatsApp{}, completer)
+
+	createMessages(t, chat, testUserID, testBotID, testUserID, testBotID)
+
+	err := chat.summarize()
+	if err != nil {
+		t.Fatalf("failed to summarize: %s", err)
+	}
+	if len(completer.requests) != 0 {
+		t.Errorf("got %d completion requests, want none without a threshold", len(completer.requests))
+	}
+}
@@ -0,0 +1,31 @@
+package chatbot
+
+import (
+	"unicode/utf8"
+)
+
+// TokenCounter counts the tokens that a message takes up in the model's context window.
+type TokenCounter interface {
+	CountTokens(message CompletionMessage) int
+}
+
+const (
+	// tokensPerMessage is the overhead of the chat format for every message, e.g. role and separators.
+	tokensPerMessage = 4
+
+	// tokensPerReply is the overhead of priming the reply of the assistant.
+	tokensPerReply = 3
+
+	// tokensPerImage is what OpenAI models charge for a high detail image of 1024x1024 pixels, i.e.
+	// four tiles of 170 tokens plus 85 base tokens.
+	tokensPerImage = 765
+)
+
+// estimateTokenCounter is a TokenCounter that estimates one token every four characters, which is a
+// good enough approximation for the BPE tokenizers of most models and errs on the side of caution
+// for short words.
+type estimateTokenCounter struct{}
+
+func (estimateTokenCounter) CountTokens(message CompletionMessage) int {
+	return tokensPerMessage + (utf8.RuneCountInString(message.Content)+3)/4 + len(message.Images)*tokensPerImage
+}
@@ -0,0 +1,279 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"go.uber.org/zap"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+const (
+	// maxToolIterations is the number of completion requests of a response that may call tools. The
+	// request after them leaves the tools out, so the model has to respond with text.
+	maxToolIterations = 5
+
+	// toolLimitNote tells the model that it can't call tools anymore in the request after the last
+	// one of maxToolIterations.
+	toolLimitNote = "The limit of tool calls for this response was reached. Respond with text using the results so far."
+
+	// toolTimeout is the time that every tool call has to run.
+	toolTimeout = 15 * time.Second
+)
+
+// Tool is a function that the model can call while responding, in the chats that enable it.
+type Tool struct {
+	ToolDefinition
+
+	// Run executes a call of the model to the tool and returns its result. The errors are sent back
+	// to the model as the result, so it can explain them or call the tool again.
+	Run func(ctx context.Context, input ToolInput) (string, error)
+}
+
+// ToolInput is the input of a call to a tool: the chat where the model called it, the sender of the
+// message being responded and the arguments of the call as a JSON object.
+type ToolInput struct {
+	ChatID    string
+	ChatJID   string // Full WhatsApp JID of the chat, e.g. to send messages to it later.
+	SenderID  string
+	Location  *time.Location // Of the chat.
+	Arguments json.RawMessage
+}
+
+// newToolRegistry indexes tools by name, checking that every tool has a unique name and can run.
+func newToolRegistry(tools []Tool) (map[string]Tool, error) {
+	registry := make(map[string]Tool, len(tools))
+	for _, tool := range tools {
+		if tool.Name == "" {
+			return nil, errors.New("tool without name")
+		}
+		if tool.Run == nil {
+			return nil, fmt.Errorf("tool %q can't run", tool.Name)
+		}
+		if _, ok := registry[tool.Name]; ok {
+			return nil, fmt.Errorf("duplicate tool %q", tool.Name)
+		}
+
+		registry[tool.Name] = tool
+	}
+
+	return registry, nil
+}
+
+// toolNames returns the names of the tools of the client in alphabetical order.
+func (c *Client) toolNames() []string {
+	names := make([]string, 0, len(c.tools))
+	for name := range c.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	return names
+}
+
+// chatTools returns the tools that chat enables, skipping the ones that the client doesn't have
+// anymore.
+func (c *Client) chatTools(chat data.Chat) []Tool {
+	tools := make([]Tool, 0, len(chat.Tools))
+	for _, name := range chat.Tools {
+		if tool, ok := c.tools[name]; ok {
+			tools = append(tools, tool)
+		}
+	}
+
+	return tools
+}
+
+// completeWithTools gets the completion response to request, running the calls of the model to tools
+// and sending their results back until it responds with text, at most maxToolIterations times.
+// After that, the model is asked to respond without tools, and it fails if the model still calls
+// them. If onText is not nil, the responses are streamed to it as in completionStream. Every tool
+// call is stored as a call made while responding the message triggerID.
+func (c *Chat) completeWithTools(
+	ctx context.Context,
+	request CompletionRequest,
+	tools []Tool,
+	input ToolInput,
+	triggerID string,
+	onText func(text string) error,
+) (CompletionResponse, error) {
+	for _, tool := range tools {
+		request.Tools = append(request.Tools, tool.ToolDefinition)
+	}
+
+	var index int
+	for i := 0; ; i++ {
+		if i == maxToolIterations {
+			request.Tools = nil
+			request.Messages = append(request.Messages, CompletionMessage{
+				Role:    RoleSystem,
+				Content: toolLimitNote,
+			})
+		}
+
+		var completionResponse CompletionResponse
+		var err error
+		if onText != nil {
+			completionResponse, err = c.client.completionStream(ctx, request, onText)
+		} else {
+			completionResponse, err = c.client.completion(ctx, request)
+		}
+		if err != nil {
+			return CompletionResponse{}, err
+		}
+
+		toolCalls := completionResponse.Message.ToolCalls
+		if len(toolCalls) == 0 {
+			return completionResponse, nil
+		}
+		if request.Tools == nil {
+			return CompletionResponse{}, errors.New("model called tools that are not available")
+		}
+
+		request.Messages = append(request.Messages, completionResponse.Message)
+		for _, toolCall := range toolCalls {
+			result, err := c.callTool(ctx, tools, toolCall, input, triggerID, index)
+			if err != nil {
+				return CompletionResponse{}, fmt.Errorf("failed to call tool %q: %w", toolCall.Name, err)
+			}
+			index++
+
+			request.Messages = append(request.Messages, CompletionMessage{
+				Role:       RoleTool,
+				Content:    result,
+				ToolCallID: toolCall.ID,
+			})
+		}
+	}
+}
+
+// callTool runs a call of the model to one of tools and stores it with the given index, returning the
+// result for the model. The failures of the call itself are part of the result, so the returned
+// error is only about storing it.
+func (c *Chat) callTool(
+	ctx context.Context,
+	tools []Tool,
+	toolCall ToolCall,
+	input ToolInput,
+	triggerID string,
+	index int,
+) (string, error) {
+	logger := c.logger.With(
+		zap.String("message_id", triggerID),
+		zap.String("tool", toolCall.Name),
+		zap.String("arguments", toolCall.Arguments),
+	)
+
+	result, err := runTool(ctx, tools, toolCall, input)
+	failed := err != nil
+	if failed {
+		logger.Info("tool call failed", zap.Error(err))
+		result = err.Error()
+	} else {
+		logger.Debug("called tool", zap.String("result", result))
+	}
+	metricToolCalls.Add(1)
+
+	err = c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		return c.client.store.CreateToolCall(ctx, tx, data.ToolCall{
+			MessageID: triggerID,
+			Index:     index,
+			ChatID:    c.id,
+			CallID:    toolCall.ID,
+			Name:      toolCall.Name,
+			Arguments: toolCall.Arguments,
+			Result:    result,
+			Failed:    failed,
+			CreatedAt: time.Now(),
+		})
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to create tool call in data store: %w", err)
+	}
+
+	if failed {
+		return "Error: " + result, nil
+	}
+	return result, nil
+}
+
+// runTool runs a call to one of tools with the input of the chat and the arguments of the call,
+// which must be a JSON object, or empty for none.
+func runTool(ctx context.Context, tools []Tool, toolCall ToolCall, input ToolInput) (string, error) {
+	var tool *Tool
+	for i := range tools {
+		if tools[i].Name == toolCall.Name {
+			tool = &tools[i]
+		}
+	}
+	if tool == nil {
+		return "", fmt.Errorf("unknown tool %q", toolCall.Name)
+	}
+
+	arguments := strings.TrimSpace(toolCall.Arguments)
+	if arguments == "" {
+		arguments = "{}"
+	}
+	if !json.Valid([]byte(arguments)) {
+		return "", errors.New("arguments are not valid JSON")
+	}
+	input.Arguments = json.RawMessage(arguments)
+
+	ctx, cancel := context.WithTimeout(ctx, toolTimeout)
+	defer cancel()
+
+	return tool.Run(ctx, input)
+}
+
+// builtinTools returns the tools that every client has, besides the ones of its Config.
+func (c *Client) builtinTools() []Tool {
+	return []Tool{
+		{
+			ToolDefinition: ToolDefinition{
+				Name:        "current_time",
+				Description: "Get the current date and time, in the timezone of the chat or the given one.",
+				Parameters: json.RawMessage(`{
+					"type": "object",
+					"properties": {
+						"timezone": {
+							"type": "string",
+							"description": "IANA Time Zone database name, e.g. Europe/Madrid"
+						}
+					}
+				}`),
+			},
+			Run: runCurrentTimeTool,
+		},
+		c.remindersTool(),
+	}
+}
+
+func runCurrentTimeTool(ctx context.Context, input ToolInput) (string, error) {
+	var arguments struct {
+		Timezone string `json:"timezone"`
+	}
+	err := json.Unmarshal(input.Arguments, &arguments)
+	if err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+
+	location := input.Location
+	if arguments.Timezone != "" {
+		location, err = time.LoadLocation(arguments.Timezone)
+		if err != nil {
+			return "", fmt.Errorf("invalid timezone: %w", err)
+		}
+	}
+
+	return time.Now().In(location).Format("Monday, 2 January 2006 15:04:05 MST (-07:00)"), nil
+}
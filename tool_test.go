@@ -0,0 +1,282 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// scriptedCompleter is a Completer that responds with its messages in order, repeating the last one.
+type scriptedCompleter struct {
+	messages []CompletionMessage
+
+	mu       sync.Mutex
+	requests []CompletionRequest
+}
+
+func (s *scriptedCompleter) Complete(ctx context.Context, request CompletionRequest) (CompletionResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests = append(s.requests, request)
+
+	i := len(s.requests) - 1
+	if i >= len(s.messages) {
+		i = len(s.messages) - 1
+	}
+
+	return CompletionResponse{Message: s.messages[i]}, nil
+}
+
+// echoTool returns a tool that echoes its "text" argument, failing if it's empty.
+func echoTool() Tool {
+	return Tool{
+		ToolDefinition: ToolDefinition{
+			Name:       "echo",
+			Parameters: json.RawMessage(`{"type": "object"}`),
+		},
+		Run: func(ctx context.Context, input ToolInput) (string, error) {
+			var arguments struct {
+				Text string `json:"text"`
+			}
+			err := json.Unmarshal(input.Arguments, &arguments)
+			if err != nil {
+				return "", err
+			}
+			if arguments.Text == "" {
+				return "", errors.New("missing text")
+			}
+
+			return arguments.Text + " from " + input.ChatID, nil
+		},
+	}
+}
+
+func toolCallMessage(id, arguments string) CompletionMessage {
+	return CompletionMessage{
+		Role:      RoleAssistant,
+		ToolCalls: []ToolCall{{ID: id, Name: "echo", Arguments: arguments}},
+	}
+}
+
+// storeTrigger stores the message of the user of the chat of newTestChat whose response calls tools.
+func storeTrigger(t *testing.T, chat *Chat) {
+	t.Helper()
+
+	msg := newTestMessage("trigger", &waProto.Message{Conversation: proto.String("Echo hello")})
+	err := chat.storeMessageReceived(context.Background(), msg)
+	if err != nil {
+		t.Fatalf("failed to store message: %s", err)
+	}
+}
+
+// storedToolCalls returns the tool calls of the chat of newTestChat made while responding messageID.
+func storedToolCalls(t *testing.T, dataStore data.Store, messageID string) []data.ToolCall {
+	t.Helper()
+
+	ctx := context.Background()
+	tx, err := dataStore.BeginTx(ctx, sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		t.Fatalf("failed to begin transaction: %s", err)
+	}
+	defer func() {
+		_ = tx.Rollback(ctx)
+	}()
+
+	toolCalls, err := dataStore.ToolCalls(ctx, tx, testUserID, []string{messageID})
+	if err != nil {
+		t.Fatalf("failed to get tool calls: %s", err)
+	}
+
+	return toolCalls
+}
+
+func TestChatCompleteWithTools(t *testing.T) {
+	completer := &scriptedCompleter{
+		messages: []CompletionMessage{
+			toolCallMessage("call-1", `{"text": "hello"}`),
+			toolCallMessage("call-2", ``),
+			{Role: RoleAssistant, Content: "Done."},
+		},
+	}
+	chat, dataStore := newTestChat(t, &fakeWhatsApp{}, completer)
+	storeTrigger(t, chat)
+
+	request := CompletionRequest{
+		Model:    "model",
+		Messages: []CompletionMessage{{Role: RoleUser, Content: "Echo hello"}},
+	}
+	response, err := chat.completeWithTools(
+		context.Background(),
+		request,
+		[]Tool{echoTool()},
+		ToolInput{ChatID: chat.id},
+		"trigger",
+		nil,
+	)
+	if err != nil {
+		t.Fatalf("failed to complete: %s", err)
+	}
+	if response.Message.Content != "Done." {
+		t.Errorf("got response %+v, want the text one", response.Message)
+	}
+
+	if len(completer.requests) != 3 {
+		t.Fatalf("got %d completion requests, want 3", len(completer.requests))
+	}
+	if len(completer.requests[0].Tools) != 1 || completer.requests[0].Tools[0].Name != "echo" {
+		t.Errorf("got tools %+v, want the echo tool", completer.requests[0].Tools)
+	}
+	messages := completer.requests[2].Messages
+	if len(messages) != 5 {
+		t.Fatalf("got %d messages in the last request, want 5", len(messages))
+	}
+	if messages[1].ToolCalls[0].ID != "call-1" ||
+		messages[2].Role != RoleTool || messages[2].ToolCallID != "call-1" || messages[2].Content != "hello from "+testUserID {
+		t.Errorf("got messages %+v, want the first tool call and its result", messages[1:3])
+	}
+	if messages[4].Role != RoleTool || messages[4].ToolCallID != "call-2" || messages[4].Content != "Error: missing text" {
+		t.Errorf("got message %+v, want the error of the second tool call", messages[4])
+	}
+
+	toolCalls := storedToolCalls(t, dataStore, "trigger")
+	if len(toolCalls) != 2 {
+		t.Fatalf("got %d stored tool calls, want 2", len(toolCalls))
+	}
+	if toolCalls[0].Index != 0 || toolCalls[0].CallID != "call-1" || toolCalls[0].Failed ||
+		toolCalls[0].Result != "hello from "+testUserID {
+		t.Errorf("got stored tool call %+v, want the first one", toolCalls[0])
+	}
+	if toolCalls[1].Index != 1 || !toolCalls[1].Failed || toolCalls[1].Result != "missing text" {
+		t.Errorf("got stored tool call %+v, want the failed one", toolCalls[1])
+	}
+}
+
+func TestChatCompleteWithToolsLimit(t *testing.T) {
+	tests := []struct {
+		name       string
+		lastAnswer CompletionMessage
+		wantErr    bool
+	}{
+		{
+			name:       "text after the limit",
+			lastAnswer: CompletionMessage{Role: RoleAssistant, Content: "Done."},
+		},
+		{
+			name:       "tool calls after the limit",
+			lastAnswer: toolCallMessage("call", `{"text": "again"}`),
+			wantErr:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			messages := make([]CompletionMessage, 0, maxToolIterations+1)
+			for i := 0; i < maxToolIterations; i++ {
+				messages = append(messages, toolCallMessage("call", `{"text": "again"}`))
+			}
+			completer := &scriptedCompleter{messages: append(messages, tt.lastAnswer)}
+			chat, dataStore := newTestChat(t, &fakeWhatsApp{}, completer)
+			storeTrigger(t, chat)
+
+			response, err := chat.completeWithTools(
+				context.Background(),
+				CompletionRequest{Model: "model"},
+				[]Tool{echoTool()},
+				ToolInput{ChatID: chat.id},
+				"trigger",
+				nil,
+			)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %v", err, tt.wantErr)
+			}
+			if err == nil && response.Message.Content != "Done." {
+				t.Errorf("got response %+v, want the text one", response.Message)
+			}
+
+			if len(completer.requests) != maxToolIterations+1 {
+				t.Fatalf("got %d completion requests, want %d", len(completer.requests), maxToolIterations+1)
+			}
+			last := completer.requests[maxToolIterations]
+			note := last.Messages[len(last.Messages)-1]
+			if last.Tools != nil || note.Role != RoleSystem || note.Content != toolLimitNote {
+				t.Errorf("got last request %+v, want it without tools and with the limit note", last)
+			}
+
+			if n := len(storedToolCalls(t, dataStore, "trigger")); n != maxToolIterations {
+				t.Errorf("got %d stored tool calls, want %d", n, maxToolIterations)
+			}
+		})
+	}
+}
+
+func TestRunTool(t *testing.T) {
+	tools := []Tool{echoTool()}
+
+	tests := []struct {
+		name      string
+		toolCall  ToolCall
+		want      string
+		wantError string
+	}{
+		{name: "call", toolCall: ToolCall{Name: "echo", Arguments: `{"text": "hi"}`}, want: "hi from chat"},
+		{name: "empty arguments", toolCall: ToolCall{Name: "echo"}, wantError: "missing text"},
+		{name: "unknown tool", toolCall: ToolCall{Name: "nope"}, wantError: `unknown tool "nope"`},
+		{name: "invalid arguments", toolCall: ToolCall{Name: "echo", Arguments: `{"text":`}, wantError: "not valid JSON"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runTool(context.Background(), tools, tt.toolCall, ToolInput{ChatID: "chat"})
+			if tt.wantError != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantError) {
+					t.Fatalf("got error %v, want %q", err, tt.wantError)
+				}
+				return
+			}
+			if err != nil || got != tt.want {
+				t.Errorf("got %q and error %v, want %q", got, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewToolRegistry(t *testing.T) {
+	run := func(ctx context.Context, input ToolInput) (string, error) { return "", nil }
+
+	tests := []struct {
+		name    string
+		tools   []Tool
+		wantErr bool
+	}{
+		{name: "valid", tools: []Tool{{ToolDefinition: ToolDefinition{Name: "a"}, Run: run}}},
+		{name: "without name", tools: []Tool{{Run: run}}, wantErr: true},
+		{name: "without run", tools: []Tool{{ToolDefinition: ToolDefinition{Name: "a"}}}, wantErr: true},
+		{
+			name: "duplicate",
+			tools: []Tool{
+				{ToolDefinition: ToolDefinition{Name: "a"}, Run: run},
+				{ToolDefinition: ToolDefinition{Name: "a"}, Run: run},
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := newToolRegistry(tt.tools)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("got error %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
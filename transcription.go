@@ -0,0 +1,55 @@
+package chatbot
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+type TranscriptionRequest struct {
+	Model    string // Ignored by backends that serve a single model.
+	Audio    []byte
+	MIMEType string // E.g. "audio/ogg; codecs=opus" for WhatsApp voice messages.
+}
+
+type TranscriptionResponse struct {
+	Text string
+}
+
+// Transcriber is implemented by the speech-to-text backends that transcribe voice messages.
+type Transcriber interface {
+	Transcribe(ctx context.Context, request TranscriptionRequest) (TranscriptionResponse, error)
+}
+
+// transcribe downloads the audio of msg and sets its transcription as the text of its content. It
+// does nothing if msg has no audio or the client has no Transcriber.
+func (c *Chat) transcribe(msg *message) error {
+	audio := msg.content.audio
+	if audio == nil || c.client.transcriber == nil {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 60*time.Second)
+	defer cancel()
+
+	audioData, err := c.client.whatsmeowClient.Download(audio)
+	if err != nil {
+		return fmt.Errorf("failed to download audio: %w", err)
+	}
+
+	transcriptionResponse, err := c.client.transcriber.Transcribe(ctx, TranscriptionRequest{
+		Model:    c.client.transcriptionModel,
+		Audio:    audioData,
+		MIMEType: audio.GetMimetype(),
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get transcription response: %w", err)
+	}
+	metricTranscribedSeconds.Add(int64(audio.GetSeconds()))
+
+	msg.content.text = strings.TrimSpace(transcriptionResponse.Text)
+	msg.content.transcribed = true
+
+	return nil
+}
@@ -0,0 +1,125 @@
+package chatbot
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+)
+
+// fakeTranscriber is a Transcriber that records the requests to it and transcribes every audio as
+// text, or fails with err if it's not nil.
+type fakeTranscriber struct {
+	text string
+	err  error
+
+	requests []TranscriptionRequest
+}
+
+func (f *fakeTranscriber) Transcribe(ctx context.Context, request TranscriptionRequest) (TranscriptionResponse, error) {
+	f.requests = append(f.requests, request)
+	if f.err != nil {
+		return TranscriptionResponse{}, f.err
+	}
+
+	return TranscriptionResponse{Text: f.text}, nil
+}
+
+func TestChatHandleVoiceMessage(t *testing.T) {
+	whatsApp := &fakeWhatsApp{downloads: map[string][]byte{"/audio": []byte("audio data")}}
+	transcriber := &fakeTranscriber{text: " What time is it?\n"}
+	completer := &fakeCompleter{content: "It's noon."}
+	chat, dataStore := newTestChat(t, whatsApp, completer)
+	chat.client.transcriber = transcriber
+	chat.client.transcriptionModel = "transcription-model"
+
+	msg := newTestMessage("1", &waProto.Message{
+		AudioMessage: &waProto.AudioMessage{
+			Mimetype:   proto.String("audio/ogg; codecs=opus"),
+			DirectPath: proto.String("/audio"),
+			Seconds:    proto.Uint32(2),
+			Ptt:        proto.Bool(true),
+		},
+	})
+	err := chat.handleMessage(msg)
+	if err != nil {
+		t.Fatalf("failed to handle message: %s", err)
+	}
+
+	want := TranscriptionRequest{Model: "transcription-model", Audio: []byte("audio data"), MIMEType: "audio/ogg; codecs=opus"}
+	if !reflect.DeepEqual(transcriber.requests, []TranscriptionRequest{want}) {
+		t.Errorf("got transcription requests %+v, want %+v", transcriber.requests, want)
+	}
+
+	messages := storedMessages(t, dataStore)
+	if len(messages) != 2 || messages[0].Conversation != "What time is it?" || !messages[0].Transcribed {
+		t.Errorf("got stored messages %+v, want the trimmed transcription and the response", messages)
+	}
+
+	// The model is told that the message was a voice message.
+	request := completer.requests[0]
+	if last := request.Messages[len(request.Messages)-1]; last.Content != "(Voice message) What time is it?" {
+		t.Errorf("got last completion message %+v, want the transcription", last)
+	}
+}
+
+func TestChatHandleVoiceMessageErrors(t *testing.T) {
+	tests := []struct {
+		name        string
+		downloads   map[string][]byte
+		transcriber *fakeTranscriber
+	}{
+		{
+			name:        "failed download",
+			downloads:   map[string][]byte{},
+			transcriber: &fakeTranscriber{text: "Hi"},
+		},
+		{
+			name:        "failed transcription",
+			downloads:   map[string][]byte{"/audio": []byte("audio data")},
+			transcriber: &fakeTranscriber{err: errors.New("unsupported format")},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			whatsApp := &fakeWhatsApp{downloads: tt.downloads}
+			completer := &fakeCompleter{content: "Hello!"}
+			chat, dataStore := newTestChat(t, whatsApp, completer)
+			chat.client.transcriber = tt.transcriber
+
+			msg := newTestMessage("1", &waProto.Message{
+				AudioMessage: &waProto.AudioMessage{DirectPath: proto.String("/audio")},
+			})
+			err := chat.handleMessage(msg)
+			if err == nil {
+				t.Error("got no error, want one")
+			}
+
+			if len(completer.requests) != 0 || len(storedMessages(t, dataStore)) != 0 {
+				t.Error("got the voice message stored or responded, want it skipped")
+			}
+		})
+	}
+}
+
+func TestChatHandleVoiceMessageWithoutTranscriber(t *testing.T) {
+	completer := &fakeCompleter{content: "Hello!"}
+	chat, dataStore := newTestChat(t, &fakeWhatsApp{}, completer)
+
+	msg := newTestMessage("1", &waProto.Message{
+		AudioMessage: &waProto.AudioMessage{DirectPath: proto.String("/audio")},
+	})
+	err := chat.handleMessage(msg)
+	if err != nil {
+		t.Fatalf("failed to handle message: %s", err)
+	}
+
+	// Without text, the voice message is ignored.
+	if len(completer.requests) != 0 || len(storedMessages(t, dataStore)) != 0 {
+		t.Error("got the voice message stored or responded, want it ignored")
+	}
+}
@@ -0,0 +1,205 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+// userCommands are the commands that every user of an allowed chat can run. It's set in init
+// because the help command refers to it.
+var userCommands commandSet
+
+func init() {
+	userCommands = commandSet{
+		{
+			name:        "reset",
+			description: "Start a new conversation, forgetting the previous messages.",
+			run:         (*Chat).runResetCommand,
+		},
+		{
+			name:        "undo",
+			description: "Forget the last message and its response.",
+			run:         (*Chat).runUndoCommand,
+		},
+		{
+			name:        "imagine",
+			args:        "<prompt>",
+			description: "Generate an image from the prompt.",
+			timeout:     2 * time.Minute,
+			run:         (*Chat).runImagineCommand,
+		},
+		{
+			name:        "voice",
+			args:        "[on|off]",
+			description: "Turn voice replies on or off, or show whether they are on.",
+			run:         (*Chat).runVoiceCommand,
+		},
+		{
+			name:        "help",
+			description: "Show the available commands.",
+			run:         (*Chat).runHelpCommand,
+		},
+	}
+}
+
+// runResetCommand starts a new conversation segment in the chat. The messages of the previous
+// segments are kept in the data store, but they are not part of the prompts anymore.
+func (c *Chat) runResetCommand(ctx context.Context, msg message, args string) (string, error) {
+	err := c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		chat, err := c.client.store.Chat(ctx, tx, c.id)
+		if err != nil {
+			return fmt.Errorf("failed to get chat from data store: %w", err)
+		}
+
+		chat.Segment++
+
+		err = c.client.store.UpdateChat(ctx, tx, chat)
+		if err != nil {
+			return fmt.Errorf("failed to update chat in data store: %w", err)
+		}
+
+		// The summary covers the previous segments only.
+		err = c.client.store.DeleteSummary(ctx, tx, c.id)
+		if err != nil {
+			return fmt.Errorf("failed to delete summary from data store: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	return "Started a new conversation.", nil
+}
+
+// runUndoCommand deletes the last exchange of the current conversation segment from the data store.
+func (c *Chat) runUndoCommand(ctx context.Context, msg message, args string) (string, error) {
+	h, err := c.loadHistory(ctx)
+	if err != nil {
+		return "", fmt.Errorf("failed to get chat history: %w", err)
+	}
+
+	exchange := lastExchange(h.messages, c.client.botID())
+	if len(exchange) == 0 {
+		return "There is nothing to undo.", nil
+	}
+
+	err = c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		for _, m := range exchange {
+			err := c.client.store.DeleteMessage(ctx, tx, c.id, m.ID)
+			if err != nil {
+				return fmt.Errorf("failed to delete message %s from data store: %w", m.ID, err)
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	return fmt.Sprintf("Forgot the last %d messages.", len(exchange)), nil
+}
+
+// runVoiceCommand sets whether the chatbot replies with voice messages in the chat.
+func (c *Chat) runVoiceCommand(ctx context.Context, msg message, args string) (string, error) {
+	if c.client.synthesizer == nil {
+		return "Voice replies are not enabled.", nil
+	}
+
+	var voiceReplies bool
+	switch strings.ToLower(strings.TrimSpace(args)) {
+	case "on":
+		voiceReplies = true
+	case "off":
+		voiceReplies = false
+	case "":
+		var chat data.Chat
+		err := c.client.execTx(ctx, sql.TxOptions{
+			Isolation: sql.LevelReadCommitted,
+			ReadOnly:  true,
+		}, func(tx data.Tx) error {
+			var err error
+			chat, err = c.client.store.Chat(ctx, tx, c.id)
+			if err != nil {
+				return fmt.Errorf("failed to get chat from data store: %w", err)
+			}
+			return nil
+		})
+		if err != nil {
+			return "", fmt.Errorf("failed to execute data store transaction: %w", err)
+		}
+
+		if chat.VoiceReplies {
+			return "Voice replies are on.", nil
+		}
+		return "Voice replies are off.", nil
+	default:
+		return "", fmt.Errorf(`%w: %q is neither "on" nor "off"`, errInvalidArgs, args)
+	}
+
+	err := c.client.execTx(ctx, sql.TxOptions{
+		Isolation: sql.LevelReadCommitted,
+		ReadOnly:  false,
+	}, func(tx data.Tx) error {
+		chat, err := c.client.store.Chat(ctx, tx, c.id)
+		if err != nil {
+			return fmt.Errorf("failed to get chat from data store: %w", err)
+		}
+
+		chat.VoiceReplies = voiceReplies
+
+		err = c.client.store.UpdateChat(ctx, tx, chat)
+		if err != nil {
+			return fmt.Errorf("failed to update chat in data store: %w", err)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to execute data store transaction: %w", err)
+	}
+
+	if voiceReplies {
+		return "Turned voice replies on.", nil
+	}
+	return "Turned voice replies off.", nil
+}
+
+func (c *Chat) runHelpCommand(ctx context.Context, msg message, args string) (string, error) {
+	help := userCommands.help(c.client.commandPrefix)
+	if c.client.isAdmin(msg) {
+		help += "\n\nAdmin commands:\n" + adminCommands.help(c.client.commandPrefix)
+	}
+
+	return help, nil
+}
+
+// lastExchange returns the last messages of the users and the responses of the chatbot to them,
+// including the messages that haven't been responded yet, if any.
+func lastExchange(messages []data.Message, botID string) []data.Message {
+	i := len(messages)
+	for i > 0 && messages[i-1].SenderID != botID {
+		i--
+	}
+	for i > 0 && messages[i-1].SenderID == botID {
+		i--
+	}
+	for i > 0 && messages[i-1].SenderID != botID {
+		i--
+	}
+
+	return messages[i:]
+}
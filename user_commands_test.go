@@ -0,0 +1,111 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func TestLastExchange(t *testing.T) {
+	tests := []struct {
+		name      string
+		senderIDs []string
+		want      []string // IDs of the messages.
+	}{
+		{name: "empty"},
+		{name: "responded", senderIDs: []string{testUserID, testBotID, testUserID, testBotID}, want: []string{"3", "4"}},
+		{
+			name:      "several messages and parts",
+			senderIDs: []string{testUserID, testBotID, testUserID, testUserID, testBotID, testBotID},
+			want:      []string{"3", "4", "5", "6"},
+		},
+		{
+			// The message that wasn't responded is forgotten with the last exchange.
+			name:      "not responded",
+			senderIDs: []string{testUserID, testBotID, testUserID},
+			want:      []string{"1", "2", "3"},
+		},
+		{name: "only responses", senderIDs: []string{testBotID, testBotID}, want: []string{"1", "2"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var messages []data.Message
+			for i, senderID := range tt.senderIDs {
+				messages = append(messages, data.Message{ID: string(rune('1' + i)), SenderID: senderID})
+			}
+
+			var got []string
+			for _, m := range lastExchange(messages, testBotID) {
+				got = append(got, m.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got messages %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatRunUndoCommand(t *testing.T) {
+	chat, dataStore := newTestChat(t, &fakeWhatsApp{}, &fakeCompleter{})
+	createMessages(t, chat, testUserID, testBotID, testUserID, testBotID)
+
+	wantReplies := []string{"Forgot the last 2 messages.", "Forgot the last 2 messages.", "There is nothing to undo."}
+	wantRemaining := []int{2, 0, 0}
+	for i, want := range wantReplies {
+		reply, err := chat.runUndoCommand(context.Background(), message{}, "")
+		if err != nil {
+			t.Fatalf("failed to run undo command: %s", err)
+		}
+		if reply != want {
+			t.Errorf("got reply %q to undo %d, want %q", reply, i+1, want)
+		}
+
+		messages := storedMessages(t, dataStore)
+		if len(messages) != wantRemaining[i] {
+			t.Errorf("got %d stored messages after undo %d, want %d", len(messages), i+1, wantRemaining[i])
+		}
+	}
+}
+
+func TestChatRunResetCommand(t *testing.T) {
+	ctx := context.Background()
+	chat, dataStore := newTestChat(t, &fakeWhatsApp{}, &fakeCompleter{})
+	createMessages(t, chat, testUserID, testBotID)
+
+	err := chat.client.execTx(ctx, sql.TxOptions{}, func(tx data.Tx) error {
+		return dataStore.UpsertSummary(ctx, tx, data.Summary{
+			ChatID:       testUserID,
+			Content:      "The user greeted the chatbot.",
+			CoveredUntil: time.Now(),
+		})
+	})
+	if err != nil {
+		t.Fatalf("failed to create summary: %s", err)
+	}
+
+	reply, err := chat.runResetCommand(ctx, message{}, "")
+	if err != nil {
+		t.Fatalf("failed to run reset command: %s", err)
+	}
+	if reply != "Started a new conversation." {
+		t.Errorf("got reply %q, want the conversation to be started", reply)
+	}
+
+	h, err := chat.loadHistory(ctx)
+	if err != nil {
+		t.Fatalf("failed to load history: %s", err)
+	}
+	if h.chat.Segment != 1 || len(h.messages) != 0 || h.summary.Content != "" {
+		t.Errorf("got history %+v, want a new segment without messages or summary", h)
+	}
+
+	// The messages of the previous segment are kept.
+	if messages := storedMessages(t, dataStore); len(messages) != 2 {
+		t.Errorf("got %d stored messages, want 2", len(messages))
+	}
+}
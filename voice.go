@@ -0,0 +1,94 @@
+package chatbot
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+
+	"go.mau.fi/whatsmeow"
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"go.mau.fi/whatsmeow/types/events"
+	"google.golang.org/protobuf/proto"
+)
+
+// voiceMIMEType is the MIME type of WhatsApp voice messages, which the Synthesizer implementations
+// must return.
+const voiceMIMEType = "audio/ogg; codecs=opus"
+
+type SpeechRequest struct {
+	Model string // Ignored by backends that serve a single model.
+	Voice string // Empty for the default voice of the backend.
+	Text  string
+}
+
+type SpeechResponse struct {
+	Audio []byte // Ogg Opus, the format of WhatsApp voice messages.
+}
+
+// Synthesizer is implemented by the text-to-speech backends that turn the responses into voice
+// messages for the chats with voice replies.
+type Synthesizer interface {
+	Synthesize(ctx context.Context, request SpeechRequest) (SpeechResponse, error)
+}
+
+func (c *Client) repliesWithVoice(voiceReplies bool) bool {
+	return voiceReplies && c.synthesizer != nil
+}
+
+// voiceMessage synthesizes text and uploads it as a voice message, which quotes the given message if
+// it's not nil.
+func (c *Chat) voiceMessage(ctx context.Context, text string, quoted *events.Message) (*waProto.Message, error) {
+	speechResponse, err := c.client.synthesizer.Synthesize(ctx, SpeechRequest{
+		Model: c.client.speechModel,
+		Voice: c.client.voice,
+		Text:  text,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get speech response: %w", err)
+	}
+
+	seconds, err := oggDuration(speechResponse.Audio)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get audio duration: %w", err)
+	}
+	metricSynthesizedSeconds.Add(int64(seconds))
+
+	uploadResponse, err := c.client.whatsmeowClient.Upload(ctx, speechResponse.Audio, whatsmeow.MediaAudio)
+	if err != nil {
+		return nil, fmt.Errorf("failed to upload audio: %w", err)
+	}
+
+	audioMessage := &waProto.AudioMessage{
+		Url:           proto.String(uploadResponse.URL),
+		DirectPath:    proto.String(uploadResponse.DirectPath),
+		MediaKey:      uploadResponse.MediaKey,
+		Mimetype:      proto.String(voiceMIMEType),
+		FileEncSha256: uploadResponse.FileEncSHA256,
+		FileSha256:    uploadResponse.FileSHA256,
+		FileLength:    proto.Uint64(uploadResponse.FileLength),
+		Seconds:       proto.Uint32(seconds),
+		Ptt:           proto.Bool(true),
+	}
+	if quoted != nil {
+		audioMessage.ContextInfo = quoteContextInfo(quoted)
+	}
+
+	return &waProto.Message{
+		AudioMessage: audioMessage,
+	}, nil
+}
+
+// oggDuration returns the duration in seconds, rounded up, of Ogg Opus audio, which is the granule
+// position of its last page: the number of samples at 48 kHz, including the pre-skip ones.
+func oggDuration(audio []byte) (uint32, error) {
+	i := bytes.LastIndex(audio, []byte("OggS"))
+	if i < 0 || len(audio) < i+14 {
+		return 0, errors.New("audio is not Ogg")
+	}
+
+	granulePosition := binary.LittleEndian.Uint64(audio[i+6 : i+14])
+
+	return uint32((granulePosition + 47999) / 48000), nil
+}
@@ -0,0 +1,183 @@
+package chatbot
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+
+	waProto "go.mau.fi/whatsmeow/binary/proto"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/happybydefault/chatbot/data"
+)
+
+func TestOggDuration(t *testing.T) {
+	tests := []struct {
+		name    string
+		audio   []byte
+		want    uint32
+		wantErr bool
+	}{
+		{
+			name:  "one second",
+			audio: []byte("OggS\x00\x04\x80\xbb\x00\x00\x00\x00\x00\x00"),
+			want:  1,
+		},
+		{
+			// The granule position of the last page is the one that counts.
+			name:  "last page rounded up",
+			audio: []byte("OggS\x00\x02\x00\x00\x00\x00\x00\x00\x00\x00payloadOggS\x00\x04\x81\xbb\x00\x00\x00\x00\x00\x00payload"),
+			want:  2,
+		},
+		{
+			name:  "empty",
+			audio: []byte("OggS\x00\x04\x00\x00\x00\x00\x00\x00\x00\x00"),
+			want:  0,
+		},
+		{
+			name:    "not Ogg",
+			audio:   []byte("RIFF\x00\x00\x00\x00WAVE"),
+			wantErr: true,
+		},
+		{
+			name:    "truncated header",
+			audio:   []byte("OggS\x00\x04\x80\xbb"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := oggDuration(tt.audio)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("got error %v, want error %t", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("got %d seconds, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChatRunVoiceCommand(t *testing.T) {
+	whatsApp := &fakeWhatsApp{}
+	chat, dataStore := newTestChat(t, whatsApp, &fakeCompleter{})
+	chat.client.commandPrefix = "/"
+	chat.client.synthesizer = &fakeSynthesizer{}
+
+	tests := []struct {
+		text      string
+		want      string
+		wantVoice bool
+	}{
+		{text: "/voice", want: "Voice replies are off."},
+		{text: "/voice on", want: "Turned voice replies on.", wantVoice: true},
+		{text: "/voice", want: "Voice replies are on.", wantVoice: true},
+		{text: "/voice maybe", want: `Failed to run /voice: invalid arguments: "maybe" is neither "on" nor "off".`, wantVoice: true},
+		{text: "/voice OFF", want: "Turned voice replies off."},
+	}
+
+	for i, tt := range tests {
+		msg := newTestMessage("command", &waProto.Message{Conversation: proto.String(tt.text)})
+		err := chat.handleMessage(msg)
+		if err != nil {
+			t.Fatalf("failed to handle %q: %s", tt.text, err)
+		}
+
+		sent := whatsApp.sentTexts()
+		if len(sent) != i+1 || sent[i] != tt.want {
+			t.Fatalf("sent %q in response to %q, want %q", sent[len(sent)-1], tt.text, tt.want)
+		}
+
+		chat, err := storedChat(t, dataStore, testUserID)
+		if err != nil {
+			t.Fatalf("failed to get chat: %s", err)
+		}
+		if chat.VoiceReplies != tt.wantVoice {
+			t.Errorf("got voice replies %t after %q, want %t", chat.VoiceReplies, tt.text, tt.wantVoice)
+		}
+	}
+}
+
+func TestChatRunVoiceCommandWithoutSynthesizer(t *testing.T) {
+	whatsApp := &fakeWhatsApp{}
+	chat, _ := newTestChat(t, whatsApp, &fakeCompleter{})
+	chat.client.commandPrefix = "/"
+
+	msg := newTestMessage("command", &waProto.Message{Conversation: proto.String("/voice on")})
+	err := chat.handleMessage(msg)
+	if err != nil {
+		t.Fatalf("failed to handle message: %s", err)
+	}
+
+	if sent := whatsApp.sentTexts(); len(sent) != 1 || sent[0] != "Voice replies are not enabled." {
+		t.Errorf("sent %q, want voice replies not to be enabled", sent)
+	}
+}
+
+func TestChatRespondVoice(t *testing.T) {
+	tests := []struct {
+		name           string
+		synthesizerErr error
+		wantVoice      bool
+	}{
+		{name: "voice", wantVoice: true},
+		{name: "failed synthesis", synthesizerErr: errors.New("voice not found"), wantVoice: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			whatsApp := &fakeWhatsApp{}
+			synthesizer := &fakeSynthesizer{err: tt.synthesizerErr}
+			chat, dataStore := newTestChat(t, whatsApp, &fakeCompleter{content: "Hello, User!"})
+			chat.client.synthesizer = synthesizer
+			chat.client.speechModel = "speech-model"
+			chat.client.voice = "alloy"
+			chat.client.quoteMode = QuoteAlways
+
+			err := chat.client.execTx(ctx, sql.TxOptions{}, func(tx data.Tx) error {
+				return dataStore.UpdateChat(ctx, tx, data.Chat{ID: testUserID, VoiceReplies: true})
+			})
+			if err != nil {
+				t.Fatalf("failed to update chat: %s", err)
+			}
+
+			msg := newTestMessage("1", &waProto.Message{Conversation: proto.String("Hi")})
+			err = chat.storeMessageReceived(ctx, msg)
+			if err != nil {
+				t.Fatalf("failed to store message: %s", err)
+			}
+
+			err = chat.respond(msg.Message)
+			if err != nil {
+				t.Fatalf("failed to respond: %s", err)
+			}
+
+			want := SpeechRequest{Model: "speech-model", Voice: "alloy", Text: "Hello, User!"}
+			if len(synthesizer.requests) != 1 || synthesizer.requests[0] != want {
+				t.Errorf("got speech requests %+v, want %+v", synthesizer.requests, want)
+			}
+
+			if len(whatsApp.sent) != 1 {
+				t.Fatalf("sent %d messages, want 1", len(whatsApp.sent))
+			}
+			sent := whatsApp.sent[0]
+			if audio := sent.GetAudioMessage(); tt.wantVoice {
+				if !audio.GetPtt() || audio.GetSeconds() != 1 || audio.GetMimetype() != voiceMIMEType ||
+					audio.GetContextInfo().GetStanzaId() != "1" {
+					t.Errorf("sent %v, want a voice message of 1 second replying to the message", sent)
+				}
+			} else if sent.GetExtendedTextMessage().GetText() != "Hello, User!" {
+				t.Errorf("sent %v, want the response as text", sent)
+			}
+
+			// The text of the response is stored either way.
+			messages := storedMessages(t, dataStore)
+			if len(messages) != 2 || messages[1].Conversation != "Hello, User!" || messages[1].QuotedID != "1" {
+				t.Errorf("got stored messages %+v, want the response replying to the message", messages)
+			}
+		})
+	}
+}
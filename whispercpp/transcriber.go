@@ -0,0 +1,103 @@
+// Package whispercpp implements chatbot.Transcriber with the HTTP server of whisper.cpp, so that voice
+// messages can be transcribed locally. The server must be started with --convert to accept the
+// formats that WhatsApp uses, which requires ffmpeg.
+package whispercpp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"github.com/happybydefault/chatbot"
+)
+
+type Config struct {
+	URL        string       // Base URL of the server, e.g. "http://localhost:8080".
+	HTTPClient *http.Client // Defaults to http.DefaultClient.
+}
+
+// Transcriber is a chatbot.Transcriber backed by a whisper.cpp server. It serves a single model, so
+// the model of the requests is ignored.
+type Transcriber struct {
+	url        string
+	httpClient *http.Client
+}
+
+func NewTranscriber(cfg Config) *Transcriber {
+	httpClient := cfg.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &Transcriber{
+		url:        strings.TrimSuffix(cfg.URL, "/") + "/inference",
+		httpClient: httpClient,
+	}
+}
+
+func (t *Transcriber) Transcribe(ctx context.Context, request chatbot.TranscriptionRequest) (chatbot.TranscriptionResponse, error) {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+
+	part, err := writer.CreateFormFile("file", "audio")
+	if err != nil {
+		return chatbot.TranscriptionResponse{}, fmt.Errorf("failed to create form file: %w", err)
+	}
+	_, err = part.Write(request.Audio)
+	if err != nil {
+		return chatbot.TranscriptionResponse{}, fmt.Errorf("failed to write form file: %w", err)
+	}
+
+	err = writer.WriteField("response_format", "json")
+	if err != nil {
+		return chatbot.TranscriptionResponse{}, fmt.Errorf("failed to write form field: %w", err)
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return chatbot.TranscriptionResponse{}, fmt.Errorf("failed to close multipart writer: %w", err)
+	}
+
+	httpRequest, err := http.NewRequestWithContext(ctx, http.MethodPost, t.url, &body)
+	if err != nil {
+		return chatbot.TranscriptionResponse{}, fmt.Errorf("failed to create request: %w", err)
+	}
+	httpRequest.Header.Set("Content-Type", writer.FormDataContentType())
+
+	httpResponse, err := t.httpClient.Do(httpRequest)
+	if err != nil {
+		return chatbot.TranscriptionResponse{}, fmt.Errorf("failed to send request: %w", err)
+	}
+	defer httpResponse.Body.Close()
+
+	if httpResponse.StatusCode != http.StatusOK {
+		message, _ := io.ReadAll(io.LimitReader(httpResponse.Body, 1024))
+		return chatbot.TranscriptionResponse{}, fmt.Errorf(
+			"unexpected response status %s: %s",
+			httpResponse.Status,
+			strings.TrimSpace(string(message)),
+		)
+	}
+
+	var response struct {
+		Text  string `json:"text"`
+		Error string `json:"error"`
+	}
+	err = json.NewDecoder(httpResponse.Body).Decode(&response)
+	if err != nil {
+		return chatbot.TranscriptionResponse{}, fmt.Errorf("failed to decode response: %w", err)
+	}
+	// Some errors, e.g. unsupported audio formats, are reported with an OK status.
+	if response.Error != "" {
+		return chatbot.TranscriptionResponse{}, fmt.Errorf("server error: %s", response.Error)
+	}
+
+	return chatbot.TranscriptionResponse{
+		Text: response.Text,
+	}, nil
+}
@@ -0,0 +1,117 @@
+package whispercpp
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/happybydefault/chatbot"
+)
+
+// newTestTranscriber returns a Transcriber of a server that mimics whisper.cpp with handler, under a
+// base URL with a trailing slash.
+func newTestTranscriber(t *testing.T, handler http.HandlerFunc) *Transcriber {
+	t.Helper()
+
+	server := httptest.NewServer(handler)
+	t.Cleanup(server.Close)
+
+	return NewTranscriber(Config{URL: server.URL + "/"})
+}
+
+func TestTranscriberTranscribe(t *testing.T) {
+	var method, path, responseFormat, audio string
+
+	transcriber := newTestTranscriber(t, func(w http.ResponseWriter, r *http.Request) {
+		method = r.Method
+		path = r.URL.Path
+
+		err := r.ParseMultipartForm(1 << 20)
+		if err != nil {
+			t.Errorf("failed to parse multipart form: %s", err)
+			return
+		}
+		responseFormat = r.FormValue("response_format")
+
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			t.Errorf("failed to get form file: %s", err)
+			return
+		}
+		defer file.Close()
+
+		data, err := io.ReadAll(file)
+		if err != nil {
+			t.Errorf("failed to read form file: %s", err)
+		}
+		audio = string(data)
+
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprint(w, `{"text": " Hello there.\n"}`)
+	})
+
+	response, err := transcriber.Transcribe(context.Background(), chatbot.TranscriptionRequest{
+		Model:    "ignored",
+		Audio:    []byte("audio data"),
+		MIMEType: "audio/ogg; codecs=opus",
+	})
+	if err != nil {
+		t.Fatalf("failed to transcribe: %s", err)
+	}
+
+	if method != http.MethodPost || path != "/inference" {
+		t.Errorf("got request %s %s, want POST /inference", method, path)
+	}
+	if responseFormat != "json" || audio != "audio data" {
+		t.Errorf("got response format %q and audio %q, want json and the audio", responseFormat, audio)
+	}
+	if response.Text != " Hello there.\n" {
+		t.Errorf("got text %q, want the one of the server", response.Text)
+	}
+}
+
+func TestTranscriberTranscribeErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{
+			name:    "error status",
+			status:  http.StatusInternalServerError,
+			body:    "failed to load model\n",
+			wantErr: "unexpected response status 500 Internal Server Error: failed to load model",
+		},
+		{
+			name:    "error with OK status",
+			status:  http.StatusOK,
+			body:    `{"error": "failed to read audio data"}`,
+			wantErr: "server error: failed to read audio data",
+		},
+		{
+			name:    "invalid response",
+			status:  http.StatusOK,
+			body:    "not JSON",
+			wantErr: "failed to decode response",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			transcriber := newTestTranscriber(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			})
+
+			_, err := transcriber.Transcribe(context.Background(), chatbot.TranscriptionRequest{Audio: []byte("audio data")})
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("got error %v, want one containing %q", err, tt.wantErr)
+			}
+		})
+	}
+}